@@ -0,0 +1,233 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+//roles a user can have, each role can do everything the roles before it can
+const (
+	RoleCashier = "cashier"
+	RoleManager = "manager"
+	RoleAdmin   = "admin"
+)
+
+var roleRank = map[string]int{
+	RoleCashier: 1,
+	RoleManager: 2,
+	RoleAdmin:   3,
+}
+
+//how long an issued token stays valid
+const TokenLifetime = 12 * time.Hour
+
+//how long a stream token stays valid, it is only checked when the event stream is opened
+const StreamTokenLifetime = time.Minute
+
+//audience of the tokens that can only open the event stream
+const streamAudience = "events"
+
+//error returned when a token is missing, malformed, expired or badly signed
+var ErrInvalidToken = errors.New("invalid token")
+
+//error returned by a Directory for a user that no longer exists
+var ErrUnknownUser = errors.New("unknown user")
+
+//Directory looks up the current role of a user, a token can outlive a change of role or the user itself
+type Directory interface {
+	Role(userId int) (string, error)
+}
+
+//directory every authenticated request is checked against
+var directory Directory
+
+//function to set the directory the role of a token is checked against, it must be set before serving
+func UseDirectory(d Directory) {
+	directory = d
+}
+
+//Claims are the fields carried by a signed token
+type Claims struct {
+	UserId   int    `json:"user_id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+type contextKey struct{}
+
+//function to check whether a role name is supported
+func ValidRole(role string) bool {
+	_, ok := roleRank[role]
+	return ok
+}
+
+//function to hash a password for storage
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+//function to compare a password with its stored hash
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+//hash of a password no user has, with the cost of HashPassword
+const unknownUserHash = "$2a$10$Nh5LbaxHQE9zJsyY3mg94ODvoLBn6Ch0g9TK3RAdmwl/vZZjaRVLO"
+
+//function to reject the password of a username that does not exist
+//it still runs a comparison so the response time does not tell which usernames exist
+func RejectPassword(password string) {
+	CheckPassword(unknownUserHash, password)
+}
+
+//function to read the signing secret, tokens cannot be issued without one
+func secret() ([]byte, error) {
+	key := os.Getenv("AUTH_SECRET")
+	if key == "" {
+		return nil, errors.New("AUTH_SECRET is not set")
+	}
+	return []byte(key), nil
+}
+
+//function to issue a signed token for a user
+func IssueToken(userId int, username string, role string) (string, time.Time, error) {
+	return issue(userId, username, role, TokenLifetime, nil)
+}
+
+//function to issue a short lived token that only opens the event stream
+//it is meant for the query string of an EventSource, where it can end up in access logs
+func IssueStreamToken(claims *Claims) (string, time.Time, error) {
+	return issue(claims.UserId, claims.Username, claims.Role, StreamTokenLifetime, jwt.ClaimStrings{streamAudience})
+}
+
+//function to sign the claims of a user valid for lifetime
+func issue(userId int, username string, role string, lifetime time.Duration, audience jwt.ClaimStrings) (string, time.Time, error) {
+	key, err := secret()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiresAt := time.Now().Add(lifetime)
+	claims := Claims{
+		UserId:   userId,
+		Username: username,
+		Role:     role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   username,
+			Audience:  audience,
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(key)
+	return token, expiresAt, err
+}
+
+//function to tell whether a token can only open the event stream
+func (c *Claims) streamOnly() bool {
+	for _, audience := range c.Audience {
+		if audience == streamAudience {
+			return true
+		}
+	}
+	return false
+}
+
+//function to verify a token and return its claims
+func ParseToken(token string) (*Claims, error) {
+	key, err := secret()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	parsed, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		return key, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !parsed.Valid || !ValidRole(claims.Role) {
+		return nil, ErrInvalidToken
+	}
+	return claims, nil
+}
+
+//function to wrap a streaming handler like Require, browsers cannot set headers on an EventSource
+//so a stream token from IssueStreamToken may be given in the access_token query parameter instead
+func RequireStream(role string, next http.HandlerFunc) http.HandlerFunc {
+	guarded := Require(role, next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("access_token")
+		if token == "" || r.Header.Get("Authorization") != "" {
+			guarded(w, r)
+			return
+		}
+
+		//a full token in the query string would be written to the logs of every proxy on the way
+		claims, err := ParseToken(token)
+		if err != nil || !claims.streamOnly() {
+			http.Error(w, "Invalid or expired stream token", http.StatusUnauthorized)
+			return
+		}
+		authorize(w, r, claims, role, next)
+	}
+}
+
+//function to return the claims of the authenticated user of a request
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+//function to wrap a handler so it only runs for users holding at least the given role
+//the token is read from the "Authorization: Bearer <token>" header
+func Require(role string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		token := strings.TrimPrefix(header, "Bearer ")
+		if header == "" || token == header {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		//stream tokens only open the event stream
+		claims, err := ParseToken(token)
+		if err != nil || claims.streamOnly() {
+			http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+			return
+		}
+		authorize(w, r, claims, role, next)
+	}
+}
+
+//function to run a handler for the user of claims when they hold at least the given role
+//the role is the current one of the directory, a deleted user is turned away even with a valid token
+func authorize(w http.ResponseWriter, r *http.Request, claims *Claims, role string, next http.HandlerFunc) {
+	if directory == nil {
+		http.Error(w, "No user directory is configured", http.StatusInternalServerError)
+		return
+	}
+	current, err := directory.Role(claims.UserId)
+	if err == ErrUnknownUser {
+		http.Error(w, "Invalid or expired token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	claims.Role = current
+
+	if roleRank[claims.Role] < roleRank[role] {
+		http.Error(w, "Insufficient permissions", http.StatusForbidden)
+		return
+	}
+	next(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+//directory of the tests, the role of each user id
+type roles map[int]string
+
+func (r roles) Role(userId int) (string, error) {
+	role, ok := r[userId]
+	if !ok {
+		return "", ErrUnknownUser
+	}
+	return role, nil
+}
+
+//function to set the directory of a test and restore the previous one afterwards
+func useRoles(t *testing.T, r roles) {
+	previous := directory
+	UseDirectory(r)
+	t.Cleanup(func() { UseDirectory(previous) })
+}
+
+func TestStreamTokens(t *testing.T) {
+	t.Setenv("AUTH_SECRET", "test-secret")
+	useRoles(t, roles{1: RoleCashier})
+
+	full, _, err := IssueToken(1, "alice", RoleCashier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseToken(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, _, err := IssueStreamToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		if _, found := FromContext(r.Context()); !found {
+			t.Error("handler ran without the claims of the user")
+		}
+	}
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		header  string
+		query   string
+		want    int
+	}{
+		{name: "api with full token", handler: Require(RoleCashier, ok), header: full, want: http.StatusOK},
+		{name: "api with stream token", handler: Require(RoleCashier, ok), header: stream, want: http.StatusUnauthorized},
+		{name: "stream with stream token in query", handler: RequireStream(RoleCashier, ok), query: stream, want: http.StatusOK},
+		{name: "stream with full token in query", handler: RequireStream(RoleCashier, ok), query: full, want: http.StatusUnauthorized},
+		{name: "stream with full token in header", handler: RequireStream(RoleCashier, ok), header: full, want: http.StatusOK},
+		{name: "stream with stream token in header", handler: RequireStream(RoleCashier, ok), header: stream, want: http.StatusUnauthorized},
+		{name: "stream without token", handler: RequireStream(RoleCashier, ok), want: http.StatusUnauthorized},
+		{name: "stream above the role of the token", handler: RequireStream(RoleManager, ok), query: stream, want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/events?access_token="+url.QueryEscape(tt.query), nil)
+			if tt.query == "" {
+				r = httptest.NewRequest(http.MethodGet, "/events", nil)
+			}
+			if tt.header != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.header)
+			}
+			w := httptest.NewRecorder()
+			tt.handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireChecksTheCurrentRole(t *testing.T) {
+	t.Setenv("AUTH_SECRET", "test-secret")
+	useRoles(t, roles{1: RoleCashier, 2: RoleManager})
+
+	tests := []struct {
+		name     string
+		userId   int
+		role     string
+		required string
+		want     int
+		wantRole string
+	}{
+		{name: "unchanged role", userId: 2, role: RoleManager, required: RoleManager, want: http.StatusOK, wantRole: RoleManager},
+		{name: "demoted since the token was issued", userId: 1, role: RoleAdmin, required: RoleManager, want: http.StatusForbidden},
+		{name: "demoted but still allowed", userId: 1, role: RoleAdmin, required: RoleCashier, want: http.StatusOK, wantRole: RoleCashier},
+		{name: "promoted since the token was issued", userId: 2, role: RoleCashier, required: RoleManager, want: http.StatusOK, wantRole: RoleManager},
+		{name: "deleted since the token was issued", userId: 3, role: RoleAdmin, required: RoleCashier, want: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _, err := IssueToken(tt.userId, "user", tt.role)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotRole string
+			handler := Require(tt.required, func(w http.ResponseWriter, r *http.Request) {
+				claims, _ := FromContext(r.Context())
+				gotRole = claims.Role
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if gotRole != tt.wantRole {
+				t.Errorf("role = %q, want %q", gotRole, tt.wantRole)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"piza_shop_billing/backend/models"
+)
+
+//error returned when another user already has the username
+var ErrUsernameTaken = errors.New("username is already taken")
+
+//function to hash the password of a new user and store it
+func CreateUser(db *sql.DB, user models.User) (models.User, error) {
+	var taken bool
+	if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE username = ?)", user.Username).Scan(&taken); err != nil {
+		return user, err
+	}
+	if taken {
+		return user, ErrUsernameTaken
+	}
+
+	hash, err := HashPassword(user.Password)
+	if err != nil {
+		return user, err
+	}
+	user.Password = ""
+	user.PasswordHash = hash
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = time.Now()
+
+	query := "INSERT INTO users(username,password_hash,role,created_at,updated_at) VALUES(?,?,?,?,?)"
+	result, err := db.Exec(query, user.Username, user.PasswordHash, user.Role, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		return user, err
+	}
+	if id, err := result.LastInsertId(); err == nil {
+		user.UserId = int(id)
+	}
+	return user, nil
+}
+
+//function to tell whether a user is the only admin left, user management would be locked without them
+func LastAdmin(db *sql.DB, userId string) (bool, error) {
+	var last bool
+	query := "SELECT role = ? AND (SELECT COUNT(*) FROM users WHERE role = ?) = 1 FROM users WHERE user_id = ?"
+	err := db.QueryRow(query, RoleAdmin, RoleAdmin, userId).Scan(&last)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return last, err
+}
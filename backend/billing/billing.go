@@ -0,0 +1,153 @@
+package billing
+
+import (
+	"time"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/loyalty"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/orders"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/promotions"
+	"piza_shop_billing/backend/tax"
+)
+
+//Totals are the amounts of an invoice, the subtotal is before discounts and charges,
+//tax is charged on the discounted amounts and the charges and the total is after all of them
+type Totals struct {
+	tax.Result
+	Discount  float64
+	Discounts []models.InvoiceDiscount
+	Charge    float64
+	Charges   []models.InvoiceCharge
+}
+
+//function to calculate the totals of invoice lines with the promotions and tax rates in force at a moment
+//coupons are the codes entered on the invoice, redemptions the loyalty rewards redeemed on it
+//and order the order type with the charges it adds
+func Summarize(rates []models.TaxRate, promos []models.Promotion, coupons []string, redemptions []models.LoyaltyRedemption, order orders.Order, items []models.InvoiceItem, at time.Time) Totals {
+	//the kind of each line decides which rate applies to it, modifiers are taxed like their own kind
+	//and combos like the items they are made of
+	var lines []tax.Line
+	for _, item := range items {
+		lines = append(lines, tax.ItemLines(item)...)
+	}
+
+	discounts := promotions.Apply(promos, items, coupons, at)
+
+	//loyalty rewards come off what the promotions left of each category
+	if len(redemptions) > 0 {
+		rewards := loyalty.Apply(redemptions, remaining(lines, discounts.Lines))
+		discounts.Discount = tax.Round(discounts.Discount + rewards.Discount)
+		discounts.Discounts = append(discounts.Discounts, rewards.Discounts...)
+		discounts.Lines = append(discounts.Lines, rewards.Lines...)
+	}
+
+	//the charges of the order type are added to what the discounts left
+	charges := orders.Apply(order, items, remaining(lines, discounts.Lines), at)
+
+	result := tax.Calculate(rates, append(append(lines, discounts.Lines...), charges.Lines...), at)
+	//tax.Calculate sums the discounted lines and the charges, the subtotal shown on the invoice is before both
+	result.SubTotal = tax.Round(result.SubTotal + discounts.Discount - charges.Charge)
+	return Totals{Result: result, Discount: discounts.Discount, Discounts: discounts.Discounts, Charge: charges.Charge, Charges: charges.Charges}
+}
+
+//function to add up what is left of each tax category once the discount lines are taken off
+func remaining(lines []tax.Line, discounts []tax.Line) map[string]float64 {
+	left := map[string]float64{}
+	for _, line := range lines {
+		left[line.Category] += line.Amount
+	}
+	for _, line := range discounts {
+		left[line.Category] += line.Amount
+	}
+	return left
+}
+
+//function to calculate the totals of an invoice from its items using the tax rates and promotions
+//in force when it was billed, so recalculating an old invoice reproduces its tax and discounts
+func CalculateTotals(db database.DBTX, invoiceId string) (Totals, error) {
+	var invoiceDate string
+	query := "SELECT " + database.Current.FormatDateTime("invoice_date") + " FROM invoices WHERE invoice_id = ?"
+	if err := db.QueryRow(query, invoiceId).Scan(&invoiceDate); err != nil {
+		return Totals{}, err
+	}
+	at, err := time.Parse(promotions.AtFormat, invoiceDate)
+	if err != nil {
+		return Totals{}, err
+	}
+
+	rates, err := tax.LoadRates(db)
+	if err != nil {
+		return Totals{}, err
+	}
+	promos, err := promotions.Load(db)
+	if err != nil {
+		return Totals{}, err
+	}
+	coupons, err := promotions.LoadCoupons(db, invoiceId)
+	if err != nil {
+		return Totals{}, err
+	}
+	//promotions already applied keep the terms they were applied with
+	applied, err := promotions.LoadApplied(db, invoiceId)
+	if err != nil {
+		return Totals{}, err
+	}
+	promos, coupons = promotions.Freeze(promos, applied, coupons)
+
+	redemptions, err := loyalty.LoadRedemptions(db, invoiceId)
+	if err != nil {
+		return Totals{}, err
+	}
+
+	order, err := orders.LoadOrder(db, invoiceId)
+	if err != nil {
+		return Totals{}, err
+	}
+
+	items, err := LoadItems(db, invoiceId)
+	if err != nil {
+		return Totals{}, err
+	}
+	return Summarize(rates, promos, coupons, redemptions, order, items, at), nil
+}
+
+//function to recalculate an invoice and store its totals with its tax and discount snapshots and its payment status
+//it fails with a payments.PaymentError when the new total is below what has already been paid and with
+//payments.ErrLocked when the invoice is paid, void or refunded, those keep the totals they were closed with
+func Recalculate(db database.DBTX, invoiceId string) (Totals, error) {
+	if err := payments.CheckOpen(db, invoiceId); err != nil {
+		return Totals{}, err
+	}
+	totals, err := CalculateTotals(db, invoiceId)
+	if err != nil {
+		return totals, err
+	}
+
+	query := "UPDATE invoices SET subtotal=?, discount=?, charge=?, tax=?, total=? WHERE invoice_id=?"
+	if _, err := db.Exec(query, totals.SubTotal, totals.Discount, totals.Charge, totals.Tax, totals.Total, invoiceId); err != nil {
+		return totals, err
+	}
+
+	// Record which tax rules and promotions produced the totals
+	if err := tax.SaveInvoiceTaxes(db, invoiceId, totals.Taxes); err != nil {
+		return totals, err
+	}
+	if err := promotions.SaveInvoiceDiscounts(db, invoiceId, totals.Discounts); err != nil {
+		return totals, err
+	}
+	if err := orders.SaveInvoiceCharges(db, invoiceId, totals.Charges); err != nil {
+		return totals, err
+	}
+
+	//the payments taken so far decide whether the invoice is paid
+	if err := payments.Settle(db, invoiceId, totals.Total); err != nil {
+		return totals, err
+	}
+	//an invoice paid in full earns its customer points
+	if err := loyalty.Award(db, invoiceId); err != nil {
+		return totals, err
+	}
+	return totals, nil
+}
@@ -0,0 +1,267 @@
+package billing
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+)
+
+//ComboLookup returns a combo with its slots or pricing.ErrUnknownItem when it does not exist
+type ComboLookup func(comboId string) (models.Combo, error)
+
+//ItemLookup returns the catalog details of an item of one kind or pricing.ErrUnknownItem
+type ItemLookup func(kind string, itemId string) (pricing.Item, error)
+
+//function to read every combo with its slots
+func LoadCombos(db database.DBTX) ([]models.Combo, error) {
+	return loadCombos(db, "ORDER BY combo_id")
+}
+
+//function to read one combo with its slots, it returns sql.ErrNoRows when the combo does not exist
+func LoadCombo(db database.DBTX, comboId string) (models.Combo, error) {
+	combos, err := loadCombos(db, "WHERE combo_id = ?", comboId)
+	if err != nil {
+		return models.Combo{}, err
+	}
+	if len(combos) == 0 {
+		return models.Combo{}, sql.ErrNoRows
+	}
+	return combos[0], nil
+}
+
+//function to read the combos matching a where or order by clause together with their slots
+func loadCombos(db database.DBTX, clause string, args ...interface{}) ([]models.Combo, error) {
+	results, err := db.Query("SELECT combo_id, name, description, price FROM combos "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	var combos []models.Combo
+	index := map[string]int{}
+	for results.Next() {
+		var combo models.Combo
+		if err := results.Scan(&combo.ComboId, &combo.Name, &combo.Description, &combo.Price); err != nil {
+			results.Close()
+			return nil, err
+		}
+		index[combo.ComboId] = len(combos)
+		combos = append(combos, combo)
+	}
+	results.Close()
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+	if len(combos) == 0 {
+		return combos, nil
+	}
+
+	//slots are read for every combo at once and kept in the order they were created
+	results, err = db.Query("SELECT combo_slot_id, combo_id, name, item_kind, size, quantity FROM combo_slots ORDER BY combo_slot_id")
+	if err != nil {
+		return nil, err
+	}
+	slots := map[int][2]int{}
+	for results.Next() {
+		var slot models.ComboSlot
+		var comboId string
+		if err := results.Scan(&slot.ComboSlotId, &comboId, &slot.Name, &slot.ItemKind, &slot.Size, &slot.Quantity); err != nil {
+			results.Close()
+			return nil, err
+		}
+		position, ok := index[comboId]
+		if !ok {
+			continue
+		}
+		slots[slot.ComboSlotId] = [2]int{position, len(combos[position].Slots)}
+		combos[position].Slots = append(combos[position].Slots, slot)
+	}
+	results.Close()
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+
+	results, err = db.Query("SELECT combo_slot_id, item_id FROM combo_slot_items ORDER BY combo_slot_id, item_id")
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+	for results.Next() {
+		var slotId int
+		var itemId string
+		if err := results.Scan(&slotId, &itemId); err != nil {
+			return nil, err
+		}
+		if at, ok := slots[slotId]; ok {
+			slot := &combos[at[0]].Slots[at[1]]
+			slot.ItemIds = append(slot.ItemIds, itemId)
+		}
+	}
+	return combos, results.Err()
+}
+
+//function to look up combos in the combos table
+func combosFrom(db database.DBTX) ComboLookup {
+	return func(comboId string) (models.Combo, error) {
+		combo, err := LoadCombo(db, comboId)
+		if err == sql.ErrNoRows {
+			return combo, pricing.ErrUnknownItem
+		}
+		return combo, err
+	}
+}
+
+//function to look up catalog items in the catalog tables
+func itemsFrom(db database.DBTX) ItemLookup {
+	return func(kind string, itemId string) (pricing.Item, error) {
+		return pricing.ResolveKind(db, kind, itemId)
+	}
+}
+
+//function to fill the slots of a combo with the chosen items and split the combo price over them
+//every slot must get exactly its quantity of allowed items, a slot that allows a single item is filled
+//with it when nothing is chosen, and the price is split in proportion to what the items cost on their own
+func FillCombo(combo models.Combo, chosen []models.ComboComponent, items ItemLookup, sizes SizeLookup) ([]models.ComboComponent, error) {
+	bySlot := map[int][]models.ComboComponent{}
+	for _, component := range chosen {
+		bySlot[component.ComboSlotId] = append(bySlot[component.ComboSlotId], component)
+	}
+	known := map[int]bool{}
+	for _, slot := range combo.Slots {
+		known[slot.ComboSlotId] = true
+	}
+	for _, component := range chosen {
+		if !known[component.ComboSlotId] {
+			return nil, ItemError(fmt.Sprintf("slot %d is not part of combo %s", component.ComboSlotId, combo.ComboId))
+		}
+	}
+
+	var components []models.ComboComponent
+	var weights []float64
+	total := 0.0
+	for _, slot := range combo.Slots {
+		picked := bySlot[slot.ComboSlotId]
+		if len(picked) == 0 && len(slot.ItemIds) == 1 {
+			picked = []models.ComboComponent{{ComboSlotId: slot.ComboSlotId, ItemId: slot.ItemIds[0], Quantity: slot.Quantity}}
+		}
+
+		count := 0
+		for _, component := range picked {
+			if component.Quantity == 0 {
+				component.Quantity = 1
+			}
+			if component.Quantity < 0 {
+				return nil, ItemError("the quantity of a combo component must be greater than zero")
+			}
+			if component.Size != "" && component.Size != slot.Size {
+				return nil, ItemError("slot " + slot.Name + " is served in size " + slot.Size)
+			}
+			if !allowedInSlot(slot, component.ItemId) {
+				return nil, ItemError("item " + component.ItemId + " cannot fill slot " + slot.Name)
+			}
+			item, err := items(slot.ItemKind, component.ItemId)
+			if err == pricing.ErrUnknownItem {
+				return nil, ItemError(slot.ItemKind + " " + component.ItemId + " not found")
+			}
+			if err != nil {
+				return nil, err
+			}
+			price, err := UnitPrice(item, slot.Size, models.InvoiceItem{}, sizes)
+			if err != nil {
+				return nil, err
+			}
+
+			component.SlotName = slot.Name
+			component.ItemKind = item.Kind
+			component.Name = item.Name
+			component.Size = slot.Size
+			components = append(components, component)
+			weights = append(weights, price*float64(component.Quantity))
+			total += price * float64(component.Quantity)
+			count += component.Quantity
+		}
+		if count != slot.Quantity {
+			return nil, ItemError(fmt.Sprintf("slot %s takes %d item(s) but %d were chosen", slot.Name, slot.Quantity, count))
+		}
+	}
+
+	//the last component takes the rounding difference so the parts add up to the combo price
+	remaining := combo.Price
+	for i := range components {
+		if i == len(components)-1 {
+			components[i].Price = math.Round(remaining*100) / 100
+			break
+		}
+		share := combo.Price / float64(len(components))
+		if total > 0 {
+			share = combo.Price * weights[i] / total
+		}
+		components[i].Price = math.Round(share*100) / 100
+		remaining -= components[i].Price
+	}
+	return components, nil
+}
+
+//function to tell whether an item can fill a slot, slots without a list take any item of their kind
+func allowedInSlot(slot models.ComboSlot, itemId string) bool {
+	if len(slot.ItemIds) == 0 {
+		return true
+	}
+	for _, allowed := range slot.ItemIds {
+		if allowed == itemId {
+			return true
+		}
+	}
+	return false
+}
+
+//function to price a combo line, the combo price covers its components and the line takes no modifiers
+func ComboLine(catalogItem pricing.Item, item models.InvoiceItem, combos ComboLookup, items ItemLookup, sizes SizeLookup) (models.InvoiceItem, error) {
+	if item.ParentItemId != nil || len(item.Modifiers) > 0 {
+		return item, ItemError("a combo line cannot have modifiers")
+	}
+	if item.Size != "" {
+		return item, ItemError("combos are not sold in sizes, the slots set the size of their pizzas")
+	}
+	if item.Configuration != nil {
+		return item, ItemError("only pizzas can be configured")
+	}
+
+	combo, err := combos(catalogItem.ItemId)
+	if err == pricing.ErrUnknownItem {
+		return item, ItemError("combo " + catalogItem.ItemId + " not found")
+	}
+	if err != nil {
+		return item, err
+	}
+	components, err := FillCombo(combo, item.Components, items, sizes)
+	if err != nil {
+		return item, err
+	}
+	item.Components = components
+	item.UnitPrice = combo.Price
+	return item, nil
+}
+
+//function to reject components on a line that is not a combo
+func CheckComponents(kind string, components []models.ComboComponent) error {
+	if len(components) > 0 && kind != pricing.KindCombo {
+		return ItemError("only combo lines have components")
+	}
+	return nil
+}
+
+//function to encode the components of a combo line for the components column, other lines store NULL
+func encodeComponents(components []models.ComboComponent) (interface{}, error) {
+	if len(components) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(components)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,227 @@
+package billing
+
+import (
+	"encoding/json"
+	"math"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+)
+
+//placements of a topping on a configured pizza
+const (
+	PlacementWhole = "whole"
+	PlacementLeft  = "left"
+	PlacementRight = "right"
+)
+
+//ToppingLookup returns the toppings linked to a pizza type with their catalog prices
+type ToppingLookup func(pizzaTypeId string) ([]models.LinkedTopping, error)
+
+//function to read the toppings linked to a pizza type, default toppings first
+func LinkedToppings(db database.DBTX, pizzaTypeId string) ([]models.LinkedTopping, error) {
+	query := `
+		SELECT t.topping_id, t.name, t.price, pt.is_default
+		FROM toppings t
+		INNER JOIN pizza_toppings pt ON t.topping_id = pt.topping_id
+		WHERE pt.pizza_type_id = ?
+		ORDER BY pt.is_default DESC, t.name
+	`
+	rows, err := db.Query(query, pizzaTypeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	toppings := []models.LinkedTopping{}
+	for rows.Next() {
+		var topping models.LinkedTopping
+		if err := rows.Scan(&topping.ToppingId, &topping.Name, &topping.Price, &topping.IsDefault); err != nil {
+			return nil, err
+		}
+		toppings = append(toppings, topping)
+	}
+	return toppings, rows.Err()
+}
+
+//function to look up linked toppings in the pizza_toppings table
+func toppingsFrom(db database.DBTX) ToppingLookup {
+	return func(pizzaTypeId string) ([]models.LinkedTopping, error) {
+		return LinkedToppings(db, pizzaTypeId)
+	}
+}
+
+//function to validate a configuration against the toppings linked to its pizza type and price it
+//only default toppings can be removed and only toppings offered on the pizza type can be added,
+//an added topping costs its price scaled by the size multiplier, or half of that on one half
+func Configure(pizza pricing.Item, config models.PizzaConfiguration, sizes SizeLookup, toppings ToppingLookup) (models.PizzaConfiguration, error) {
+	if pizza.Kind != pricing.KindPizza {
+		return config, ItemError("only pizzas can be configured")
+	}
+	config.PizzaTypeId = pizza.ItemId
+	config.Name = pizza.Name
+
+	basePrice, err := UnitPrice(pizza, config.Size, models.InvoiceItem{}, sizes)
+	if err != nil {
+		return config, err
+	}
+	toppingMultiplier := 1.0
+	if config.Size != "" {
+		if _, toppingMultiplier, err = sizes(pizza.ItemId, config.Size); err != nil {
+			return config, err
+		}
+	}
+
+	linked, err := toppings(pizza.ItemId)
+	if err != nil {
+		return config, err
+	}
+	offered := map[string]models.LinkedTopping{}
+	for _, topping := range linked {
+		offered[topping.ToppingId] = topping
+	}
+
+	removed := make([]models.ToppingChoice, 0, len(config.Removed))
+	seen := map[string]bool{}
+	for _, choice := range config.Removed {
+		topping, err := chooseTopping(pizza, choice, offered, seen)
+		if err != nil {
+			return config, err
+		}
+		if !topping.IsDefault {
+			return config, ItemError("topping " + choice.ToppingId + " is not a default topping of pizza " + pizza.ItemId)
+		}
+		//default toppings are part of the base price, leaving them off costs nothing
+		choice.Name = topping.Name
+		choice.Placement = placementOf(choice)
+		choice.Price = 0
+		removed = append(removed, choice)
+	}
+
+	added := make([]models.ToppingChoice, 0, len(config.Added))
+	seen = map[string]bool{}
+	unitPrice := basePrice
+	for _, choice := range config.Added {
+		topping, err := chooseTopping(pizza, choice, offered, seen)
+		if err != nil {
+			return config, err
+		}
+		choice.Name = topping.Name
+		choice.Placement = placementOf(choice)
+		choice.Price = pricing.ToppingPrice(topping.Price, toppingMultiplier)
+		if choice.Placement != PlacementWhole {
+			choice.Price = pricing.ToppingPrice(choice.Price, 0.5)
+		}
+		unitPrice += choice.Price
+		added = append(added, choice)
+	}
+
+	config.Removed = removed
+	config.Added = added
+	config.BasePrice = basePrice
+	config.UnitPrice = math.Round(unitPrice*100) / 100
+	return config, nil
+}
+
+//function to check one topping choice of a configuration, seen holds the toppings already chosen in the same list
+func chooseTopping(pizza pricing.Item, choice models.ToppingChoice, offered map[string]models.LinkedTopping, seen map[string]bool) (models.LinkedTopping, error) {
+	switch choice.Placement {
+	case "", PlacementWhole, PlacementLeft, PlacementRight:
+	default:
+		return models.LinkedTopping{}, ItemError("placement must be one of whole, left or right")
+	}
+	if seen[choice.ToppingId] {
+		return models.LinkedTopping{}, ItemError("topping " + choice.ToppingId + " is listed more than once")
+	}
+	seen[choice.ToppingId] = true
+
+	topping, ok := offered[choice.ToppingId]
+	if !ok {
+		return topping, ItemError("topping " + choice.ToppingId + " is not offered on pizza " + pizza.ItemId)
+	}
+	return topping, nil
+}
+
+//function to return the placement of a topping choice, the whole pizza unless a half is given
+func placementOf(choice models.ToppingChoice) string {
+	if choice.Placement == "" {
+		return PlacementWhole
+	}
+	return choice.Placement
+}
+
+//function to fill in the pizza type and size of a configured line from its configuration
+//lines without a configuration are returned unchanged
+func ConfiguredItem(item models.InvoiceItem) models.InvoiceItem {
+	if item.Configuration == nil {
+		return item
+	}
+	if item.ItemKind == "" {
+		item.ItemKind = pricing.KindPizza
+	}
+	if item.ItemId == "" {
+		item.ItemId = item.Configuration.PizzaTypeId
+	}
+	if item.Size == "" {
+		item.Size = item.Configuration.Size
+	}
+	return item
+}
+
+//function to apply a new configuration to a line being updated and keep the configuration
+//in step with the pizza type and size of the line
+func MergeConfiguration(item models.InvoiceItem, update *models.PizzaConfiguration) models.InvoiceItem {
+	if update != nil {
+		config := *update
+		if config.PizzaTypeId != "" {
+			item.ItemId = config.PizzaTypeId
+		}
+		if config.Size != "" {
+			item.Size = config.Size
+		}
+		item.Configuration = &config
+	}
+	if item.Configuration != nil {
+		config := *item.Configuration
+		config.PizzaTypeId = item.ItemId
+		config.Size = item.Size
+		item.Configuration = &config
+	}
+	return item
+}
+
+//function to price a configured pizza line, its toppings live in the configuration instead of modifier lines
+func ConfigureLine(catalogItem pricing.Item, item models.InvoiceItem, sizes SizeLookup, toppings ToppingLookup) (models.InvoiceItem, error) {
+	if item.ParentItemId != nil || len(item.Modifiers) > 0 {
+		return item, ItemError("a configured pizza carries its toppings in its configuration")
+	}
+	config := *item.Configuration
+	if config.PizzaTypeId != "" && config.PizzaTypeId != catalogItem.ItemId {
+		return item, ItemError("the configuration is for pizza " + config.PizzaTypeId + " but the line is for " + catalogItem.ItemId)
+	}
+	if config.Size != "" && config.Size != item.Size {
+		return item, ItemError("the configuration is for size " + config.Size + " but the line is for size " + item.Size)
+	}
+	config.Size = item.Size
+
+	configured, err := Configure(catalogItem, config, sizes, toppings)
+	if err != nil {
+		return item, err
+	}
+	item.Configuration = &configured
+	item.UnitPrice = configured.UnitPrice
+	return item, nil
+}
+
+//function to encode a configuration for the configuration column, lines without one store NULL
+func encodeConfiguration(config *models.PizzaConfiguration) (interface{}, error) {
+	if config == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,144 @@
+package billing
+
+import (
+	"database/sql"
+	"math"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/orders"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/promotions"
+	"piza_shop_billing/backend/tax"
+)
+
+//Discrepancy describes an invoice whose stored totals disagree with its items
+type Discrepancy struct {
+	InvoiceId        string  `json:"invoice_id"`
+	Status           string  `json:"status"`
+	StoredSubTotal   float64 `json:"stored_subtotal"`
+	StoredTax        float64 `json:"stored_tax"`
+	StoredTotal      float64 `json:"stored_total"`
+	ExpectedSubTotal float64 `json:"expected_subtotal"`
+	ExpectedTax      float64 `json:"expected_tax"`
+	ExpectedTotal    float64 `json:"expected_total"`
+}
+
+//amounts closer than half a cent are considered equal
+const tolerance = 0.005
+
+//function to tell whether the invoice of a discrepancy can be repaired, paid, void and refunded
+//invoices keep the totals they were closed with and are only reported
+func (d Discrepancy) Repairable() bool {
+	return d.Status == payments.StatusOpen
+}
+
+//function to find every invoice whose stored totals disagree with the sum of its items
+//open invoices are compared with a recalculation, closed invoices with the tax, discount and
+//charge snapshots they were closed with so a later change to the rates or promotions is not reported
+func FindDiscrepancies(db *sql.DB) ([]Discrepancy, error) {
+	results, err := db.Query("SELECT invoice_id, status, subtotal, tax, total FROM invoices ORDER BY invoice_id")
+	if err != nil {
+		return nil, err
+	}
+
+	//read all invoices first so the result set is closed before the per invoice queries run
+	var stored []Discrepancy
+	for results.Next() {
+		var d Discrepancy
+		if err := results.Scan(&d.InvoiceId, &d.Status, &d.StoredSubTotal, &d.StoredTax, &d.StoredTotal); err != nil {
+			results.Close()
+			return nil, err
+		}
+		stored = append(stored, d)
+	}
+	results.Close()
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+
+	var discrepancies []Discrepancy
+	for _, d := range stored {
+		var expected tax.Result
+		if d.Repairable() {
+			totals, err := CalculateTotals(db, d.InvoiceId)
+			if err != nil {
+				return nil, err
+			}
+			expected = totals.Result
+		} else if expected, err = snapshotTotals(db, d.InvoiceId); err != nil {
+			return nil, err
+		}
+		d.ExpectedSubTotal = expected.SubTotal
+		d.ExpectedTax = expected.Tax
+		d.ExpectedTotal = expected.Total
+
+		if differs(d.StoredSubTotal, d.ExpectedSubTotal) || differs(d.StoredTax, d.ExpectedTax) || differs(d.StoredTotal, d.ExpectedTotal) {
+			discrepancies = append(discrepancies, d)
+		}
+	}
+	return discrepancies, nil
+}
+
+//function to work out the totals of an invoice from its items and the snapshots stored against it
+func snapshotTotals(db database.DBTX, invoiceId string) (tax.Result, error) {
+	var result tax.Result
+	items, err := LoadItems(db, invoiceId)
+	if err != nil {
+		return result, err
+	}
+	for _, item := range items {
+		for _, line := range tax.ItemLines(item) {
+			result.SubTotal += line.Amount
+		}
+	}
+
+	discounts, err := promotions.LoadInvoiceDiscounts(db, invoiceId)
+	if err != nil {
+		return result, err
+	}
+	charges, err := orders.LoadInvoiceCharges(db, invoiceId)
+	if err != nil {
+		return result, err
+	}
+	if result.Taxes, err = tax.LoadInvoiceTaxes(db, invoiceId); err != nil {
+		return result, err
+	}
+
+	total := result.SubTotal
+	for _, discount := range discounts {
+		total -= discount.Amount
+	}
+	for _, charge := range charges {
+		total += charge.Amount
+	}
+	//inclusive tax is already part of the amounts, exclusive tax comes on top of them
+	for _, t := range result.Taxes {
+		result.Tax += t.TaxAmount
+		if !t.Inclusive {
+			total += t.TaxAmount
+		}
+	}
+	result.SubTotal = tax.Round(result.SubTotal)
+	result.Tax = tax.Round(result.Tax)
+	result.Total = tax.Round(total)
+	return result, nil
+}
+
+//function to recalculate and store the totals of a single open invoice in its own transaction
+//it fails with payments.ErrLocked when the invoice is paid, void or refunded
+func Repair(db *sql.DB, invoiceId string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := Recalculate(tx, invoiceId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func differs(a, b float64) bool {
+	return math.Abs(a-b) >= tolerance
+}
@@ -0,0 +1,448 @@
+package billing
+
+import (
+	"database/sql"
+	"encoding/json"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+)
+
+//ItemError is returned when an invoice line is invalid, as opposed to a database failure
+type ItemError string
+
+func (e ItemError) Error() string { return string(e) }
+
+//error returned when an invoice item id does not exist
+var ErrItemNotFound = ItemError("invoice item not found")
+
+const itemColumns = "invoice_item_id, invoice_id, parent_item_id, item_kind, item_id, item_name, size, quantity, unit_price, configuration, components"
+
+//function to load the items of an invoice as lines with their modifiers nested under them
+func LoadItems(db database.DBTX, invoiceId string) ([]models.InvoiceItem, error) {
+	results, err := db.Query("SELECT "+itemColumns+" FROM invoice_items WHERE invoice_id = ? ORDER BY invoice_item_id", invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	items, err := ScanItems(results)
+	if err != nil {
+		return nil, err
+	}
+	return BuildTree(items), nil
+}
+
+//function to scan rows selected with the item columns into a flat list of items
+func ScanItems(results *sql.Rows) ([]models.InvoiceItem, error) {
+	var items []models.InvoiceItem
+	for results.Next() {
+		var item models.InvoiceItem
+		var parentId sql.NullInt64
+		var configuration, components sql.NullString
+		if err := results.Scan(&item.InvoiceItemId, &item.InvoiceId, &parentId, &item.ItemKind, &item.ItemId, &item.Name, &item.Size, &item.Quantity, &item.UnitPrice, &configuration, &components); err != nil {
+			return nil, err
+		}
+		if parentId.Valid {
+			id := int(parentId.Int64)
+			item.ParentItemId = &id
+		}
+		if configuration.Valid {
+			item.Configuration = &models.PizzaConfiguration{}
+			if err := json.Unmarshal([]byte(configuration.String), item.Configuration); err != nil {
+				return nil, err
+			}
+		}
+		if components.Valid {
+			if err := json.Unmarshal([]byte(components.String), &item.Components); err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, item)
+	}
+	return items, results.Err()
+}
+
+//function to nest modifier lines under their parent and compute every line total
+//a modifier is billed once per unit of its parent line
+func BuildTree(items []models.InvoiceItem) []models.InvoiceItem {
+	var lines []models.InvoiceItem
+	index := map[int]int{}
+	for _, item := range items {
+		if item.ParentItemId == nil {
+			item.LineTotal = float64(item.Quantity) * item.UnitPrice
+			index[item.InvoiceItemId] = len(lines)
+			lines = append(lines, item)
+		}
+	}
+	for _, item := range items {
+		if item.ParentItemId == nil {
+			continue
+		}
+		position, ok := index[*item.ParentItemId]
+		if !ok {
+			//an orphaned modifier is still billed on its own
+			item.LineTotal = float64(item.Quantity) * item.UnitPrice
+			lines = append(lines, item)
+			continue
+		}
+		parent := &lines[position]
+		item.LineTotal = float64(parent.Quantity*item.Quantity) * item.UnitPrice
+		parent.Modifiers = append(parent.Modifiers, item)
+	}
+	return lines
+}
+
+//function to add a line and its modifiers to an invoice, priced from the catalog
+func AddItem(db database.DBTX, invoiceId int, item models.InvoiceItem) (models.InvoiceItem, error) {
+	item = ConfiguredItem(item)
+	if item.Quantity <= 0 {
+		return item, ItemError("quantity must be greater than zero")
+	}
+	if item.ItemKind != "" && !pricing.ValidKind(item.ItemKind) {
+		return item, ItemError("item_kind must be one of pizza, topping, beverage or combo")
+	}
+
+	//a modifier must hang off a top level line of the same invoice
+	var parent models.InvoiceItem
+	if item.ParentItemId != nil {
+		var configured bool
+		query := "SELECT item_kind, item_id, size, configuration IS NOT NULL FROM invoice_items WHERE invoice_item_id = ? AND invoice_id = ? AND parent_item_id IS NULL"
+		err := db.QueryRow(query, *item.ParentItemId, invoiceId).Scan(&parent.ItemKind, &parent.ItemId, &parent.Size, &configured)
+		if err == sql.ErrNoRows {
+			return item, ItemError("parent line not found on this invoice")
+		}
+		if err != nil {
+			return item, err
+		}
+		if configured {
+			return item, ItemError("a configured pizza carries its toppings in its configuration")
+		}
+	}
+
+	catalogItem, err := pricing.ResolveKind(db, item.ItemKind, item.ItemId)
+	if err == pricing.ErrUnknownItem {
+		return item, ItemError("item " + item.ItemId + " not found")
+	}
+	if err == pricing.ErrAmbiguousItem {
+		return item, ItemError("item " + item.ItemId + " is in more than one catalog, item_kind is required")
+	}
+	if err != nil {
+		return item, err
+	}
+	if err := CheckPlacement(catalogItem.Kind, parent.ItemKind, item.ParentItemId != nil); err != nil {
+		return item, err
+	}
+	if err := CheckComponents(catalogItem.Kind, item.Components); err != nil {
+		return item, err
+	}
+
+	item.InvoiceId = invoiceId
+	item.ItemKind = catalogItem.Kind
+	item.Name = catalogItem.Name
+	if catalogItem.Kind == pricing.KindCombo {
+		item, err = ComboLine(catalogItem, item, combosFrom(db), itemsFrom(db), sizesFrom(db))
+	} else if item.Configuration != nil {
+		item, err = ConfigureLine(catalogItem, item, sizesFrom(db), toppingsFrom(db))
+	} else {
+		item.UnitPrice, err = UnitPrice(catalogItem, item.Size, parent, sizesFrom(db))
+	}
+	if err != nil {
+		return item, err
+	}
+	configuration, err := encodeConfiguration(item.Configuration)
+	if err != nil {
+		return item, err
+	}
+	components, err := encodeComponents(item.Components)
+	if err != nil {
+		return item, err
+	}
+
+	query := "INSERT INTO invoice_items (invoice_id, parent_item_id, item_kind, item_id, item_name, size, quantity, unit_price, configuration, components) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := db.Exec(query, item.InvoiceId, item.ParentItemId, item.ItemKind, item.ItemId, item.Name, item.Size, item.Quantity, item.UnitPrice, configuration, components)
+	if err != nil {
+		return item, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return item, err
+	}
+	item.InvoiceItemId = int(id)
+
+	modifiers := item.Modifiers
+	item.Modifiers = nil
+	for _, modifier := range modifiers {
+		if len(modifier.Modifiers) > 0 {
+			return item, ItemError("modifiers cannot have modifiers of their own")
+		}
+		if modifier.Quantity == 0 {
+			modifier.Quantity = 1
+		}
+		modifier.ParentItemId = &item.InvoiceItemId
+		added, err := AddItem(db, invoiceId, modifier)
+		if err != nil {
+			return item, err
+		}
+		item.Modifiers = append(item.Modifiers, added)
+	}
+
+	return withLineTotals(item), nil
+}
+
+//function to change the catalog item, size, quantity, configuration or combo components of a line, its kind and parent stay the same
+//toppings on a pizza line are re-priced when the pizza changes
+//it returns the updated line and the id of the invoice it belongs to
+func UpdateItem(db database.DBTX, invoiceItemId string, update models.InvoiceItem) (models.InvoiceItem, string, error) {
+	var item models.InvoiceItem
+	var invoiceId string
+	var parentId sql.NullInt64
+	var configuration, components sql.NullString
+	query := "SELECT invoice_item_id, invoice_id, parent_item_id, item_kind, item_id, size, quantity, configuration, components FROM invoice_items WHERE invoice_item_id = ?"
+	err := db.QueryRow(query, invoiceItemId).Scan(&item.InvoiceItemId, &invoiceId, &parentId, &item.ItemKind, &item.ItemId, &item.Size, &item.Quantity, &configuration, &components)
+	if err == sql.ErrNoRows {
+		return item, "", ErrItemNotFound
+	}
+	if err != nil {
+		return item, "", err
+	}
+	if parentId.Valid {
+		id := int(parentId.Int64)
+		item.ParentItemId = &id
+	}
+	if configuration.Valid {
+		item.Configuration = &models.PizzaConfiguration{}
+		if err := json.Unmarshal([]byte(configuration.String), item.Configuration); err != nil {
+			return item, invoiceId, err
+		}
+	}
+	if components.Valid {
+		if err := json.Unmarshal([]byte(components.String), &item.Components); err != nil {
+			return item, invoiceId, err
+		}
+	}
+
+	if update.ItemId != "" {
+		item.ItemId = update.ItemId
+	}
+	if update.Size != "" {
+		item.Size = update.Size
+	}
+	if update.Quantity != 0 {
+		item.Quantity = update.Quantity
+	}
+	if item.Quantity <= 0 {
+		return item, invoiceId, ItemError("quantity must be greater than zero")
+	}
+	if update.Configuration != nil && item.ParentItemId != nil {
+		return item, invoiceId, ItemError("only pizza lines can be configured")
+	}
+	item = MergeConfiguration(item, update.Configuration)
+	//new components replace the items chosen for the combo
+	if update.Components != nil {
+		item.Components = update.Components
+	}
+
+	var parent models.InvoiceItem
+	if item.ParentItemId != nil {
+		query := "SELECT item_kind, item_id, size FROM invoice_items WHERE invoice_item_id = ?"
+		if err := db.QueryRow(query, *item.ParentItemId).Scan(&parent.ItemKind, &parent.ItemId, &parent.Size); err != nil && err != sql.ErrNoRows {
+			return item, invoiceId, err
+		}
+	}
+
+	//re-price the line from the catalog in case the item id changed
+	catalogItem, err := pricing.ResolveKind(db, item.ItemKind, item.ItemId)
+	if err == pricing.ErrUnknownItem {
+		return item, invoiceId, ItemError("item " + item.ItemId + " not found")
+	}
+	if err != nil {
+		return item, invoiceId, err
+	}
+	if err := CheckComponents(catalogItem.Kind, item.Components); err != nil {
+		return item, invoiceId, err
+	}
+	item.ItemKind = catalogItem.Kind
+	item.ItemId = catalogItem.ItemId
+	item.Name = catalogItem.Name
+	if catalogItem.Kind == pricing.KindCombo {
+		item, err = ComboLine(catalogItem, item, combosFrom(db), itemsFrom(db), sizesFrom(db))
+	} else if item.Configuration != nil {
+		item, err = ConfigureLine(catalogItem, item, sizesFrom(db), toppingsFrom(db))
+	} else {
+		item.UnitPrice, err = UnitPrice(catalogItem, item.Size, parent, sizesFrom(db))
+	}
+	if err != nil {
+		return item, invoiceId, err
+	}
+	encoded, err := encodeConfiguration(item.Configuration)
+	if err != nil {
+		return item, invoiceId, err
+	}
+	encodedComponents, err := encodeComponents(item.Components)
+	if err != nil {
+		return item, invoiceId, err
+	}
+
+	query = "UPDATE invoice_items SET item_kind=?, item_id=?, item_name=?, size=?, quantity=?, unit_price=?, configuration=?, components=? WHERE invoice_item_id=?"
+	if _, err := db.Exec(query, item.ItemKind, item.ItemId, item.Name, item.Size, item.Quantity, item.UnitPrice, encoded, encodedComponents, invoiceItemId); err != nil {
+		return item, invoiceId, err
+	}
+	if item.ItemKind == pricing.KindPizza {
+		if err := repriceModifiers(db, item); err != nil {
+			return item, invoiceId, err
+		}
+	}
+
+	items, err := LoadItems(db, invoiceId)
+	if err != nil {
+		return item, invoiceId, err
+	}
+	for _, line := range items {
+		if line.InvoiceItemId == item.InvoiceItemId {
+			return line, invoiceId, nil
+		}
+		for _, modifier := range line.Modifiers {
+			if modifier.InvoiceItemId == item.InvoiceItemId {
+				return modifier, invoiceId, nil
+			}
+		}
+	}
+	return item, invoiceId, nil
+}
+
+//function to delete a line together with its modifiers
+//it returns the id of the invoice the line belonged to
+func DeleteItem(db database.DBTX, invoiceItemId string) (string, error) {
+	var invoiceId string
+	err := db.QueryRow("SELECT invoice_id FROM invoice_items WHERE invoice_item_id = ?", invoiceItemId).Scan(&invoiceId)
+	if err == sql.ErrNoRows {
+		return "", ErrItemNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := db.Exec("DELETE FROM invoice_items WHERE parent_item_id = ?", invoiceItemId); err != nil {
+		return invoiceId, err
+	}
+	if _, err := db.Exec("DELETE FROM invoice_items WHERE invoice_item_id = ?", invoiceItemId); err != nil {
+		return invoiceId, err
+	}
+	return invoiceId, nil
+}
+
+//SizeLookup returns the price and topping multiplier of a pizza type in one size
+//or pricing.ErrUnknownSize when the pizza is not sold in that size
+type SizeLookup func(pizzaTypeId string, size string) (float64, float64, error)
+
+//function to look up sizes in the pizza_sizes table
+func sizesFrom(db database.DBTX) SizeLookup {
+	return func(pizzaTypeId string, size string) (float64, float64, error) {
+		return pricing.ResolveSize(db, pizzaTypeId, size)
+	}
+}
+
+//function to price a line from the catalog, pizzas in a size use the size matrix
+//and toppings follow the topping multiplier of the size of their pizza
+func UnitPrice(catalogItem pricing.Item, size string, parent models.InvoiceItem, lookup SizeLookup) (float64, error) {
+	if size != "" && catalogItem.Kind != pricing.KindPizza {
+		return 0, ItemError("only pizzas are sold in sizes")
+	}
+	switch catalogItem.Kind {
+	case pricing.KindPizza:
+		if size == "" {
+			return catalogItem.UnitPrice, nil
+		}
+		if !pricing.ValidSize(size) {
+			return 0, ItemError("size must be one of small, medium, large or custom")
+		}
+		price, _, err := lookup(catalogItem.ItemId, size)
+		if err == pricing.ErrUnknownSize {
+			return 0, ItemError("pizza " + catalogItem.ItemId + " is not sold in size " + size)
+		}
+		return price, err
+	case pricing.KindTopping:
+		if parent.Size == "" {
+			return catalogItem.UnitPrice, nil
+		}
+		_, toppingMultiplier, err := lookup(parent.ItemId, parent.Size)
+		//a size removed from the matrix after the pizza was billed no longer scales its toppings
+		if err == pricing.ErrUnknownSize {
+			return catalogItem.UnitPrice, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		return pricing.ToppingPrice(catalogItem.UnitPrice, toppingMultiplier), nil
+	}
+	return catalogItem.UnitPrice, nil
+}
+
+//function to re-price the toppings of a pizza line after its item or size changed
+func repriceModifiers(db database.DBTX, pizza models.InvoiceItem) error {
+	results, err := db.Query("SELECT invoice_item_id, item_kind, item_id FROM invoice_items WHERE parent_item_id = ?", pizza.InvoiceItemId)
+	if err != nil {
+		return err
+	}
+	//read every modifier before updating so the result set is closed first
+	var modifiers []models.InvoiceItem
+	for results.Next() {
+		var modifier models.InvoiceItem
+		if err := results.Scan(&modifier.InvoiceItemId, &modifier.ItemKind, &modifier.ItemId); err != nil {
+			results.Close()
+			return err
+		}
+		modifiers = append(modifiers, modifier)
+	}
+	results.Close()
+	if err := results.Err(); err != nil {
+		return err
+	}
+
+	for _, modifier := range modifiers {
+		catalogItem, err := pricing.ResolveKind(db, modifier.ItemKind, modifier.ItemId)
+		//a topping removed from the catalog keeps the price it was billed at
+		if err == pricing.ErrUnknownItem {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		price, err := UnitPrice(catalogItem, "", pizza, sizesFrom(db))
+		if err != nil {
+			return err
+		}
+		if _, err := db.Exec("UPDATE invoice_items SET unit_price=? WHERE invoice_item_id=?", price, modifier.InvoiceItemId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//function to check that a line of the given kind may sit at its place in the hierarchy
+func CheckPlacement(kind string, parentKind string, hasParent bool) error {
+	if kind == pricing.KindTopping {
+		if !hasParent {
+			return ItemError("toppings must be attached to a pizza line")
+		}
+		if parentKind != pricing.KindPizza {
+			return ItemError("toppings can only be attached to a pizza line")
+		}
+		return nil
+	}
+	if hasParent {
+		return ItemError("only toppings can be attached to another line")
+	}
+	return nil
+}
+
+//function to fill in the line totals of a freshly added line and its modifiers
+func withLineTotals(item models.InvoiceItem) models.InvoiceItem {
+	item.LineTotal = float64(item.Quantity) * item.UnitPrice
+	for i := range item.Modifiers {
+		item.Modifiers[i].LineTotal = float64(item.Quantity*item.Modifiers[i].Quantity) * item.Modifiers[i].UnitPrice
+	}
+	return item
+}
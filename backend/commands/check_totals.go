@@ -0,0 +1,61 @@
+package commands
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+
+	"piza_shop_billing/backend/billing"
+)
+
+//command to report, and optionally repair, invoices whose totals disagree with their items
+//only open invoices are repaired, paid, void and refunded invoices are reported and left as they were closed
+//usage: check-totals [-repair]
+func CheckTotals(db *sql.DB, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("check-totals", flag.ContinueOnError)
+	repair := flags.Bool("repair", false, "recalculate the totals of every inconsistent open invoice")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	discrepancies, err := billing.FindDiscrepancies(db)
+	if err != nil {
+		return err
+	}
+	if len(discrepancies) == 0 {
+		fmt.Fprintln(out, "All invoice totals are consistent")
+		return nil
+	}
+
+	locked, failed := 0, 0
+	for _, d := range discrepancies {
+		fmt.Fprintf(out, "invoice %s (%s): stored %.2f/%.2f/%.2f expected %.2f/%.2f/%.2f (subtotal/tax/total)\n",
+			d.InvoiceId, d.Status, d.StoredSubTotal, d.StoredTax, d.StoredTotal, d.ExpectedSubTotal, d.ExpectedTax, d.ExpectedTotal)
+
+		if !d.Repairable() {
+			locked++
+			continue
+		}
+		if *repair {
+			//a failed repair, such as a total that would fall below what was paid, does not stop the others
+			if err := billing.Repair(db, d.InvoiceId); err != nil {
+				failed++
+				fmt.Fprintf(out, "invoice %s: not repaired: %v\n", d.InvoiceId, err)
+				continue
+			}
+			fmt.Fprintf(out, "invoice %s: repaired\n", d.InvoiceId)
+		}
+	}
+
+	if !*repair && locked < len(discrepancies) {
+		fmt.Fprintf(out, "%d inconsistent open invoice(s), run with -repair to fix them\n", len(discrepancies)-locked)
+	}
+	if locked > 0 {
+		fmt.Fprintf(out, "%d inconsistent paid, void or refunded invoice(s) left unchanged\n", locked)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d inconsistent open invoice(s) could not be repaired", failed, len(discrepancies)-locked)
+	}
+	return nil
+}
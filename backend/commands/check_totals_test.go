@@ -0,0 +1,69 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/database/databasetest"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/repository"
+)
+
+func TestCheckTotalsRepairsPastAFailure(t *testing.T) {
+	db := databasetest.Open(t)
+	repos := repository.NewSQL(db)
+	if err := repos.Pizzas.Create(models.PizzaType{PizzaTypeId: "P1", Name: "Margherita", BasePrice: 10}); err != nil {
+		t.Fatal(err)
+	}
+	checkout := func(tenders ...models.Payment) string {
+		invoice, _, err := repos.Invoices.Create(models.Invoice{InvoiceDate: "2026-03-13 18:00:00", CustomerName: "Ana", Payments: tenders, UpdatedAt: time.Now()},
+			[]models.InvoiceItem{{ItemKind: pricing.KindPizza, ItemId: "P1", Quantity: 2}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return invoice.InvoiceId
+	}
+	exec := func(query string, args ...interface{}) {
+		if _, err := db.Exec(query, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	//partly paid, its line is now cheaper than what was paid so the recalculated total cannot be stored
+	underpaid := checkout(models.Payment{Tender: payments.TenderCard, Amount: 20})
+	exec("UPDATE invoice_items SET unit_price = 2.5 WHERE invoice_id = ?", underpaid)
+	//its stored total was tampered with and can be recalculated
+	tampered := checkout()
+	exec("UPDATE invoices SET total = 99 WHERE invoice_id = ?", tampered)
+	//closed invoices are only reported
+	paid := checkout(models.Payment{Tender: payments.TenderCash, Amount: 22})
+	exec("UPDATE invoices SET total = 98 WHERE invoice_id = ?", paid)
+
+	var out bytes.Buffer
+	err := CheckTotals(db, []string{"-repair"}, &out)
+	if err == nil || !strings.Contains(err.Error(), "1 of 2") {
+		t.Errorf("CheckTotals() error = %v, want 1 of 2 open invoices not repaired", err)
+	}
+	for _, want := range []string{
+		"invoice " + underpaid + ": not repaired: ",
+		"invoice " + tampered + ": repaired",
+		"invoice " + paid + " (paid)",
+		"1 inconsistent paid, void or refunded invoice(s) left unchanged",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output does not contain %q:\n%s", want, out.String())
+		}
+	}
+
+	var total float64
+	if err := db.QueryRow("SELECT total FROM invoices WHERE invoice_id = ?", tampered).Scan(&total); err != nil {
+		t.Fatal(err)
+	}
+	if total != 22 {
+		t.Errorf("repaired total = %v, want 22", total)
+	}
+}
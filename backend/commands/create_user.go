@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/models"
+)
+
+//command to create a user from the shell, used to bootstrap the first admin
+//usage: create-user -username <name> -password <password> [-role admin|manager|cashier]
+func CreateUser(db *sql.DB, args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("create-user", flag.ContinueOnError)
+	username := flags.String("username", "", "login name of the user")
+	password := flags.String("password", "", "password of the user")
+	role := flags.String("role", auth.RoleAdmin, "role of the user: admin, manager or cashier")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *username == "" || *password == "" {
+		return errors.New("-username and -password are required")
+	}
+	if !auth.ValidRole(*role) {
+		return fmt.Errorf("unknown role %q", *role)
+	}
+
+	user, err := auth.CreateUser(db, models.User{Username: *username, Password: *password, Role: *role})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Created %s %s with id %d\n", user.Role, user.Username, user.UserId)
+	return nil
+}
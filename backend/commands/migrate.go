@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"piza_shop_billing/backend/database"
+)
+
+//command to apply, revert or list the embedded schema migrations
+//usage: migrate up | migrate down [-steps n | n] | migrate status
+func Migrate(db *sql.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected up, down or status")
+	}
+	//only down takes arguments, anything else is a typo that must not run against the schema
+	if args[0] != "down" && len(args) > 1 {
+		return fmt.Errorf("migrate %s takes no arguments, got %q", args[0], strings.Join(args[1:], " "))
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := database.MigrateUp(db)
+		for _, migration := range applied {
+			fmt.Fprintf(out, "applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Fprintln(out, "The schema is up to date")
+		}
+		return nil
+
+	case "down":
+		flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+		steps := flags.Int("steps", 1, "number of migrations to revert")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		//the count can also be given on its own, as in migrate down 2
+		stepsSet := false
+		flags.Visit(func(f *flag.Flag) { stepsSet = stepsSet || f.Name == "steps" })
+		switch {
+		case flags.NArg() > 1 || (flags.NArg() == 1 && stepsSet):
+			return fmt.Errorf("usage: migrate down [-steps n | n], got %q", strings.Join(flags.Args(), " "))
+		case flags.NArg() == 1:
+			n, err := strconv.Atoi(flags.Arg(0))
+			if err != nil {
+				return fmt.Errorf("usage: migrate down [-steps n | n], %q is not a number", flags.Arg(0))
+			}
+			*steps = n
+		}
+		if *steps < 1 {
+			return errors.New("-steps must be at least 1")
+		}
+
+		reverted, err := database.MigrateDown(db, *steps)
+		for _, migration := range reverted {
+			fmt.Fprintf(out, "reverted %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(reverted) == 0 {
+			fmt.Fprintln(out, "No applied migrations to revert")
+		}
+		return nil
+
+	case "status":
+		states, err := database.MigrationStatus(db)
+		if err != nil {
+			return err
+		}
+		for _, state := range states {
+			status := "pending"
+			if state.Applied {
+				status = "applied " + state.AppliedAt
+			}
+			fmt.Fprintf(out, "%04d_%-30s %s\n", state.Version, state.Name, status)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown migrate action %q, expected up, down or status", args[0])
+}
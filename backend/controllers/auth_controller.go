@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/repository"
+)
+
+//function to log a user in and issue a signed token
+func Login(userRepo repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var credentials struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&credentials); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		user, err := userRepo.FindByUsername(credentials.Username)
+		if err != nil && err != repository.ErrNotFound {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		//unknown users and wrong passwords get the same answer, after the same amount of work
+		if err == repository.ErrNotFound {
+			auth.RejectPassword(credentials.Password)
+			http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+			return
+		}
+		if !auth.CheckPassword(user.PasswordHash, credentials.Password) {
+			http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+			return
+		}
+
+		token, expiresAt, err := auth.IssueToken(user.UserId, user.Username, user.Role)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := struct {
+			Token     string    `json:"token"`
+			ExpiresAt time.Time `json:"expires_at"`
+			UserId    int       `json:"user_id"`
+			Username  string    `json:"username"`
+			Role      string    `json:"role"`
+		}{token, expiresAt, user.UserId, user.Username, user.Role}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+//function to issue a short lived token the signed in user opens the event stream with
+//an EventSource cannot send the Authorization header, so the token goes in its access_token parameter
+func IssueStreamToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := auth.FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Authentication required", http.StatusUnauthorized)
+			return
+		}
+
+		token, expiresAt, err := auth.IssueStreamToken(claims)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := struct {
+			Token     string    `json:"token"`
+			ExpiresAt time.Time `json:"expires_at"`
+		}{token, expiresAt}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
@@ -1,21 +1,26 @@
 package controllers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"time"
 	"log"
 	"net/http"
 	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
 
 	"github.com/gorilla/mux"
 )
 
-//method to get all beverages returns a http.HandlerFunc
-func GetBeverages(db *sql.DB) http.HandlerFunc {
+//method to get a page of beverages, filtered and sorted like the invoice list, returns a http.HandlerFunc
+func GetBeverages(beverages repository.BeverageRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		query := "SELECT beverage_id,name,price FROM beverages"
-		results, err := db.Query(query)
+		q, ok := parseListing(w, r, repository.BeverageListing)
+		if !ok {
+			return
+		}
+
+		//fetch the beverages from the repository
+		beverageList, page, err := beverages.List(q)
 
 		//check if there is an error and return it to the client
 		if err != nil {
@@ -23,31 +28,12 @@ func GetBeverages(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		//defer is used to close the result set after the function returns
-		defer results.Close()
-
-		//create a slice of beverages
-		var beverages []models.Beverage
-		//iterate over the result set and append the beverages to the slice
-		for results.Next() {
-			var beverage models.Beverage
-			//scan the result set into the beverage struct and check for errors
-			if err := results.Scan(&beverage.BeverageId, &beverage.Name, &beverage.Price); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			//append the beverage to the slice
-			beverages = append(beverages, beverage)
-		}
-
-		//set the response header to application/json
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(beverages)
+		writePage(w, beverageList, page)
 	}
 }
 
 //method to create a beverage
-func CreateBeverage(db *sql.DB) http.HandlerFunc {
+func CreateBeverage(beverages repository.BeverageRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		//create a beverage struct
 		var beverage models.Beverage
@@ -59,11 +45,9 @@ func CreateBeverage(db *sql.DB) http.HandlerFunc {
 
 		beverage.CreatedAt = time.Now()
 		beverage.UpdatedAt = time.Now()
-		//create a query to insert the beverage into the database
-		query := "INSERT INTO beverages(beverage_id,name,price,created_at,updated_at) VALUES(?,?,?,?,?)"
-		//execute the query and check for errors
-		if _, err := db.Exec(query, beverage.BeverageId,beverage.Name, beverage.Price,beverage.CreatedAt,beverage.UpdatedAt); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		//store the beverage and check for errors
+		if err := beverages.Create(beverage); err != nil {
+			writeRepositoryError(w, err)
 			return
 		}
 		//set the response header to application/json
@@ -74,22 +58,17 @@ func CreateBeverage(db *sql.DB) http.HandlerFunc {
 }
 
 //method to update a beverage
-func UpdateBeverage(db *sql.DB) http.HandlerFunc {
+func UpdateBeverage(beverages repository.BeverageRepository) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
         // Extract beverage_id from URL path
         vars := mux.Vars(r)
         beverageId := vars["beverage_id"]
 
-        // Fetch the existing record from the database
-        var existingBeverage models.Beverage
-        query := "SELECT beverage_id, name,price FROM beverages WHERE beverage_id = ?"
-        if err := db.QueryRow(query, beverageId).Scan(
-            &existingBeverage.BeverageId,
-            &existingBeverage.Name,
-            &existingBeverage.Price,
-        ); err != nil {
-            log.Printf("Error fetching existing pizza type: %v", err)
-            http.Error(w, err.Error(), http.StatusInternalServerError)
+        // Fetch the existing record from the repository
+        existingBeverage, err := beverages.Get(beverageId)
+        if err != nil {
+            log.Printf("Error fetching existing beverage: %v", err)
+            writeRepositoryError(w, err)
             return
         }
 
@@ -111,19 +90,10 @@ func UpdateBeverage(db *sql.DB) http.HandlerFunc {
 		}
         existingBeverage.UpdatedAt = time.Now()
 
-        // Build the update query dynamically
-        updateQuery := "UPDATE beverages SET name=?, price=?,updated_at=? WHERE beverage_id=?"
-        args := []interface{}{
-            existingBeverage.Name,
-            existingBeverage.Price,
-            existingBeverage.UpdatedAt,
-            beverageId,
-        }
-
-        // Execute the query and check for errors
-        if _, err := db.Exec(updateQuery, args...); err != nil {
+        // Store the merged beverage and check for errors
+        if err := beverages.Update(existingBeverage); err != nil {
             log.Printf("Error executing update query: %v", err)
-            http.Error(w, err.Error(), http.StatusInternalServerError)
+            writeRepositoryError(w, err)
             return
         }
 
@@ -137,16 +107,14 @@ func UpdateBeverage(db *sql.DB) http.HandlerFunc {
     }
 }
 //method to delete a beverage
-func DeleteBeverage(db *sql.DB) http.HandlerFunc {
+func DeleteBeverage(beverages repository.BeverageRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// extract beverage_id from the request URL
 		vars := mux.Vars(r)
 		beverageId := vars["beverage_id"]
 
-		// create a query to delete the beverage from the database
-		query := "DELETE FROM beverages WHERE beverage_id = ?"
-		// execute the query and check for errors
-		if _, err := db.Exec(query, beverageId); err != nil {
+		// delete the beverage and check for errors
+		if err := beverages.Delete(beverageId); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
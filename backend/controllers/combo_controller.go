@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/repository"
+
+	"github.com/gorilla/mux"
+)
+
+//method to get all combos with their slots
+func GetCombos(combos repository.ComboRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := combos.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		//always answer with a list, even when no combo is set up
+		if list == nil {
+			list = []models.Combo{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+//method to get one combo with its slots
+func GetCombo(combos repository.ComboRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		combo, err := combos.Get(vars["combo_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(combo)
+	}
+}
+
+//method to create a combo, the response carries the ids of its slots
+func CreateCombo(combos repository.ComboRepository, pizzas repository.PizzaRepository, beverages repository.BeverageRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var combo models.Combo
+		if err := json.NewDecoder(r.Body).Decode(&combo); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if combo.ComboId == "" {
+			http.Error(w, "combo_id is required", http.StatusBadRequest)
+			return
+		}
+		combo.Slots = withSlotDefaults(combo.Slots)
+		if msg := validateCombo(combo, pizzas, beverages); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		combo.CreatedAt = time.Now()
+		combo.UpdatedAt = time.Now()
+		combo, err := combos.Create(combo)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(combo)
+	}
+}
+
+//method to update a combo, slots sent in the request replace the slots of the combo
+//and slots sent with their combo_slot_id keep it
+func UpdateCombo(combos repository.ComboRepository, pizzas repository.PizzaRepository, beverages repository.BeverageRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		comboId := vars["combo_id"]
+
+		existing, err := combos.Get(comboId)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		var update models.Combo
+		if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Merge the changes
+		if update.Name != "" {
+			existing.Name = update.Name
+		}
+		if update.Description != "" {
+			existing.Description = update.Description
+		}
+		if update.Price != 0 {
+			existing.Price = update.Price
+		}
+		if update.Slots != nil {
+			existing.Slots = withSlotDefaults(update.Slots)
+		}
+		if msg := validateCombo(existing, pizzas, beverages); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		existing.UpdatedAt = time.Now()
+		combo, err := combos.Update(existing)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(combo)
+	}
+}
+
+//method to delete a combo, combo lines already billed keep their components
+func DeleteCombo(combos repository.ComboRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		if err := combos.Delete(vars["combo_id"]); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Combo deleted successfully"})
+	}
+}
+
+//function to fill in the quantity of slots sent without one, a slot holds one item unless told otherwise
+func withSlotDefaults(slots []models.ComboSlot) []models.ComboSlot {
+	filled := make([]models.ComboSlot, 0, len(slots))
+	for _, slot := range slots {
+		if slot.Quantity == 0 {
+			slot.Quantity = 1
+		}
+		filled = append(filled, slot)
+	}
+	return filled
+}
+
+//function to validate a combo and its slots and return a message describing the first problem
+func validateCombo(combo models.Combo, pizzas repository.PizzaRepository, beverages repository.BeverageRepository) string {
+	if combo.Name == "" {
+		return "Combo name is required"
+	}
+	if combo.Price <= 0 {
+		return "Combo price must be greater than zero"
+	}
+	if len(combo.Slots) == 0 {
+		return "A combo needs at least one slot"
+	}
+
+	for i, slot := range combo.Slots {
+		if slot.Name == "" {
+			return fmt.Sprintf("slot %d: name is required", i+1)
+		}
+		if slot.Quantity < 0 {
+			return fmt.Sprintf("slot %d: quantity must be greater than zero", i+1)
+		}
+		switch slot.ItemKind {
+		case pricing.KindPizza:
+			if slot.Size != "" && !pricing.ValidSize(slot.Size) {
+				return fmt.Sprintf("slot %d: size must be one of small, medium, large or custom", i+1)
+			}
+		case pricing.KindBeverage:
+			if slot.Size != "" {
+				return fmt.Sprintf("slot %d: only pizza slots have a size", i+1)
+			}
+		default:
+			return fmt.Sprintf("slot %d: item_kind must be pizza or beverage", i+1)
+		}
+
+		seen := map[string]bool{}
+		for _, itemId := range slot.ItemIds {
+			if seen[itemId] {
+				return fmt.Sprintf("slot %d: item %s is listed more than once", i+1, itemId)
+			}
+			seen[itemId] = true
+			if msg := checkSlotItem(slot, itemId, pizzas, beverages); msg != "" {
+				return fmt.Sprintf("slot %d: %s", i+1, msg)
+			}
+		}
+	}
+	return ""
+}
+
+//function to check that an item allowed in a slot exists and, for sized pizza slots, is sold in the size of the slot
+func checkSlotItem(slot models.ComboSlot, itemId string, pizzas repository.PizzaRepository, beverages repository.BeverageRepository) string {
+	if slot.ItemKind == pricing.KindBeverage {
+		if _, err := beverages.Get(itemId); err != nil {
+			return "beverage " + itemId + " not found"
+		}
+		return ""
+	}
+
+	pizzaType, err := pizzas.Get(itemId)
+	if err != nil {
+		return "pizza type " + itemId + " not found"
+	}
+	if slot.Size == "" {
+		return ""
+	}
+	for _, size := range pizzaType.Sizes {
+		if size.Size == slot.Size {
+			return ""
+		}
+	}
+	return "pizza " + itemId + " is not sold in size " + slot.Size
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
+
+	"github.com/gorilla/mux"
+)
+
+//method to void an invoice, the invoice is kept and a credit note for its full amount is issued
+//the body carries the reason and the tender any money paid is handed back in
+func VoidInvoice(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return creditInvoice(invoices.Void)
+}
+
+//method to refund the lines of a paid invoice, every line that is left is refunded when none are listed
+func RefundInvoice(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return creditInvoice(invoices.Refund)
+}
+
+//function to build a handler that issues a credit note against the invoice of the request
+func creditInvoice(issue func(invoiceId string, request models.CreditNote) (models.Invoice, models.CreditNote, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var request models.CreditNote
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		invoice, note, err := issue(vars["invoice_id"], request)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		response := struct {
+			Invoice    models.Invoice    `json:"invoice"`
+			CreditNote models.CreditNote `json:"credit_note"`
+		}{
+			Invoice:    invoice,
+			CreditNote: note,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+//method to get the credit notes issued against an invoice
+func GetInvoiceCreditNotes(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		list, err := invoices.CreditNotes(vars["invoice_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+		if list == nil {
+			list = []models.CreditNote{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+//method to get one credit note with its lines
+func GetCreditNote(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		note, err := invoices.CreditNote(vars["credit_note_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(note)
+	}
+}
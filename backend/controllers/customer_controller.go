@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"piza_shop_billing/backend/listing"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
+
+	"github.com/gorilla/mux"
+)
+
+//number of favourite items returned when the request does not ask for a number
+const defaultFavourites = 10
+
+//method to get a page of customers, q finds them by name, phone or email
+func GetCustomers(customers repository.CustomerRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, ok := parseListing(w, r, repository.CustomerListing)
+		if !ok {
+			return
+		}
+		list, page, err := customers.List(q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writePage(w, list, page)
+	}
+}
+
+//method to get one customer with their addresses
+func GetCustomer(customers repository.CustomerRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		customer, err := customers.Get(vars["customer_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(customer)
+	}
+}
+
+//method to add a customer to the directory, the response carries the ids of the customer and their addresses
+func CreateCustomer(customers repository.CustomerRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var customer models.Customer
+		if err := json.NewDecoder(r.Body).Decode(&customer); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		customer = cleanCustomer(customer)
+		if msg := validateCustomer(customer); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		customer.CreatedAt = time.Now()
+		customer.UpdatedAt = time.Now()
+		customer, err := customers.Create(customer)
+		if err == repository.ErrDuplicate {
+			http.Error(w, "Phone number is already used by another customer", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(customer)
+	}
+}
+
+//method to update a customer, fields missing from the request keep their value
+//and addresses sent in the request replace the addresses of the customer
+func UpdateCustomer(customers repository.CustomerRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		existing, err := customers.Get(vars["customer_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		//decoding over the existing customer merges the changes
+		customer := existing
+		if err := json.NewDecoder(r.Body).Decode(&customer); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		customer.CustomerId = existing.CustomerId
+		customer.CreatedAt = existing.CreatedAt
+		customer = cleanCustomer(customer)
+		if msg := validateCustomer(customer); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		customer.UpdatedAt = time.Now()
+		customer, err = customers.Update(customer)
+		if err == repository.ErrDuplicate {
+			http.Error(w, "Phone number is already used by another customer", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(customer)
+	}
+}
+
+//method to remove a customer from the directory, their invoices keep the name they were billed to
+func DeleteCustomer(customers repository.CustomerRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		if err := customers.Delete(vars["customer_id"]); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Customer deleted successfully"})
+	}
+}
+
+//method to get a page of the invoices billed to a customer with what the customer spent in total
+//the invoices can be filtered and sorted like the invoice list
+func GetCustomerInvoices(customers repository.CustomerRepository, invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		customerId := vars["customer_id"]
+
+		customer, err := customers.Get(customerId)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+		q, ok := parseListing(w, r, repository.InvoiceListing)
+		if !ok {
+			return
+		}
+		q.Conditions = append(q.Conditions, listing.Condition{Field: "customer_id", Op: "eq", Value: strconv.Itoa(customer.CustomerId)})
+
+		invoiceList, page, err := invoices.List(q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		spend, err := customers.Spend(customerId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := struct {
+			listResponse
+			Spend models.CustomerSpend `json:"spend"`
+		}{
+			listResponse: listResponse{Items: invoiceList, Page: page},
+			Spend:        spend,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+//method to get the items a customer orders most, ?limit= sets how many are returned
+func GetCustomerFavourites(customers repository.CustomerRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		customerId := vars["customer_id"]
+
+		limit := defaultFavourites
+		if value := r.URL.Query().Get("limit"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > listing.MaxLimit {
+				http.Error(w, "limit must be a number from 1 to "+strconv.Itoa(listing.MaxLimit), http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+
+		if _, err := customers.Get(customerId); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+		favourites, err := customers.Favourites(customerId, limit)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(favourites)
+	}
+}
+
+//function to trim the fields of a customer and keep only the digits and a leading + of the phone number
+//so the same number typed differently finds the same customer
+func cleanCustomer(customer models.Customer) models.Customer {
+	customer.Name = strings.TrimSpace(customer.Name)
+	customer.Email = strings.ToLower(strings.TrimSpace(customer.Email))
+	customer.Notes = strings.TrimSpace(customer.Notes)
+	customer.Phone = normalizePhone(customer.Phone)
+	if customer.Addresses == nil {
+		customer.Addresses = []models.CustomerAddress{}
+	}
+	for i := range customer.Addresses {
+		customer.Addresses[i].Line1 = strings.TrimSpace(customer.Addresses[i].Line1)
+	}
+	return customer
+}
+
+//function to write a phone number the way it is stored, spaces, dashes, dots and brackets are dropped
+func normalizePhone(phone string) string {
+	var digits strings.Builder
+	for i, c := range strings.TrimSpace(phone) {
+		if (c >= '0' && c <= '9') || (c == '+' && i == 0) {
+			digits.WriteRune(c)
+		}
+	}
+	return digits.String()
+}
+
+//function to validate a customer and return a message describing the first problem
+func validateCustomer(customer models.Customer) string {
+	if customer.Name == "" {
+		return "Customer name is required"
+	}
+	if customer.Phone != "" && len(strings.TrimPrefix(customer.Phone, "+")) < 6 {
+		return "Phone number is too short"
+	}
+	if customer.Email != "" && !strings.Contains(customer.Email, "@") {
+		return "Email address is not valid"
+	}
+	for i, address := range customer.Addresses {
+		if address.Line1 == "" {
+			return "Address " + strconv.Itoa(i+1) + " needs its first line"
+		}
+	}
+	return ""
+}
+
+//function to check the customer an invoice is billed to, the name on the invoice is taken
+//from the directory when the request leaves it empty
+func billTo(customers repository.CustomerRepository, customerId int, customerName string) (string, error) {
+	if customerId == 0 {
+		return customerName, nil
+	}
+	customer, err := customers.Get(strconv.Itoa(customerId))
+	if err != nil {
+		return customerName, err
+	}
+	if strings.TrimSpace(customerName) == "" {
+		return customer.Name, nil
+	}
+	return customerName, nil
+}
@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"piza_shop_billing/backend/events"
+)
+
+//how often a comment is written to an idle stream so proxies keep the connection open
+const streamKeepAlive = 20 * time.Second
+
+//function to stream the events of the requested topics as server-sent events
+//topics is a comma separated list of invoices, items, payments and kitchen, all of them when it is empty
+//a client reconnecting with a Last-Event-ID header, or a last_event_id parameter, first gets the events it missed
+//and a resync event when some of them are no longer kept and it has to reload what it shows
+func StreamEvents(broker *events.Broker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		topics, unknown, ok := events.ParseTopics(r.URL.Query().Get("topics"))
+		if !ok {
+			http.Error(w, "Unknown topic "+unknown+", topics are invoices, items, payments and kitchen", http.StatusBadRequest)
+			return
+		}
+		lastEventId := r.Header.Get("Last-Event-ID")
+		if lastEventId == "" {
+			lastEventId = r.URL.Query().Get("last_event_id")
+		}
+		var lastId int64
+		if lastEventId != "" {
+			id, err := strconv.ParseInt(lastEventId, 10, 64)
+			if err != nil {
+				http.Error(w, "Last-Event-ID must be an event id", http.StatusBadRequest)
+				return
+			}
+			lastId = id
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+
+		subscription, backlog, complete := broker.Subscribe(topics, lastId)
+		defer broker.Unsubscribe(subscription)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if !complete {
+			fmt.Fprint(w, "event: resync\ndata: {}\n\n")
+		}
+		for _, event := range backlog {
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		keepAlive := time.NewTicker(streamKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case event, open := <-subscription.Events:
+				//a subscriber that fell behind is dropped, its client reconnects and catches up
+				if !open {
+					return
+				}
+				if err := writeEvent(w, event); err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+//function to write an event in the server-sent events format, the type names the event
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.Id, event.Type, data)
+	return err
+}
@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/promotions"
+	"piza_shop_billing/backend/repository"
+)
+
+//body of a checkout request, the customer plus every line of the bill
+//pizza lines carry their extra toppings as modifiers, tenders sent with the bill pay it straight away
+//and a customer_id bills the invoice to a customer of the directory
+//order_type is dine_in with a table_number, takeaway or delivery with a delivery address
+type checkoutRequest struct {
+	CustomerName string               `json:"customer_name"`
+	CustomerId   int                  `json:"customer_id"`
+	OrderType    string               `json:"order_type"`
+	TableNumber  string               `json:"table_number"`
+	Delivery     *models.Delivery     `json:"delivery"`
+	Items        []models.InvoiceItem `json:"items"`
+	CouponCodes  []string             `json:"coupon_codes"`
+	Payments     []models.Payment     `json:"payments"`
+}
+
+//function to create an invoice together with all of its items in one transaction
+func CreateInvoiceWithItems(invoices repository.InvoiceRepository, customers repository.CustomerRepository, orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var request checkoutRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(request.Items) == 0 {
+			http.Error(w, "An invoice needs at least one item", http.StatusBadRequest)
+			return
+		}
+
+		customerName, err := billTo(customers, request.CustomerId, request.CustomerName)
+		if err != nil {
+			writeCustomerError(w, err)
+			return
+		}
+
+		invoice := models.Invoice{
+			CustomerName: customerName,
+			CustomerId:   request.CustomerId,
+			OrderType:    request.OrderType,
+			TableNumber:  request.TableNumber,
+			Delivery:     request.Delivery,
+			InvoiceDate:  time.Now().Format(DateTimeFormat),
+			UpdatedAt:    time.Now(),
+			CouponCodes:  request.CouponCodes,
+			Payments:     request.Payments,
+		}
+		if invoice, err = orderDetails(orderRepo, customers, invoice); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+		//the invoice and its items are stored atomically with their totals
+		invoice, invoiceItems, err := invoices.Create(invoice, request.Items)
+		if _, ok := err.(billing.ItemError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := err.(promotions.CouponError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := err.(payments.PaymentError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := struct {
+			Invoice      models.Invoice       `json:"invoice"`
+			InvoiceItems []models.InvoiceItem `json:"invoice_items"`
+		}{
+			Invoice:      invoice,
+			InvoiceItems: invoiceItems,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+	}
+}
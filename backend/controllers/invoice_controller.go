@@ -2,46 +2,70 @@ package controllers
 
 import (
 	"encoding/json"
+	"log"
 	"net/http"
-	"database/sql"
 	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/creditnotes"
+	"piza_shop_billing/backend/kitchen"
+	"piza_shop_billing/backend/loyalty"
 	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/orders"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/receipt"
+	"piza_shop_billing/backend/repository"
+	"strconv"
+	"strings"
 	"time"
 )
 
 const DateTimeFormat = "2006-01-02 15:04:05"
 
-//function to return all invoices
-func GetInvoices(db *sql.DB) http.HandlerFunc {
+//function to return a page of invoices, filtered by date, customer, status or totals and sorted as requested
+//e.g. /invoices?invoice_date[gte]=2026-10-01&customer_name[like]=ann&status=paid&sort=-total&limit=20
+func GetInvoices(invoices repository.InvoiceRepository) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
-        query := "SELECT invoice_id,DATE_FORMAT(invoice_date,'%Y-%m-%d') AS invoice_date, subtotal, tax, total,customer_name FROM invoices"
-        results, err := db.Query(query)
+        q, ok := parseListing(w, r, repository.InvoiceListing)
+        if !ok {
+            return
+        }
+        invoiceList, page, err := invoices.List(q)
         if err != nil {
             http.Error(w, err.Error(), http.StatusInternalServerError)
             return
         }
-        defer results.Close()
-
-		//create a slice of invoices
-        var invoices []models.Invoice
-        for results.Next() {
-            var invoice models.Invoice
-            if err := results.Scan(&invoice.InvoiceId,&invoice.InvoiceDate,&invoice.SubTotal, &invoice.Tax, &invoice.Total,&invoice.CustomerName); err != nil {
-                http.Error(w, err.Error(), http.StatusInternalServerError)
-                return
-            }
-			//if no error append the invoice to the invoices slice
-            invoices = append(invoices, invoice)
-        }
 
 		//generate a json response object
-        w.Header().Set("Content-Type", "application/json")
-        json.NewEncoder(w).Encode(invoices)
+        writePage(w, invoiceList, page)
     }
 }
 
-//function to create a new invoice
-func CreateInvoice(db *sql.DB) http.HandlerFunc {
+//function to return one invoice, it can be looked up by its id or by the number printed on the receipt
+func GetInvoice(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		invoiceID := vars["invoice_id"]
+
+		var invoice models.Invoice
+		var err error
+		if _, convErr := strconv.Atoi(invoiceID); convErr == nil {
+			invoice, err = invoices.Get(invoiceID)
+		} else {
+			invoice, err = invoices.GetByNumber(strings.ToUpper(invoiceID))
+		}
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(invoice)
+	}
+}
+
+//function to create a new invoice, it can be billed to a customer of the directory
+//an invoice without an order_type is a takeaway order
+func CreateInvoice(invoices repository.InvoiceRepository, customers repository.CustomerRepository, orderRepo repository.OrderRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
         var invoice models.Invoice
         if err := json.NewDecoder(r.Body).Decode(&invoice); err != nil {
@@ -53,30 +77,35 @@ func CreateInvoice(db *sql.DB) http.HandlerFunc {
         invoice.SubTotal = 0.00
         invoice.Tax = 0.00
         invoice.Total = 0.00
+        //payments are taken once the invoice has items
+        invoice.Payments = nil
+		customerName, err := billTo(customers, invoice.CustomerId, invoice.CustomerName)
+		if err != nil {
+			writeCustomerError(w, err)
+			return
+		}
+		invoice.CustomerName = customerName
+		if invoice, err = orderDetails(orderRepo, customers, invoice); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
 		invoice.InvoiceDate = time.Now().Format(DateTimeFormat)
 		invoice.UpdatedAt = time.Now()
 
-        query := "INSERT INTO invoices (invoice_date, subtotal, tax, total,customer_name,updated_at) VALUES (?,?,?,?,?,?)"
-        _, err := db.Exec(query, invoice.InvoiceDate, invoice.SubTotal, invoice.Tax, invoice.Total,invoice.CustomerName, invoice.UpdatedAt)
+        // The stored invoice carries the generated id so the client can add items to it
+        invoice, _, err = invoices.Create(invoice, nil)
         if err != nil {
             http.Error(w, err.Error(), http.StatusInternalServerError)
             return
         }
 
-    
-        if err != nil {
-            http.Error(w, err.Error(), http.StatusInternalServerError)
-            return
-        }
-       
-
         w.Header().Set("Content-Type", "application/json")
         json.NewEncoder(w).Encode(invoice)
     }
 }
 
-//function to update an invoice
-func UpdateInvoice(db *sql.DB) http.HandlerFunc {
+//function to update who an invoice is billed to
+func UpdateInvoice(invoices repository.InvoiceRepository, customers repository.CustomerRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		invoiceID := vars["invoice_id"]
@@ -87,99 +116,44 @@ func UpdateInvoice(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		 // Update invoice details (excluding subtotal, tax, and total)
-		 query := "UPDATE invoices SET  customer_name=?, updated_at=NOW() WHERE invoice_id=?"
-		 _, err := db.Exec(query,invoice.CustomerName, invoiceID)
+		 customerName, err := billTo(customers, invoice.CustomerId, invoice.CustomerName)
 		 if err != nil {
-			 http.Error(w, err.Error(), http.StatusInternalServerError)
+			 writeCustomerError(w, err)
 			 return
 		 }
-		 // Calculate subtotal, tax, and total from invoice_items
-		 query = `
-		 SELECT SUM(quantity * unit_price) AS subtotal
-		 FROM invoice_items
-		 WHERE invoice_id = ?`
-
-		 var subtotal float64
-		 err = db.QueryRow(query, invoiceID).Scan(&subtotal)
+
+		 // Update who the invoice is billed to, paid and voided invoices are refused
+		 invoice, err = invoices.UpdateCustomer(invoiceID, invoice.CustomerId, customerName)
 		 if err != nil {
-			 http.Error(w, err.Error(), http.StatusInternalServerError)
+			 writeRepositoryError(w, err)
 			 return
 		 }
 
-		 tax := subtotal * 0.10
-		 total := subtotal + tax
-		// Update subtotal, tax, and total in invoices table
-		query = "UPDATE invoices SET subtotal=?, tax=?, total=? WHERE invoice_id=?"
-		_, err = db.Exec(query, subtotal, tax, total, invoiceID)
-		if err != nil {
-			  http.Error(w, err.Error(), http.StatusInternalServerError)
-			  return
-		}
-
-		  // Fetch the updated invoice
-		  query = "SELECT invoice_id, customer_name, subtotal, tax, total FROM invoices WHERE invoice_id=?"
-		  err = db.QueryRow(query, invoiceID).Scan(&invoice.InvoiceId, &invoice.CustomerName, &invoice.SubTotal, &invoice.Tax, &invoice.Total)
-		  if err != nil {
-			  http.Error(w, err.Error(), http.StatusInternalServerError)
-			  return
-		  }
-
 		  w.Header().Set("Content-Type", "application/json")
 		  json.NewEncoder(w).Encode(invoice)
   	
 	}
 }
 
-//delete an invoice
-func DeleteInvoice(db *sql.DB) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		vars := mux.Vars(r)
-		invoiceID := vars["invoice_id"]
-
-		query := "DELETE FROM invoices WHERE invoice_id = ?"
-		_, err := db.Exec(query, invoiceID)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-        json.NewEncoder(w).Encode(map[string]string{"message": "Invoice deleted successfully"})
-	}
-}
-
-//function to return all invoice items specific to an invoice
-func GetInvoiceItems(db *sql.DB) http.HandlerFunc {
+//function to return all invoice items specific to an invoice, toppings are nested under their pizza
+func GetInvoiceItems(invoices repository.InvoiceRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		invoiceID := vars["invoice_id"]
 
-		query := "SELECT invoice_item_id, invoice_id, item_id, quantity, unit_price FROM invoice_items WHERE invoice_id = ?"
-		results, err := db.Query(query, invoiceID)
+		invoiceItems, err := invoices.Items(invoiceID)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		defer results.Close()
-
-		var invoiceItems []models.InvoiceItem
-		for results.Next() {
-			var invoiceItem models.InvoiceItem
-			if err := results.Scan(&invoiceItem.InvoiceItemId, &invoiceItem.InvoiceId, &invoiceItem.ItemId, &invoiceItem.Quantity, &invoiceItem.UnitPrice); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			invoiceItems = append(invoiceItems, invoiceItem)
-		}
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(invoiceItems)
 	}
 }
 
-//function to create a new invoice item
-func CreateInvoiceItem(db *sql.DB) http.HandlerFunc {
+//function to create a new invoice item, pizza lines may carry their toppings as modifiers
+func CreateInvoiceItem(invoices repository.InvoiceRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		invoiceID := vars["invoice_id"]
@@ -190,19 +164,16 @@ func CreateInvoiceItem(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		query := "INSERT INTO invoice_items (invoice_id, item_id, quantity, unit_price) VALUES (?, ?, ?, ?)"
-		_, err := db.Exec(query, invoiceID, invoiceItem.ItemId, invoiceItem.Quantity, invoiceItem.UnitPrice)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		//the unit price always comes from the catalog, never from the client
+		invoiceItem, err := invoices.AddItem(invoiceID, invoiceItem)
+		if err == repository.ErrNotFound {
+			http.Error(w, "Invoice not found", http.StatusNotFound)
 			return
 		}
-
-		
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeItemError(w, err)
 			return
 		}
-		
 
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(invoiceItem)
@@ -210,7 +181,7 @@ func CreateInvoiceItem(db *sql.DB) http.HandlerFunc {
 }
 
 //funtion to update an invoice item
-func UpdateInvoiceItem(db *sql.DB) http.HandlerFunc {
+func UpdateInvoiceItem(invoices repository.InvoiceRepository) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
         vars := mux.Vars(r)
         itemID := vars["invoice_item_id"]
@@ -221,10 +192,10 @@ func UpdateInvoiceItem(db *sql.DB) http.HandlerFunc {
             return
         }
 
-        query := "UPDATE invoice_items SET item_id=?, quantity=?, unit_price=?,  WHERE invoice_item_id=?"
-        _, err := db.Exec(query, item.ItemId, item.Quantity, item.UnitPrice,itemID)
+        //re-price the item from the catalog in case the item id changed
+        item, err := invoices.UpdateItem(itemID, item)
         if err != nil {
-            http.Error(w, err.Error(), http.StatusInternalServerError)
+            writeItemError(w, err)
             return
         }
 
@@ -233,16 +204,14 @@ func UpdateInvoiceItem(db *sql.DB) http.HandlerFunc {
     }
 }
 
-//function to delete an invoice item
-func DeleteInvoiceItem(db *sql.DB) http.HandlerFunc {
+//function to delete an invoice item, deleting a pizza line also removes its toppings
+func DeleteInvoiceItem(invoices repository.InvoiceRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		invoiceItemID := vars["invoice_item_id"]
 
-		query := "DELETE FROM invoice_items WHERE   invoice_item_id = ?"
-		_, err := db.Exec(query,invoiceItemID)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if _, err := invoices.DeleteItem(invoiceItemID); err != nil {
+			writeItemError(w, err)
 			return
 		}
 
@@ -251,62 +220,201 @@ func DeleteInvoiceItem(db *sql.DB) http.HandlerFunc {
 	}
 }
 
+//function to report a failed item operation, invalid lines are the client's fault
+func writeItemError(w http.ResponseWriter, err error) {
+	if err == billing.ErrItemNotFound {
+		http.Error(w, "Invoice item not found", http.StatusNotFound)
+		return
+	}
+	if _, ok := err.(billing.ItemError); ok {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeRepositoryError(w, err)
+}
+
+//function to report a customer that could not be billed, an unknown customer is an invalid request
+func writeCustomerError(w http.ResponseWriter, err error) {
+	if err == repository.ErrNotFound {
+		http.Error(w, "Customer not found", http.StatusBadRequest)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
+
+//function to report a failed repository operation
+func writeRepositoryError(w http.ResponseWriter, err error) {
+	switch err {
+	case repository.ErrNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case repository.ErrDuplicate:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case payments.ErrLocked:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		//a change that would leave the invoice overpaid is refused like an invalid request
+		if _, ok := err.(payments.PaymentError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := err.(creditnotes.CreditError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := err.(loyalty.LoyaltyError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := err.(orders.OrderError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := err.(kitchen.KitchenError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+//function to load an invoice with its items ready for rendering
+func loadReceipt(invoices repository.InvoiceRepository, invoiceId string) (receipt.Receipt, error) {
+	invoice, err := invoices.Get(invoiceId)
+	if err != nil {
+		return receipt.Receipt{}, err
+	}
+	items, err := invoices.Items(invoiceId)
+	if err != nil {
+		return receipt.Receipt{}, err
+	}
+	return receipt.New(invoice, items), nil
+}
+
 //function to generate a printable invoice
-func GeneratePrintableInvoice(db *sql.DB) http.HandlerFunc {
+//the format is chosen with ?format=json|html|text|pdf or the Accept header,
+//text receipts are 80mm wide unless ?paper=58mm is given
+func GeneratePrintableInvoice(invoices repository.InvoiceRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		vars := mux.Vars(r)
 		invoiceID := vars["invoice_id"]
 
-		//fetch the invoice details
-		
-		query := "SELECT invoice_id,subtotal, tax, total FROM invoices WHERE invoice_id = ?"
-		results, err := db.Query(query, invoiceID)
+		//fetch the invoice with its items, names and taxes
+		printable, err := loadReceipt(invoices, invoiceID)
+		if err == repository.ErrNotFound {
+			http.Error(w, "Invoice not found", http.StatusNotFound)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		defer results.Close()
 
-		var invoice models.Invoice
-		if results.Next() {
-			if err := results.Scan(&invoice.InvoiceId, &invoice.SubTotal, &invoice.Tax, &invoice.Total); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
+		switch printFormat(r) {
+		case "html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			err = receipt.RenderHTML(w, printable)
+		case "text":
+			width := receipt.Width80mm
+			if r.URL.Query().Get("paper") == "58mm" {
+				width = receipt.Width58mm
 			}
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			err = receipt.RenderText(w, printable, width)
+		case "pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			w.Header().Set("Content-Disposition", "inline; filename=invoice-"+strings.TrimPrefix(printable.Number(), "#")+".pdf")
+			err = receipt.RenderPDF(w, printable)
+		default:
+			//create response object
+			response := struct {
+				Invoice models.Invoice `json:"invoice"`
+				InvoiceItems []models.InvoiceItem `json:"invoice_items"`
+			}{
+				Invoice: printable.Invoice,
+				InvoiceItems: printable.Items,
+			}
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(w).Encode(response)
+		}
+		if err != nil {
+			log.Printf("Error rendering invoice %s: %v", invoiceID, err)
 		}
+	}
+}
 
-		//fetch the invoice items
+//function to pick the printable format from the query string or the Accept header
+func printFormat(r *http.Request) string {
+	if format := r.URL.Query().Get("format"); format != "" {
+		return strings.ToLower(format)
+	}
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "application/pdf"):
+		return "pdf"
+	case strings.Contains(accept, "text/html"):
+		return "html"
+	case strings.Contains(accept, "text/plain"):
+		return "text"
+	}
+	return "json"
+}
 
-		query = "SELECT item_id, quantity, unit_price FROM invoice_items WHERE invoice_id = ?"
-		results, err = db.Query(query, invoiceID)
+//function to generate an ESC/POS receipt for thermal printers
+//?paper=58mm selects the narrow roll and ?drawer=false skips the cash drawer kick
+func GenerateESCPOSInvoice(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		invoiceID := vars["invoice_id"]
+
+		printable, err := loadReceipt(invoices, invoiceID)
+		if err == repository.ErrNotFound {
+			http.Error(w, "Invoice not found", http.StatusNotFound)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		defer results.Close()
-
-		var invoiceItems []models.InvoiceItem
-		for results.Next() {
-			var invoiceItem models.InvoiceItem
-			if err := results.Scan(&invoiceItem.ItemId, &invoiceItem.Quantity, &invoiceItem.UnitPrice); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			invoiceItems = append(invoiceItems, invoiceItem)
+
+		width := receipt.Width80mm
+		if r.URL.Query().Get("paper") == "58mm" {
+			width = receipt.Width58mm
+		}
+		kickDrawer := r.URL.Query().Get("drawer") != "false"
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment; filename=invoice-"+invoiceID+".bin")
+		if err := receipt.RenderESCPOS(w, printable, width, kickDrawer); err != nil {
+			log.Printf("Error rendering ESC/POS invoice %s: %v", invoiceID, err)
 		}
+	}
+}
+
+//function to generate an ESC/POS kitchen ticket listing the pizzas of an invoice
+func GenerateKitchenTicket(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		invoiceID := vars["invoice_id"]
 
-		//create response object
-		response := struct {
-			Invoice models.Invoice `json:"invoice"`
-			InvoiceItems []models.InvoiceItem `json:"invoice_items"`
-		}{
-			Invoice: invoice,
-			InvoiceItems: invoiceItems,
+		printable, err := loadReceipt(invoices, invoiceID)
+		if err == repository.ErrNotFound {
+			http.Error(w, "Invoice not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
 
-		
+		width := receipt.Width80mm
+		if r.URL.Query().Get("paper") == "58mm" {
+			width = receipt.Width58mm
+		}
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(response)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment; filename=kitchen-"+invoiceID+".bin")
+		if err := receipt.RenderKitchenTicket(w, printable, width); err != nil {
+			log.Printf("Error rendering kitchen ticket %s: %v", invoiceID, err)
+		}
 	}
-}
\ No newline at end of file
+}
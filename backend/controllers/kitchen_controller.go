@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
+
+	"github.com/gorilla/mux"
+)
+
+//method to get the kitchen stations
+func GetKitchenStations(kitchenRepo repository.KitchenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stations, err := kitchenRepo.Stations()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(stations)
+	}
+}
+
+//method to create a kitchen station, stations are active unless the request says otherwise
+func CreateKitchenStation(kitchenRepo repository.KitchenRepository, pizzas repository.PizzaRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		station := models.KitchenStation{Active: true}
+		if err := json.NewDecoder(r.Body).Decode(&station); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg := validateKitchenStation(&station, pizzas); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		station.CreatedAt = time.Now()
+		station.UpdatedAt = time.Now()
+		station, err := kitchenRepo.CreateStation(station)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(station)
+	}
+}
+
+//method to update a kitchen station, fields missing from the request keep their value
+func UpdateKitchenStation(kitchenRepo repository.KitchenRepository, pizzas repository.PizzaRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		existing, err := kitchenRepo.Station(vars["station_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		//decoding over the existing station merges the changes
+		station := existing
+		if err := json.NewDecoder(r.Body).Decode(&station); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		station.StationId = existing.StationId
+		station.CreatedAt = existing.CreatedAt
+		if msg := validateKitchenStation(&station, pizzas); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		station.UpdatedAt = time.Now()
+		if station, err = kitchenRepo.UpdateStation(station); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(station)
+	}
+}
+
+//method to delete a kitchen station, its tickets are left without a station
+func DeleteKitchenStation(kitchenRepo repository.KitchenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		if err := kitchenRepo.DeleteStation(vars["station_id"]); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Kitchen station deleted successfully"})
+	}
+}
+
+//method to get the tickets the kitchen still has to make or hand over, oldest first
+//station_id limits the queue to the tickets of one station
+func GetKitchenQueue(kitchenRepo repository.KitchenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stationId := r.URL.Query().Get("station_id")
+		if stationId != "" {
+			if _, err := kitchenRepo.Station(stationId); err != nil {
+				writeRepositoryError(w, err)
+				return
+			}
+		}
+
+		tickets, err := kitchenRepo.Queue(stationId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tickets)
+	}
+}
+
+//method to get a kitchen ticket
+func GetKitchenTicket(kitchenRepo repository.KitchenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		ticket, err := kitchenRepo.Ticket(vars["ticket_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ticket)
+	}
+}
+
+//method to get the kitchen tickets of an invoice, so the counter can see how far its pizzas are
+func GetInvoiceKitchenTickets(kitchenRepo repository.KitchenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		tickets, err := kitchenRepo.InvoiceTickets(vars["invoice_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(tickets)
+	}
+}
+
+//method to bump a ticket to its next status
+func BumpKitchenTicket(kitchenRepo repository.KitchenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		ticket, err := kitchenRepo.UpdateStatus(vars["ticket_id"], "")
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ticket)
+	}
+}
+
+//method to move a ticket to a given status, used to recall a ticket bumped by mistake
+func UpdateKitchenTicketStatus(kitchenRepo repository.KitchenRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var request struct {
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if request.Status == "" {
+			http.Error(w, "status is required", http.StatusBadRequest)
+			return
+		}
+
+		ticket, err := kitchenRepo.UpdateStatus(vars["ticket_id"], request.Status)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ticket)
+	}
+}
+
+//function to validate a kitchen station and tidy its list of pizza types, it returns a message describing the first problem
+func validateKitchenStation(station *models.KitchenStation, pizzas repository.PizzaRepository) string {
+	if station.Name == "" {
+		return "Kitchen station name is required"
+	}
+	var pizzaTypes []string
+	for _, id := range strings.Split(station.PizzaTypes, ",") {
+		if id = strings.TrimSpace(id); id == "" {
+			continue
+		}
+		if _, err := pizzas.Get(id); err != nil {
+			return "Pizza type " + id + " not found"
+		}
+		pizzaTypes = append(pizzaTypes, id)
+	}
+	station.PizzaTypes = strings.Join(pizzaTypes, ",")
+	return ""
+}
@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"piza_shop_billing/backend/listing"
+)
+
+//body of a list response, one page of records and where it sits in the list
+type listResponse struct {
+	Items interface{}  `json:"items"`
+	Page  listing.Page `json:"page"`
+}
+
+//function to parse the filters, sort and cursor of a list request, a bad query is answered with 400
+func parseListing(w http.ResponseWriter, r *http.Request, schema listing.Schema) (listing.Query, bool) {
+	q, err := listing.Parse(r.URL.Query(), schema)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return q, false
+	}
+	return q, true
+}
+
+//function to write one page of a list
+func writePage(w http.ResponseWriter, items interface{}, page listing.Page) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(listResponse{Items: items, Page: page})
+}
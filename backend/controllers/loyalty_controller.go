@@ -0,0 +1,270 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/listing"
+	"piza_shop_billing/backend/loyalty"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/repository"
+
+	"github.com/gorilla/mux"
+)
+
+//method to get the earning rules of the loyalty program
+func GetLoyaltyRules(loyaltyRepo repository.LoyaltyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := loyaltyRepo.Rules()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rules)
+	}
+}
+
+//method to get the redemption terms of the loyalty program
+func GetLoyaltySettings(loyaltyRepo repository.LoyaltyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(loyaltyRepo.Settings())
+	}
+}
+
+//method to create an earning rule, rules are active unless the request says otherwise
+func CreateLoyaltyRule(loyaltyRepo repository.LoyaltyRepository, pizzas repository.PizzaRepository, beverages repository.BeverageRepository, combos repository.ComboRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rule := models.LoyaltyRule{Active: true}
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg := validateLoyaltyRule(rule, pizzas, beverages, combos); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		rule.CreatedAt = time.Now()
+		rule.UpdatedAt = time.Now()
+		rule, err := loyaltyRepo.CreateRule(rule)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+//method to update an earning rule, fields missing from the request keep their value
+//invoices already paid keep the points they earned
+func UpdateLoyaltyRule(loyaltyRepo repository.LoyaltyRepository, pizzas repository.PizzaRepository, beverages repository.BeverageRepository, combos repository.ComboRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		existing, err := loyaltyRepo.Rule(vars["rule_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		//decoding over the existing rule merges the changes
+		rule := existing
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rule.RuleId = existing.RuleId
+		rule.CreatedAt = existing.CreatedAt
+		if msg := validateLoyaltyRule(rule, pizzas, beverages, combos); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		rule.UpdatedAt = time.Now()
+		if rule, err = loyaltyRepo.UpdateRule(rule); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+//method to delete an earning rule, points already earned under it are kept
+func DeleteLoyaltyRule(loyaltyRepo repository.LoyaltyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		if err := loyaltyRepo.DeleteRule(vars["rule_id"]); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Loyalty rule deleted successfully"})
+	}
+}
+
+//method to get the points ledger of a customer one page at a time, with the current balance
+func GetCustomerLoyalty(loyaltyRepo repository.LoyaltyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		customerId := vars["customer_id"]
+
+		balance, err := loyaltyRepo.Balance(customerId)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+		q, ok := parseListing(w, r, repository.LoyaltyLedgerListing)
+		if !ok {
+			return
+		}
+		q.Conditions = append(q.Conditions, listing.Condition{Field: "customer_id", Op: "eq", Value: customerId})
+
+		entries, page, err := loyaltyRepo.Ledger(q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := struct {
+			listResponse
+			Balance int `json:"balance"`
+		}{
+			listResponse: listResponse{Items: entries, Page: page},
+			Balance:      balance,
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+//method to correct the points of a customer by hand, the reason is kept in the ledger
+func AdjustCustomerLoyalty(loyaltyRepo repository.LoyaltyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var requestBody struct {
+			Points int    `json:"points"`
+			Reason string `json:"reason"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if requestBody.Reason == "" {
+			http.Error(w, "A reason is required to adjust points", http.StatusBadRequest)
+			return
+		}
+
+		entry := models.LoyaltyEntry{Points: requestBody.Points, Description: requestBody.Reason, CreatedBy: username(r)}
+		entry, err := loyaltyRepo.Adjust(vars["customer_id"], entry)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(entry)
+	}
+}
+
+//method to redeem points of the customer of an open invoice as a discount or a free beverage on it
+func RedeemLoyaltyPoints(loyaltyRepo repository.LoyaltyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var request models.LoyaltyRedemption
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		request.CreatedBy = username(r)
+
+		invoice, redemption, err := loyaltyRepo.Redeem(vars["invoice_id"], request)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		response := struct {
+			Invoice    models.Invoice           `json:"invoice"`
+			Redemption models.LoyaltyRedemption `json:"redemption"`
+		}{invoice, redemption}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+//method to cancel a redemption on an open invoice, the points go back to the customer
+func CancelLoyaltyRedemption(loyaltyRepo repository.LoyaltyRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		invoice, err := loyaltyRepo.CancelRedemption(vars["invoice_id"], vars["redemption_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(invoice)
+	}
+}
+
+//function to validate an earning rule and return a message describing the first problem
+func validateLoyaltyRule(rule models.LoyaltyRule, pizzas repository.PizzaRepository, beverages repository.BeverageRepository, combos repository.ComboRepository) string {
+	if rule.Name == "" {
+		return "Loyalty rule name is required"
+	}
+	if !loyalty.ValidRuleKind(rule.Kind) {
+		return "Loyalty rule kind must be per_amount or item_bonus"
+	}
+	if rule.Points <= 0 {
+		return "points must be greater than zero"
+	}
+	if rule.Kind == loyalty.RulePerAmount {
+		if rule.ItemKind != "" || rule.ItemId != "" {
+			return "Only item_bonus rules take an item_kind and item_id"
+		}
+		return ""
+	}
+
+	var err error
+	switch rule.ItemKind {
+	case pricing.KindPizza:
+		_, err = pizzas.Get(rule.ItemId)
+	case pricing.KindBeverage:
+		_, err = beverages.Get(rule.ItemId)
+	case pricing.KindCombo:
+		_, err = combos.Get(rule.ItemId)
+	default:
+		return "An item_bonus rule needs an item_kind of pizza, beverage or combo"
+	}
+	if err != nil {
+		return "Item " + rule.ItemId + " not found"
+	}
+	return ""
+}
+
+//function to name the signed in user in the records they make
+func username(r *http.Request) string {
+	if claims, ok := auth.FromContext(r.Context()); ok {
+		return claims.Username
+	}
+	return ""
+}
+
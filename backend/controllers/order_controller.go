@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/orders"
+	"piza_shop_billing/backend/repository"
+	"piza_shop_billing/backend/tax"
+
+	"github.com/gorilla/mux"
+)
+
+//method to get the delivery zones
+func GetDeliveryZones(orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zones, err := orderRepo.Zones()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(zones)
+	}
+}
+
+//method to create a delivery zone, zones are active unless the request says otherwise
+func CreateDeliveryZone(orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		zone := models.DeliveryZone{Active: true}
+		if err := json.NewDecoder(r.Body).Decode(&zone); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg := validateDeliveryZone(zone); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		zone.CreatedAt = time.Now()
+		zone.UpdatedAt = time.Now()
+		zone, err := orderRepo.CreateZone(zone)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(zone)
+	}
+}
+
+//method to update a delivery zone, fields missing from the request keep their value
+//open deliveries in the zone pay the new fee the next time they are recalculated
+func UpdateDeliveryZone(orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		existing, err := orderRepo.Zone(vars["zone_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		//decoding over the existing zone merges the changes
+		zone := existing
+		if err := json.NewDecoder(r.Body).Decode(&zone); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		zone.ZoneId = existing.ZoneId
+		zone.CreatedAt = existing.CreatedAt
+		if msg := validateDeliveryZone(zone); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		zone.UpdatedAt = time.Now()
+		if zone, err = orderRepo.UpdateZone(zone); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(zone)
+	}
+}
+
+//method to delete a delivery zone, invoices keep the deliveries and fees already recorded on them
+func DeleteDeliveryZone(orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		if err := orderRepo.DeleteZone(vars["zone_id"]); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Delivery zone deleted successfully"})
+	}
+}
+
+//method to get the charges of every order type
+func GetOrderCharges(orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		charges, err := orderRepo.Charges()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(charges)
+	}
+}
+
+//method to create an order charge, it applies from today unless the request gives an effective_from date
+func CreateOrderCharge(orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		charge := models.OrderCharge{Active: true}
+		if err := json.NewDecoder(r.Body).Decode(&charge); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if charge.EffectiveFrom == "" {
+			charge.EffectiveFrom = time.Now().Format(tax.DateFormat)
+		}
+		if msg := validateOrderCharge(charge); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		charge.CreatedAt = time.Now()
+		charge.UpdatedAt = time.Now()
+		charge, err := orderRepo.CreateCharge(charge)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(charge)
+	}
+}
+
+//method to update an order charge, fields missing from the request keep their value
+func UpdateOrderCharge(orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		existing, err := orderRepo.Charge(vars["charge_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		//decoding over the existing charge merges the changes
+		charge := existing
+		if err := json.NewDecoder(r.Body).Decode(&charge); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		charge.ChargeId = existing.ChargeId
+		charge.CreatedAt = existing.CreatedAt
+		if msg := validateOrderCharge(charge); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		charge.UpdatedAt = time.Now()
+		if charge, err = orderRepo.UpdateCharge(charge); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(charge)
+	}
+}
+
+//method to delete an order charge, invoices keep the charges already recorded on them
+func DeleteOrderCharge(orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		if err := orderRepo.DeleteCharge(vars["charge_id"]); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Order charge deleted successfully"})
+	}
+}
+
+//method to change the order type of an open invoice with its table or delivery address
+func UpdateInvoiceOrder(invoices repository.InvoiceRepository, customers repository.CustomerRepository, orderRepo repository.OrderRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		invoiceId := vars["invoice_id"]
+
+		existing, err := invoices.Get(invoiceId)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		var request struct {
+			OrderType   string           `json:"order_type"`
+			TableNumber string           `json:"table_number"`
+			Delivery    *models.Delivery `json:"delivery"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		//addresses are looked up in the address book of the customer the invoice is billed to
+		existing.OrderType = request.OrderType
+		existing.TableNumber = request.TableNumber
+		existing.Delivery = request.Delivery
+		order, err := orderDetails(orderRepo, customers, existing)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		invoice, err := invoices.UpdateOrder(invoiceId, order.OrderType, order.TableNumber, order.Delivery)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(invoice)
+	}
+}
+
+//function to check the order details of an invoice and complete them, an invoice without an order type is
+//takeaway, a delivery to an address of the customer copies the address and the zone is found by postal code
+//when none is given, invalid details are reported with orders.OrderError
+func orderDetails(orderRepo repository.OrderRepository, customers repository.CustomerRepository, invoice models.Invoice) (models.Invoice, error) {
+	if invoice.OrderType == "" {
+		invoice.OrderType = orders.DefaultType
+	}
+	invoice.TableNumber = strings.TrimSpace(invoice.TableNumber)
+
+	switch invoice.OrderType {
+	case orders.TypeDineIn:
+		if invoice.TableNumber == "" {
+			return invoice, orders.OrderError("A dine-in order needs a table_number")
+		}
+	case orders.TypeTakeaway, orders.TypeDelivery:
+		if invoice.TableNumber != "" {
+			return invoice, orders.OrderError("Only dine-in orders take a table_number")
+		}
+	default:
+		return invoice, orders.OrderError("order_type must be one of dine_in, takeaway or delivery")
+	}
+	if invoice.OrderType != orders.TypeDelivery {
+		if invoice.Delivery != nil {
+			return invoice, orders.OrderError("Only delivery orders take a delivery address")
+		}
+		return invoice, nil
+	}
+
+	if invoice.Delivery == nil {
+		return invoice, orders.OrderError("A delivery order needs a delivery address")
+	}
+	delivery := *invoice.Delivery
+	if delivery.AddressId != 0 {
+		address, err := customerAddress(customers, invoice.CustomerId, delivery.AddressId)
+		if err != nil {
+			return invoice, err
+		}
+		delivery.Line1, delivery.Line2, delivery.City, delivery.PostalCode = address.Line1, address.Line2, address.City, address.PostalCode
+		if delivery.Instructions == "" {
+			delivery.Instructions = address.Instructions
+		}
+	}
+	if strings.TrimSpace(delivery.Line1) == "" {
+		return invoice, orders.OrderError("A delivery address needs a line1 or the address_id of an address of the customer")
+	}
+
+	if delivery.ZoneId != 0 {
+		zone, err := orderRepo.Zone(strconv.Itoa(delivery.ZoneId))
+		if err == repository.ErrNotFound || (err == nil && !zone.Active) {
+			return invoice, orders.OrderError("Delivery zone not found")
+		}
+		if err != nil {
+			return invoice, err
+		}
+	} else {
+		zones, err := orderRepo.Zones()
+		if err != nil {
+			return invoice, err
+		}
+		zone, ok := orders.ZoneFor(zones, delivery.PostalCode)
+		if !ok {
+			return invoice, orders.OrderError("No delivery zone covers postal code " + delivery.PostalCode + ", choose a zone_id")
+		}
+		delivery.ZoneId = zone.ZoneId
+	}
+	invoice.Delivery = &delivery
+	return invoice, nil
+}
+
+//function to find an address in the address book of a customer
+func customerAddress(customers repository.CustomerRepository, customerId int, addressId int) (models.CustomerAddress, error) {
+	if customerId == 0 {
+		return models.CustomerAddress{}, orders.OrderError("An address_id needs an invoice billed to a customer")
+	}
+	customer, err := customers.Get(strconv.Itoa(customerId))
+	if err != nil {
+		return models.CustomerAddress{}, err
+	}
+	for _, address := range customer.Addresses {
+		if address.AddressId == addressId {
+			return address, nil
+		}
+	}
+	return models.CustomerAddress{}, orders.OrderError("Address " + strconv.Itoa(addressId) + " is not an address of the customer")
+}
+
+//function to validate a delivery zone and return a message describing the first problem
+func validateDeliveryZone(zone models.DeliveryZone) string {
+	if zone.Name == "" {
+		return "Delivery zone name is required"
+	}
+	if zone.Fee < 0 || zone.FreeOver < 0 {
+		return "fee and free_over cannot be negative"
+	}
+	return ""
+}
+
+//function to validate an order charge and return a message describing the first problem
+func validateOrderCharge(charge models.OrderCharge) string {
+	if charge.Name == "" {
+		return "Order charge name is required"
+	}
+	if !orders.ValidType(charge.OrderType) {
+		return "order_type must be one of dine_in, takeaway or delivery"
+	}
+	if !orders.ValidChargeKind(charge.Kind) {
+		return "Order charge kind must be one of fixed, per_item or percentage"
+	}
+	if charge.Amount <= 0 {
+		return "amount must be greater than zero"
+	}
+	if charge.Kind == orders.ChargePercentage && charge.Amount > 1 {
+		return "A percentage charge takes an amount between 0 and 1"
+	}
+	if _, err := time.Parse(tax.DateFormat, charge.EffectiveFrom); err != nil {
+		return "effective_from must be a date in YYYY-MM-DD format"
+	}
+	return ""
+}
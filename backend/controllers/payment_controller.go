@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
+
+	"github.com/gorilla/mux"
+)
+
+//method to take a payment against an invoice, a payment can be split over several tenders
+//and cash handed over above the balance is given back as change
+func CreateInvoicePayment(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		var requestBody struct {
+			Tenders []models.Payment `json:"tenders"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		invoice, taken, change, err := invoices.Pay(vars["invoice_id"], requestBody.Tenders)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		response := struct {
+			Invoice   models.Invoice   `json:"invoice"`
+			Payments  []models.Payment `json:"payments"`
+			ChangeDue float64          `json:"change_due"`
+		}{
+			Invoice:   invoice,
+			Payments:  taken,
+			ChangeDue: change,
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+//method to get the payments taken against an invoice
+func GetInvoicePayments(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		list, err := invoices.Payments(vars["invoice_id"])
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+		if list == nil {
+			list = []models.Payment{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/repository"
+)
+
+//function to price a build-your-own pizza without billing it
+//the priced configuration can be sent as the configuration of an invoice line to bill it as one line
+func ConfigurePizza(pizzas repository.PizzaRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var config models.PizzaConfiguration
+		if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		pizzaType, err := pizzas.Get(config.PizzaTypeId)
+		if err == repository.ErrNotFound {
+			http.Error(w, "Pizza type not found", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		//the size matrix comes along with the pizza type
+		sizes := func(pizzaTypeId string, size string) (float64, float64, error) {
+			for _, entry := range pizzaType.Sizes {
+				if entry.Size == size {
+					return entry.Price, entry.ToppingMultiplier, nil
+				}
+			}
+			return 0, 0, pricing.ErrUnknownSize
+		}
+		pizza := pricing.Item{
+			ItemId:    pizzaType.PizzaTypeId,
+			Kind:      pricing.KindPizza,
+			Name:      pizzaType.Name,
+			UnitPrice: pizzaType.BasePrice,
+		}
+
+		configured, err := billing.Configure(pizza, config, sizes, pizzas.Toppings)
+		if err != nil {
+			writeItemError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(configured)
+	}
+}
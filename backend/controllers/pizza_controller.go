@@ -1,21 +1,27 @@
 package controllers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"time"
 	"log"
 	"net/http"
 	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/repository"
 
 	"github.com/gorilla/mux"
 )
 
-//method to get all pizza types returns a http.HandlerFunc
-func GetPizzaTypes(db *sql.DB) http.HandlerFunc {
+//method to get a page of pizza types, filtered and sorted like the invoice list, returns a http.HandlerFunc
+func GetPizzaTypes(pizzas repository.PizzaRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		query := "SELECT pizza_type_id,name,size,base_price,description FROM pizza_types"
-		results, err := db.Query(query)
+		q, ok := parseListing(w, r, repository.PizzaListing)
+		if !ok {
+			return
+		}
+
+		//fetch the pizza types from the repository
+		pizzaTypes, page, err := pizzas.List(q)
 
 		//check if there is an error and return it to the client
 		if err != nil {
@@ -23,31 +29,12 @@ func GetPizzaTypes(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		//defer is used to close the result set after the function returns
-		defer results.Close()
-
-		//create a slice of pizza types
-		var pizzaTypes []models.PizzaType
-		//iterate over the result set and append the pizza types to the slice
-		for results.Next() {
-			var pizzaType models.PizzaType
-			//scan the result set into the pizza type struct and check for errors
-			if err := results.Scan(&pizzaType.PizzaTypeId, &pizzaType.Name, &pizzaType.Size, &pizzaType.BasePrice, &pizzaType.Description); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			//append the pizza type to the slice
-			pizzaTypes = append(pizzaTypes, pizzaType)
-		}
-
-		//set the response header to application/json
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(pizzaTypes)
+		writePage(w, pizzaTypes, page)
 	}
 }
 
 //method to create a pizza type
-func CreatePizzaType(db *sql.DB) http.HandlerFunc {
+func CreatePizzaType(pizzas repository.PizzaRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		//create a pizza type struct
 		var pizzaType models.PizzaType
@@ -57,13 +44,16 @@ func CreatePizzaType(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
+		if message := validatePizzaSizes(pizzaType.Sizes); message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+		pizzaType.Sizes = normalizePizzaSizes(pizzaType.PizzaTypeId, pizzaType.Sizes)
 		pizzaType.CreatedAt = time.Now()
 		pizzaType.UpdatedAt = time.Now()
-		//create a query to insert the pizza type into the database
-		query := "INSERT INTO pizza_types(pizza_type_id,name,size,base_price,description,created_at,updated_at) VALUES(?,?,?,?,?,?,?)"
-		//execute the query and check for errors
-		if _, err := db.Exec(query, pizzaType.PizzaTypeId,pizzaType.Name, pizzaType.Size, pizzaType.BasePrice, pizzaType.Description,pizzaType.CreatedAt,pizzaType.UpdatedAt); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		//store the pizza type and check for errors
+		if err := pizzas.Create(pizzaType); err != nil {
+			writeRepositoryError(w, err)
 			return
 		}
 		//set the response header to application/json
@@ -74,24 +64,17 @@ func CreatePizzaType(db *sql.DB) http.HandlerFunc {
 }
 
 //method to update a pizza type
-func UpdatePizzaType(db *sql.DB) http.HandlerFunc {
+func UpdatePizzaType(pizzas repository.PizzaRepository) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
         // Extract pizza_type_id from URL path
         vars := mux.Vars(r)
         pizzaTypeId := vars["pizza_type_id"]
 
-        // Fetch the existing record from the database
-        var existingPizzaType models.PizzaType
-        query := "SELECT pizza_type_id, name, size, base_price, description FROM pizza_types WHERE pizza_type_id = ?"
-        if err := db.QueryRow(query, pizzaTypeId).Scan(
-            &existingPizzaType.PizzaTypeId,
-            &existingPizzaType.Name,
-            &existingPizzaType.Size,
-            &existingPizzaType.BasePrice,
-            &existingPizzaType.Description,
-        ); err != nil {
+        // Fetch the existing record from the repository
+        existingPizzaType, err := pizzas.Get(pizzaTypeId)
+        if err != nil {
             log.Printf("Error fetching existing pizza type: %v", err)
-            http.Error(w, err.Error(), http.StatusInternalServerError)
+            writeRepositoryError(w, err)
             return
         }
 
@@ -117,23 +100,20 @@ func UpdatePizzaType(db *sql.DB) http.HandlerFunc {
         if updatedPizzaType.Description != "" {
             existingPizzaType.Description = updatedPizzaType.Description
         }
-        existingPizzaType.UpdatedAt = time.Now()
-
-        // Build the update query dynamically
-        updateQuery := "UPDATE pizza_types SET name=?, size=?, base_price=?, description=?, updated_at=? WHERE pizza_type_id=?"
-        args := []interface{}{
-            existingPizzaType.Name,
-            existingPizzaType.Size,
-            existingPizzaType.BasePrice,
-            existingPizzaType.Description,
-            existingPizzaType.UpdatedAt,
-            pizzaTypeId,
+        // A sizes list replaces the whole size matrix, leaving it out keeps the current one
+        if updatedPizzaType.Sizes != nil {
+            if message := validatePizzaSizes(updatedPizzaType.Sizes); message != "" {
+                http.Error(w, message, http.StatusBadRequest)
+                return
+            }
+            existingPizzaType.Sizes = normalizePizzaSizes(pizzaTypeId, updatedPizzaType.Sizes)
         }
+        existingPizzaType.UpdatedAt = time.Now()
 
-        // Execute the query and check for errors
-        if _, err := db.Exec(updateQuery, args...); err != nil {
+        // Store the merged pizza type and check for errors
+        if err := pizzas.Update(existingPizzaType); err != nil {
             log.Printf("Error executing update query: %v", err)
-            http.Error(w, err.Error(), http.StatusInternalServerError)
+            writeRepositoryError(w, err)
             return
         }
 
@@ -147,22 +127,14 @@ func UpdatePizzaType(db *sql.DB) http.HandlerFunc {
     }
 }
 //method to delete a pizza type
-func DeletePizzaType(db *sql.DB) http.HandlerFunc {
+func DeletePizzaType(pizzas repository.PizzaRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// extract pizza_type_id from the request URL
 		vars := mux.Vars(r)
 		pizzaTypeId := vars["pizza_type_id"]
 
-		//query to delete topping from associated tables
-		relatedQuery:= "DELETE FROM pizza_toppings WHERE pizza_type_id = ?"
-		if _, err := db.Exec(relatedQuery, pizzaTypeId); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-		}
-		// create a query to delete the pizza type from the database
-		query := "DELETE FROM pizza_types WHERE pizza_type_id = ?"
-		// execute the query and check for errors
-		if _, err := db.Exec(query, pizzaTypeId); err != nil {
+		// delete the pizza type together with its topping links
+		if err := pizzas.Delete(pizzaTypeId); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -172,4 +144,87 @@ func DeletePizzaType(db *sql.DB) http.HandlerFunc {
 		// write a success message to the response writer
 		json.NewEncoder(w).Encode(map[string]string{"message": "Pizza type deleted successfully"})
 	}
-}
\ No newline at end of file
+}
+
+//method to get the size matrix of a pizza type
+func GetPizzaSizes(pizzas repository.PizzaRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		pizzaTypeId := vars["pizza_type_id"]
+
+		pizzaType, err := pizzas.Get(pizzaTypeId)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		//always answer with a list, even for a pizza type sold without sizes
+		sizes := pizzaType.Sizes
+		if sizes == nil {
+			sizes = []models.PizzaSize{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sizes)
+	}
+}
+
+//method to replace the size matrix of a pizza type
+func UpdatePizzaSizes(pizzas repository.PizzaRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		pizzaTypeId := vars["pizza_type_id"]
+
+		var sizes []models.PizzaSize
+		if err := json.NewDecoder(r.Body).Decode(&sizes); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if message := validatePizzaSizes(sizes); message != "" {
+			http.Error(w, message, http.StatusBadRequest)
+			return
+		}
+		sizes = normalizePizzaSizes(pizzaTypeId, sizes)
+
+		if err := pizzas.SetSizes(pizzaTypeId, sizes); err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sizes)
+	}
+}
+
+//function to check a size matrix, returns a message describing the first problem found
+func validatePizzaSizes(sizes []models.PizzaSize) string {
+	seen := map[string]bool{}
+	for _, size := range sizes {
+		if !pricing.ValidSize(size.Size) {
+			return "size must be one of small, medium, large or custom"
+		}
+		if seen[size.Size] {
+			return "size " + size.Size + " is listed more than once"
+		}
+		seen[size.Size] = true
+		if size.Price <= 0 {
+			return "price of size " + size.Size + " must be greater than zero"
+		}
+		if size.ToppingMultiplier < 0 {
+			return "topping_multiplier of size " + size.Size + " cannot be negative"
+		}
+	}
+	return ""
+}
+
+//function to attach the sizes to their pizza type, a missing topping multiplier means toppings keep their price
+func normalizePizzaSizes(pizzaTypeId string, sizes []models.PizzaSize) []models.PizzaSize {
+	normalized := []models.PizzaSize{}
+	for _, size := range sizes {
+		size.PizzaTypeId = pizzaTypeId
+		if size.ToppingMultiplier == 0 {
+			size.ToppingMultiplier = 1
+		}
+		normalized = append(normalized, size)
+	}
+	return normalized
+}
@@ -1,15 +1,27 @@
 package controllers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"net/http"
 	"github.com/gorilla/mux"
 	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
 )
 
+//toppingLink is the body of the topping link requests, toppings are included
+//in the pizza price unless is_default is false
+type toppingLink struct {
+	ToppingId string `json:"topping_id"`
+	IsDefault *bool  `json:"is_default"`
+}
+
+//function to tell whether the linked topping is included in the pizza price
+func (l toppingLink) isDefault() bool {
+	return l.IsDefault == nil || *l.IsDefault
+}
+
 //function to handle POST requests to link pizza type and topping
-func LinkPizzaTopping(db *sql.DB) http.HandlerFunc {
+func LinkPizzaTopping(pizzas repository.PizzaRepository, toppings repository.ToppingRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		//extract the pizza type id from the request
 		vars := mux.Vars(r)
@@ -17,34 +29,31 @@ func LinkPizzaTopping(db *sql.DB) http.HandlerFunc {
 
 
 		//decode the request body to get the topping id
-		var requestBody struct {
-			ToppingId string `json:"topping_id"`
-		}
-		
+		var requestBody toppingLink
+
 		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 		// Check if the pizza_type_id exists
-		var exists bool
-		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM pizza_types WHERE pizza_type_id = ?)", pizzaTypeId).Scan(&exists)
-		if err != nil || !exists {
+		if _, err := pizzas.Get(pizzaTypeId); err != nil {
 		 http.Error(w, "Pizza type not found", http.StatusBadRequest)
 			return
 		}
 
 		// Check if the topping_id exists
-		err = db.QueryRow("SELECT EXISTS(SELECT 1 FROM toppings WHERE topping_id = ?)", requestBody.ToppingId).Scan(&exists)
-		if err != nil || !exists {
+		if _, err := toppings.Get(requestBody.ToppingId); err != nil {
 		 http.Error(w, "Topping not found", http.StatusBadRequest)
 		 return
 		}
 
-		//query to insert pizza type and topping into pizza_toppings table
-		query := `INSERT INTO pizza_toppings (pizza_type_id, topping_id) VALUES (?, ?)`
-
-		if _, err := db.Exec(query,pizzaTypeId,requestBody.ToppingId); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		//link the pizza type and the topping, a topping can only be linked once
+		if err := pizzas.LinkTopping(pizzaTypeId, requestBody.ToppingId, requestBody.isDefault()); err != nil {
+			if err == repository.ErrDuplicate {
+				http.Error(w, "Topping is already linked to the pizza type", http.StatusConflict)
+				return
+			}
+			writeRepositoryError(w, err)
 			return
 		}
 
@@ -52,6 +61,7 @@ func LinkPizzaTopping(db *sql.DB) http.HandlerFunc {
 		pizzaTopping := models.PizzaTopping{
         	PizzaTypeId: pizzaTypeId,
             ToppingId:   requestBody.ToppingId,
+            IsDefault:   requestBody.isDefault(),
         }
 
 		w.Header().Set("Content-Type", "application/json")
@@ -60,56 +70,100 @@ func LinkPizzaTopping(db *sql.DB) http.HandlerFunc {
 	}
 }
 
-//function to get topping names related to specific pizzatype
-// Function to get topping names related to a specific pizza type
-func GetToppingsByPizzaType(db *sql.DB) http.HandlerFunc {
+//function to handle DELETE requests to remove a topping from a pizza type
+func UnlinkPizzaTopping(pizzas repository.PizzaRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Extract the pizza type ID from the request
 		vars := mux.Vars(r)
 		pizzaTypeId := vars["pizza_type_id"]
+		toppingId := vars["topping_id"]
 
-		// Check if the pizza_type_id exists
-		var exists bool
-		err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM pizza_types WHERE pizza_type_id = ?)", pizzaTypeId).Scan(&exists)
-		if err != nil || !exists {
-			http.Error(w, "Pizza type not found", http.StatusBadRequest)
+		if err := pizzas.UnlinkTopping(pizzaTypeId, toppingId); err != nil {
+			if err == repository.ErrNotFound {
+				http.Error(w, "Topping is not linked to the pizza type", http.StatusNotFound)
+				return
+			}
+			writeRepositoryError(w, err)
 			return
 		}
 
-		// Query to get the topping names for the given pizza type ID
-		query := `
-			SELECT t.name 
-			FROM toppings t
-			INNER JOIN pizza_toppings pt ON t.topping_id = pt.topping_id
-			WHERE pt.pizza_type_id = ?
-		`
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Topping removed from pizza type successfully"})
+	}
+}
 
-		// Execute the query
-		rows, err := db.Query(query, pizzaTypeId)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+//function to handle PUT requests replacing every topping of a pizza type
+func ReplacePizzaToppings(pizzas repository.PizzaRepository, toppings repository.ToppingRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		pizzaTypeId := vars["pizza_type_id"]
+
+		var requestBody []toppingLink
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if _, err := pizzas.Get(pizzaTypeId); err != nil {
+			http.Error(w, "Pizza type not found", http.StatusNotFound)
 			return
 		}
-		defer rows.Close()
-
-		// Collect the topping names
-		var toppings []string
-		for rows.Next() {
-			var toppingName string
-			if err := rows.Scan(&toppingName); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		//validate the whole set before touching the stored links
+		links := make([]models.PizzaTopping, 0, len(requestBody))
+		seen := map[string]bool{}
+		for _, link := range requestBody {
+			if seen[link.ToppingId] {
+				http.Error(w, "Topping "+link.ToppingId+" is listed more than once", http.StatusBadRequest)
 				return
 			}
-			toppings = append(toppings, toppingName)
+			seen[link.ToppingId] = true
+			if _, err := toppings.Get(link.ToppingId); err != nil {
+				http.Error(w, "Topping not found: "+link.ToppingId, http.StatusBadRequest)
+				return
+			}
+			links = append(links, models.PizzaTopping{
+				PizzaTypeId: pizzaTypeId,
+				ToppingId:   link.ToppingId,
+				IsDefault:   link.isDefault(),
+			})
+		}
+
+		if err := pizzas.SetToppings(pizzaTypeId, links); err != nil {
+			writeRepositoryError(w, err)
+			return
 		}
 
-		// Check for errors from iterating over rows
-		if err := rows.Err(); err != nil {
+		linked, err := pizzas.Toppings(pizzaTypeId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(linked)
+	}
+}
+
+// Function to get the toppings of a specific pizza type with their prices
+func GetToppingsByPizzaType(pizzas repository.PizzaRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract the pizza type ID from the request
+		vars := mux.Vars(r)
+		pizzaTypeId := vars["pizza_type_id"]
+
+		// Check if the pizza_type_id exists
+		if _, err := pizzas.Get(pizzaTypeId); err != nil {
+			http.Error(w, "Pizza type not found", http.StatusBadRequest)
+			return
+		}
+
+		// Collect the toppings for the given pizza type ID, default toppings come first
+		toppings, err := pizzas.Toppings(pizzaTypeId)
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		// Return the topping names as JSON
+		// Return the toppings as JSON
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(toppings)
 	}
@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/promotions"
+	"piza_shop_billing/backend/repository"
+	"piza_shop_billing/backend/tax"
+
+	"github.com/gorilla/mux"
+)
+
+//method to get all promotions
+func GetPromotions(promotionRepo repository.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := promotionRepo.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		//always answer with a list, even when no promotion is set up
+		if list == nil {
+			list = []models.Promotion{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(list)
+	}
+}
+
+//method to create a promotion, promotions are active unless the request says otherwise
+func CreatePromotion(promotionRepo repository.PromotionRepository, pizzas repository.PizzaRepository, beverages repository.BeverageRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		promotion := models.Promotion{Active: true}
+		if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		promotion.CouponCode = promotions.NormalizeCode(promotion.CouponCode)
+		if msg := validatePromotion(promotion, pizzas, beverages); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		promotion.CreatedAt = time.Now()
+		promotion.UpdatedAt = time.Now()
+		promotion, err := promotionRepo.Create(promotion)
+		if err == repository.ErrDuplicate {
+			http.Error(w, "Coupon code is already used by another promotion", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(promotion)
+	}
+}
+
+//method to update a promotion, fields missing from the request keep their value
+func UpdatePromotion(promotionRepo repository.PromotionRepository, pizzas repository.PizzaRepository, beverages repository.BeverageRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		promotionId := vars["promotion_id"]
+
+		existing, err := promotionRepo.Get(promotionId)
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		//decoding over the existing promotion merges the changes
+		promotion := existing
+		if err := json.NewDecoder(r.Body).Decode(&promotion); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		promotion.PromotionId = existing.PromotionId
+		promotion.TimesUsed = existing.TimesUsed
+		promotion.CreatedAt = existing.CreatedAt
+		promotion.CouponCode = promotions.NormalizeCode(promotion.CouponCode)
+		if msg := validatePromotion(promotion, pizzas, beverages); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		promotion.UpdatedAt = time.Now()
+		if err := promotionRepo.Update(promotion); err != nil {
+			if err == repository.ErrDuplicate {
+				http.Error(w, "Coupon code is already used by another promotion", http.StatusConflict)
+				return
+			}
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(promotion)
+	}
+}
+
+//method to delete a promotion, invoices keep the discounts already recorded on them
+func DeletePromotion(promotionRepo repository.PromotionRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		promotionId := vars["promotion_id"]
+
+		if err := promotionRepo.Delete(promotionId); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Promotion deleted successfully"})
+	}
+}
+
+//method to enter a coupon code on an invoice
+func ApplyInvoiceCoupon(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		invoiceId := vars["invoice_id"]
+
+		var requestBody struct {
+			CouponCode string `json:"coupon_code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if requestBody.CouponCode == "" {
+			http.Error(w, "coupon_code is required", http.StatusBadRequest)
+			return
+		}
+
+		invoice, err := invoices.ApplyCoupon(invoiceId, requestBody.CouponCode)
+		if err != nil {
+			writeCouponError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(invoice)
+	}
+}
+
+//method to take a coupon code off an invoice, the use of the coupon is given back
+func RemoveInvoiceCoupon(invoices repository.InvoiceRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+
+		invoice, err := invoices.RemoveCoupon(vars["invoice_id"], vars["coupon_code"])
+		if err == repository.ErrNotFound {
+			http.Error(w, "Coupon is not entered on the invoice", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			writeRepositoryError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(invoice)
+	}
+}
+
+//function to report a coupon that could not be entered on an invoice
+func writeCouponError(w http.ResponseWriter, err error) {
+	if _, ok := err.(promotions.CouponError); ok {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == repository.ErrDuplicate {
+		http.Error(w, "Coupon is already entered on the invoice", http.StatusConflict)
+		return
+	}
+	writeRepositoryError(w, err)
+}
+
+//function to validate a promotion and return a message describing the first problem
+func validatePromotion(promotion models.Promotion, pizzas repository.PizzaRepository, beverages repository.BeverageRepository) string {
+	if promotion.Name == "" {
+		return "Promotion name is required"
+	}
+	switch promotion.Kind {
+	case promotions.KindPercentage:
+		if promotion.Value <= 0 || promotion.Value > 1 {
+			return "A percentage promotion takes a value between 0 and 1"
+		}
+	case promotions.KindFixed, promotions.KindCombo:
+		if promotion.Value <= 0 {
+			return "A fixed or combo promotion takes a value greater than zero"
+		}
+	case promotions.KindBuyXGetY:
+		if promotion.PizzaTypeId == "" {
+			return "A buy_x_get_y promotion needs a pizza_type_id"
+		}
+		if promotion.BuyQuantity < 1 || promotion.FreeQuantity < 1 {
+			return "A buy_x_get_y promotion needs a buy_quantity and free_quantity of at least 1"
+		}
+	default:
+		return "Promotion kind must be one of percentage, fixed, buy_x_get_y or combo"
+	}
+	if promotion.BeverageId != "" && promotion.Kind != promotions.KindCombo {
+		return "Only combo promotions take a beverage_id"
+	}
+
+	if promotion.PizzaTypeId != "" {
+		if _, err := pizzas.Get(promotion.PizzaTypeId); err != nil {
+			return "Pizza type not found"
+		}
+	}
+	if promotion.BeverageId != "" {
+		if _, err := beverages.Get(promotion.BeverageId); err != nil {
+			return "Beverage not found"
+		}
+	}
+
+	if promotion.UsageLimit < 0 {
+		return "usage_limit cannot be negative"
+	}
+	if promotion.UsageLimit > 0 && promotion.CouponCode == "" {
+		return "usage_limit needs a coupon_code"
+	}
+	for _, date := range []string{promotion.ValidFrom, promotion.ValidTo} {
+		if _, err := time.Parse(tax.DateFormat, date); date != "" && err != nil {
+			return "valid_from and valid_to must be dates in YYYY-MM-DD format"
+		}
+	}
+	if promotion.ValidFrom != "" && promotion.ValidTo != "" && promotion.ValidTo < promotion.ValidFrom {
+		return "valid_to cannot be before valid_from"
+	}
+	if !promotions.ValidDays(promotion.Days) {
+		return "days must be weekday names such as mon,tue,wed"
+	}
+	for _, clock := range []string{promotion.StartTime, promotion.EndTime} {
+		if _, err := time.Parse(promotions.TimeFormat, clock); clock != "" && err != nil {
+			return "start_time and end_time must be times in HH:MM format"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"piza_shop_billing/backend/repository"
+)
+
+//layout of the from and to dates accepted by the reports
+const ReportDateFormat = "2006-01-02"
+
+//function to return the sales report for a date range, both ends default to today
+func GetSalesReport(reportRepo repository.ReportRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, to, msg := reportPeriod(r)
+		if msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		report, err := reportRepo.Sales(from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+//function to return the prep time report of the kitchen for a date range, both ends default to today
+func GetPrepTimeReport(reportRepo repository.ReportRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, to, msg := reportPeriod(r)
+		if msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+
+		report, err := reportRepo.PrepTimes(from, to)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+//function to read the from and to dates of a report, from defaults to today and to defaults to from
+//a message describing the problem is returned when a date is invalid
+func reportPeriod(r *http.Request) (string, string, string) {
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		from = time.Now().Format(ReportDateFormat)
+	}
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = from
+	}
+	if _, err := time.Parse(ReportDateFormat, from); err != nil {
+		return from, to, "from must be a date in YYYY-MM-DD format"
+	}
+	if _, err := time.Parse(ReportDateFormat, to); err != nil {
+		return from, to, "to must be a date in YYYY-MM-DD format"
+	}
+	return from, to, ""
+}
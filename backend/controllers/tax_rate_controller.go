@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
+	"piza_shop_billing/backend/tax"
+
+	"github.com/gorilla/mux"
+)
+
+//method to get all tax rates returns a http.HandlerFunc
+func GetTaxRates(taxRateRepo repository.TaxRateRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rates, err := taxRateRepo.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		//set the response header to application/json
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rates)
+	}
+}
+
+//method to create a tax rate
+func CreateTaxRate(taxRateRepo repository.TaxRateRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		//decode the request body into the tax rate struct
+		var rate models.TaxRate
+		if err := json.NewDecoder(r.Body).Decode(&rate); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		//a rate without an explicit category applies to every item
+		if rate.Category == "" {
+			rate.Category = tax.CategoryAll
+		}
+		if rate.EffectiveFrom == "" {
+			rate.EffectiveFrom = time.Now().Format(tax.DateFormat)
+		}
+		if msg := validateTaxRate(rate); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		//a new rate cannot reach back to days that were already taxed
+		if rate.EffectiveFrom < time.Now().Format(tax.DateFormat) {
+			http.Error(w, "Tax rate effective_from cannot be in the past", http.StatusBadRequest)
+			return
+		}
+
+		rate.CreatedAt = time.Now()
+		rate.UpdatedAt = time.Now()
+		rate, err := taxRateRepo.Create(rate)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rate)
+	}
+}
+
+//method to update a tax rate, once a rate is in effect only its name can change so the invoices
+//taxed with it keep reproducing their tax, a new rate with a later effective_from replaces it
+func UpdateTaxRate(taxRateRepo repository.TaxRateRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Extract tax_rate_id from URL path
+		vars := mux.Vars(r)
+		taxRateId := vars["tax_rate_id"]
+
+		// Fetch the existing record
+		existingRate, err := taxRateRepo.Get(taxRateId)
+		if err == repository.ErrNotFound {
+			http.Error(w, "Tax rate not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching existing tax rate: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// Decode the request body, inclusive is a pointer so false can be told apart from missing
+		var updatedRate struct {
+			Name          string   `json:"name"`
+			Category      string   `json:"category"`
+			Rate          *float64 `json:"rate"`
+			Inclusive     *bool    `json:"inclusive"`
+			EffectiveFrom string   `json:"effective_from"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&updatedRate); err != nil {
+			log.Printf("Error decoding request body: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Merge the changes
+		current := existingRate
+		if updatedRate.Name != "" {
+			existingRate.Name = updatedRate.Name
+		}
+		if updatedRate.Category != "" {
+			existingRate.Category = updatedRate.Category
+		}
+		if updatedRate.Rate != nil {
+			existingRate.Rate = *updatedRate.Rate
+		}
+		if updatedRate.Inclusive != nil {
+			existingRate.Inclusive = *updatedRate.Inclusive
+		}
+		if updatedRate.EffectiveFrom != "" {
+			existingRate.EffectiveFrom = updatedRate.EffectiveFrom
+		}
+		if msg := validateTaxRate(existingRate); msg != "" {
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		changed := existingRate.Category != current.Category || existingRate.Rate != current.Rate || existingRate.Inclusive != current.Inclusive || existingRate.EffectiveFrom != current.EffectiveFrom
+		if changed && tax.Started(current, time.Now()) {
+			http.Error(w, "Tax rate is already in effect, add a new rate with a later effective_from to replace it", http.StatusConflict)
+			return
+		}
+		if changed && existingRate.EffectiveFrom < time.Now().Format(tax.DateFormat) {
+			http.Error(w, "Tax rate effective_from cannot be in the past", http.StatusBadRequest)
+			return
+		}
+		existingRate.UpdatedAt = time.Now()
+
+		if err := taxRateRepo.Update(existingRate); err != nil {
+			log.Printf("Error updating tax rate: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(existingRate); err != nil {
+			log.Printf("Error encoding response: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+//method to delete a tax rate that has not taken effect yet, rates in effect are replaced by a newer one instead
+func DeleteTaxRate(taxRateRepo repository.TaxRateRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		taxRateId := vars["tax_rate_id"]
+
+		rate, err := taxRateRepo.Get(taxRateId)
+		if err == repository.ErrNotFound {
+			http.Error(w, "Tax rate not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if tax.Started(rate, time.Now()) {
+			http.Error(w, "Tax rate is already in effect, add a new rate with a later effective_from to replace it", http.StatusConflict)
+			return
+		}
+
+		if err := taxRateRepo.Delete(taxRateId); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Tax rate deleted successfully"})
+	}
+}
+
+//function to validate a tax rate and return a message describing the first problem
+func validateTaxRate(rate models.TaxRate) string {
+	if rate.Name == "" {
+		return "Tax rate name is required"
+	}
+	if !tax.ValidCategory(rate.Category) {
+		return "Tax rate category must be one of all, food or beverage"
+	}
+	if rate.Rate < 0 || rate.Rate >= 1 {
+		return "Tax rate must be a fraction between 0 and 1"
+	}
+	if _, err := time.Parse(tax.DateFormat, rate.EffectiveFrom); err != nil {
+		return "Tax rate effective_from must be a date in YYYY-MM-DD format"
+	}
+	return ""
+}
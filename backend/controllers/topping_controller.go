@@ -1,21 +1,26 @@
 package controllers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"time"
 	"log"
 	"net/http"
 	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
 
 	"github.com/gorilla/mux"
 )
 
-//method to get all toppings and  returns a http.HandlerFunc
-func GetToppings(db *sql.DB) http.HandlerFunc {
+//method to get a page of toppings, filtered and sorted like the invoice list, returns a http.HandlerFunc
+func GetToppings(toppings repository.ToppingRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		query := " SELECT topping_id,name,price FROM toppings"
-		results, err := db.Query(query)
+		q, ok := parseListing(w, r, repository.ToppingListing)
+		if !ok {
+			return
+		}
+
+		//fetch the toppings from the repository
+		toppingList, page, err := toppings.List(q)
 
 		//check if there is an error and return it to the client
 		if err != nil {
@@ -23,31 +28,12 @@ func GetToppings(db *sql.DB) http.HandlerFunc {
 			return
 		}
 
-		//defer is used to close the result set after the function returns
-		defer results.Close()
-
-		//create a slice of toppings
-		var toppings []models.Topping
-		//iterate over the result set and append the toppings to the slice
-		for results.Next() {
-			var topping models.Topping
-			//scan the result set into the topping struct and check for errors
-			if err := results.Scan(&topping.ToppingId, &topping.Name,&topping.Price); err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
-				return
-			}
-			//append the topping to the slice
-			toppings = append(toppings, topping)
-		}
-
-		//set the response header to application/json
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(toppings)
+		writePage(w, toppingList, page)
 	}
 }
 
 //method to create a topping
-func CreateTopping(db *sql.DB) http.HandlerFunc {
+func CreateTopping(toppings repository.ToppingRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		//create a topping struct
 		var topping models.Topping
@@ -59,11 +45,9 @@ func CreateTopping(db *sql.DB) http.HandlerFunc {
 
 		topping.CreatedAt = time.Now()
 		topping.UpdatedAt = time.Now()
-		//create a query to insert the topping into the database
-		query := "INSERT INTO toppings(topping_id,name,price,created_at,updated_at) VALUES(?,?,?,?,?)"
-		//execute the query and check for errors
-		if _, err := db.Exec(query, topping.ToppingId,topping.Name,topping.Price,topping.CreatedAt,topping.UpdatedAt); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		//store the topping and check for errors
+		if err := toppings.Create(topping); err != nil {
+			writeRepositoryError(w, err)
 			return
 		}
 		//set the response header to application/json
@@ -74,22 +58,17 @@ func CreateTopping(db *sql.DB) http.HandlerFunc {
 }
 
 //method to update a topping
-func UpdateTopping(db *sql.DB) http.HandlerFunc {
+func UpdateTopping(toppings repository.ToppingRepository) http.HandlerFunc {
     return func(w http.ResponseWriter, r *http.Request) {
         // Extract topping_id from URL path
         vars := mux.Vars(r)
         toppingId := vars["topping_id"]
 
-        // Fetch the existing record from the database
-        var existingTopping models.Topping
-        query := "SELECT topping_id,name,price FROM toppings WHERE topping_id = ?"
-        if err := db.QueryRow(query, toppingId).Scan(
-            &existingTopping.ToppingId,
-            &existingTopping.Name,
-            &existingTopping.Price,
-        ); err != nil {
+        // Fetch the existing record from the repository
+        existingTopping, err := toppings.Get(toppingId)
+        if err != nil {
             log.Printf("Error fetching existing Topping: %v", err)
-            http.Error(w, err.Error(), http.StatusInternalServerError)
+            writeRepositoryError(w, err)
             return
         }
 
@@ -112,19 +91,10 @@ func UpdateTopping(db *sql.DB) http.HandlerFunc {
         
         existingTopping.UpdatedAt = time.Now()
 
-        // Build the update query dynamically
-        updateQuery := "UPDATE toppings SET name=?,price=?,updated_at=? WHERE topping_id=?"
-        args := []interface{}{
-            existingTopping.Name,
-			existingTopping.Price,
-			existingTopping.UpdatedAt,
-            toppingId,
-        }
-
-        // Execute the query and check for errors
-        if _, err := db.Exec(updateQuery, args...); err != nil {
+        // Store the merged topping and check for errors
+        if err := toppings.Update(existingTopping); err != nil {
             log.Printf("Error executing update query: %v", err)
-            http.Error(w, err.Error(), http.StatusInternalServerError)
+            writeRepositoryError(w, err)
             return
         }
 
@@ -138,23 +108,14 @@ func UpdateTopping(db *sql.DB) http.HandlerFunc {
     }
 }
 //method to delete a topping
-func DeleteTopping(db *sql.DB) http.HandlerFunc {
+func DeleteTopping(toppings repository.ToppingRepository) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// extract topping_id from the request URL
 		vars := mux.Vars(r)
 		toppingId := vars["topping_id"]
 
-		//query to delete topping from associated tables
-		relatedQuery:= "DELETE FROM pizza_toppings WHERE topping_id = ?"
-		if _, err := db.Exec(relatedQuery, toppingId); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
-
-		// create a query to delete the topping from the database
-		query := "DELETE FROM toppings WHERE topping_id = ?"
-		// execute the query and check for errors
-		if _, err := db.Exec(query, toppingId); err != nil {
+		// delete the topping together with its pizza type links
+		if err := toppings.Delete(toppingId); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
+
+	"github.com/gorilla/mux"
+)
+
+//method to get all users, password hashes are never returned
+func GetUsers(userRepo repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		users, err := userRepo.List()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(users)
+	}
+}
+
+//method to create a user
+func CreateUser(userRepo repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var user models.User
+		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if user.Username == "" || user.Password == "" {
+			http.Error(w, "Username and password are required", http.StatusBadRequest)
+			return
+		}
+		if !auth.ValidRole(user.Role) {
+			http.Error(w, "Role must be one of cashier, manager or admin", http.StatusBadRequest)
+			return
+		}
+
+		user, err := userRepo.Create(user)
+		if err == repository.ErrDuplicate {
+			http.Error(w, "Username is already taken", http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(user)
+	}
+}
+
+//method to update the role or password of a user
+func UpdateUser(userRepo repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		userId := vars["user_id"]
+
+		// Fetch the existing record
+		existingUser, err := userRepo.Get(userId)
+		if err == repository.ErrNotFound {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error fetching existing user: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		var updatedUser models.User
+		if err := json.NewDecoder(r.Body).Decode(&updatedUser); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Merge the changes
+		if updatedUser.Role != "" {
+			if !auth.ValidRole(updatedUser.Role) {
+				http.Error(w, "Role must be one of cashier, manager or admin", http.StatusBadRequest)
+				return
+			}
+			if updatedUser.Role != auth.RoleAdmin {
+				last, err := userRepo.LastAdmin(userId)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if last {
+					http.Error(w, "The last admin cannot be given another role", http.StatusConflict)
+					return
+				}
+			}
+			existingUser.Role = updatedUser.Role
+		}
+		if updatedUser.Password != "" {
+			hash, err := auth.HashPassword(updatedUser.Password)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			existingUser.PasswordHash = hash
+		}
+		existingUser.UpdatedAt = time.Now()
+
+		if err := userRepo.Update(existingUser); err != nil {
+			log.Printf("Error updating user: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(existingUser)
+	}
+}
+
+//method to delete a user, admins cannot delete their own account or the last admin
+func DeleteUser(userRepo repository.UserRepository) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars := mux.Vars(r)
+		userId := vars["user_id"]
+
+		if claims, ok := auth.FromContext(r.Context()); ok && strconv.Itoa(claims.UserId) == userId {
+			http.Error(w, "You cannot delete your own account", http.StatusBadRequest)
+			return
+		}
+		last, err := userRepo.LastAdmin(userId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if last {
+			http.Error(w, "The last admin cannot be deleted", http.StatusConflict)
+			return
+		}
+
+		if err := userRepo.Delete(userId); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "User deleted successfully"})
+	}
+}
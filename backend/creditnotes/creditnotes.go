@@ -0,0 +1,298 @@
+package creditnotes
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+)
+
+//kinds of credit note, a void cancels a whole invoice and a refund gives back some or all of its lines
+const (
+	KindVoid   = "void"
+	KindRefund = "refund"
+)
+
+//CreditError is returned when a void or refund is not allowed, as opposed to a database failure
+type CreditError string
+
+func (e CreditError) Error() string { return string(e) }
+
+//function to round an amount to cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+//function to turn an amount into the negative amount printed on a credit note, without printing -0
+func negative(amount float64) float64 {
+	return 0 - round(amount)
+}
+
+//function to issue a credit note against an invoice and work out the status the invoice moves to
+//lines are the lines of the invoice with their modifiers nested and previous the credit notes already issued against it
+//a void credits every line and hands back what was paid, a refund credits the requested lines, or every line
+//that is left when none are requested, and hands back what they cost
+func Issue(kind string, invoice models.Invoice, lines []models.InvoiceItem, previous []models.CreditNote, request models.CreditNote) (models.CreditNote, string, error) {
+	if request.Reason == "" {
+		return request, "", CreditError("a reason is required")
+	}
+	if invoice.Status == payments.StatusVoid || invoice.Status == payments.StatusRefunded {
+		return request, "", payments.ErrLocked
+	}
+	switch kind {
+	case KindVoid:
+		if len(previous) > 0 {
+			return request, "", CreditError("part of the invoice is already refunded, refund the remaining lines instead")
+		}
+		if len(request.Items) > 0 {
+			return request, "", CreditError("a void cancels the whole invoice, use a refund to give back single lines")
+		}
+	case KindRefund:
+		if invoice.Status != payments.StatusPaid {
+			return request, "", CreditError("only paid invoices can be refunded, void an open invoice instead")
+		}
+	default:
+		return request, "", CreditError("kind must be void or refund")
+	}
+
+	note, complete, err := build(invoice, lines, previous, request.Items)
+	if err != nil {
+		return request, "", err
+	}
+	note.InvoiceId, _ = strconv.Atoi(invoice.InvoiceId)
+	note.Kind = kind
+	note.Reason = request.Reason
+	note.CreatedAt = time.Now()
+
+	status := payments.StatusPaid
+	switch {
+	case kind == KindVoid:
+		note.AmountRefunded = round(invoice.AmountPaid)
+		status = payments.StatusVoid
+	case complete:
+		note.AmountRefunded = round(-note.Total)
+		status = payments.StatusRefunded
+	default:
+		note.AmountRefunded = round(-note.Total)
+	}
+
+	//money is handed back in cash unless another tender is asked for
+	if note.AmountRefunded > 0 {
+		note.Tender = request.Tender
+		if note.Tender == "" {
+			note.Tender = payments.TenderCash
+		}
+		if !payments.ValidTender(note.Tender) {
+			return request, "", CreditError("tender must be one of cash, card or voucher")
+		}
+	}
+	return note, status, nil
+}
+
+//function to credit units of the lines of an invoice, a pizza is credited together with its toppings
+//discounts and tax are credited in proportion to the credited lines, delivery, packaging and service charges
+//are not given back for single lines, the credit note that clears the last line takes whatever is left
+//including the charges so the invoice and its credit notes add up to zero
+func build(invoice models.Invoice, lines []models.InvoiceItem, previous []models.CreditNote, requested []models.CreditNoteItem) (models.CreditNote, bool, error) {
+	credited := map[int]int{}
+	left := models.CreditNote{SubTotal: invoice.SubTotal, Discount: invoice.Discount, Charge: invoice.Charge, Tax: invoice.Tax, Total: invoice.Total}
+	for _, note := range previous {
+		for _, item := range note.Items {
+			credited[item.InvoiceItemId] += item.Quantity
+		}
+		left.SubTotal += note.SubTotal
+		left.Discount += note.Discount
+		left.Charge += note.Charge
+		left.Tax += note.Tax
+		left.Total += note.Total
+	}
+
+	byId := map[int]models.InvoiceItem{}
+	modifiers := map[int]bool{}
+	for _, line := range lines {
+		byId[line.InvoiceItemId] = line
+		for _, modifier := range line.Modifiers {
+			modifiers[modifier.InvoiceItemId] = true
+		}
+	}
+
+	if len(requested) == 0 {
+		for _, line := range lines {
+			if remaining := line.Quantity - credited[line.InvoiceItemId]; remaining > 0 {
+				requested = append(requested, models.CreditNoteItem{InvoiceItemId: line.InvoiceItemId, Quantity: remaining})
+			}
+		}
+		if len(requested) == 0 {
+			return models.CreditNote{}, false, CreditError("every line of the invoice has already been refunded")
+		}
+	}
+
+	var note models.CreditNote
+	seen := map[int]bool{}
+	subTotal := 0.0
+	for _, item := range requested {
+		line, ok := byId[item.InvoiceItemId]
+		if !ok {
+			if modifiers[item.InvoiceItemId] {
+				return note, false, CreditError("toppings are refunded together with their pizza")
+			}
+			return note, false, CreditError(fmt.Sprintf("line %d is not on this invoice", item.InvoiceItemId))
+		}
+		if seen[item.InvoiceItemId] {
+			return note, false, CreditError(fmt.Sprintf("line %d is listed more than once", item.InvoiceItemId))
+		}
+		seen[item.InvoiceItemId] = true
+
+		remaining := line.Quantity - credited[line.InvoiceItemId]
+		if item.Quantity == 0 {
+			item.Quantity = remaining
+		}
+		if item.Quantity < 0 {
+			return note, false, CreditError("the quantity of a refunded line must be greater than zero")
+		}
+		if item.Quantity > remaining {
+			return note, false, CreditError(fmt.Sprintf("only %d of line %d can still be refunded", remaining, line.InvoiceItemId))
+		}
+
+		gross := line.LineTotal
+		for _, modifier := range line.Modifiers {
+			gross += modifier.LineTotal
+		}
+		amount := round(gross * float64(item.Quantity) / float64(line.Quantity))
+		subTotal += amount
+		credited[line.InvoiceItemId] += item.Quantity
+
+		item.Name = line.Name
+		item.Amount = negative(amount)
+		note.Items = append(note.Items, item)
+	}
+
+	complete := true
+	for _, line := range lines {
+		if credited[line.InvoiceItemId] < line.Quantity {
+			complete = false
+		}
+	}
+
+	if complete {
+		note.SubTotal = negative(left.SubTotal)
+		note.Discount = negative(left.Discount)
+		note.Charge = negative(left.Charge)
+		note.Tax = negative(left.Tax)
+		note.Total = negative(left.Total)
+		return note, true, nil
+	}
+	ratio := 0.0
+	if invoice.SubTotal > 0 {
+		ratio = subTotal / invoice.SubTotal
+	}
+	//the tax and total of the invoice also cover its charges, only the share of the discounted items is credited
+	share := ratio
+	if base := invoice.SubTotal - invoice.Discount + invoice.Charge; base > 0 {
+		share = ratio * (invoice.SubTotal - invoice.Discount) / base
+	}
+	note.SubTotal = negative(subTotal)
+	note.Discount = negative(invoice.Discount * ratio)
+	note.Tax = negative(invoice.Tax * share)
+	note.Total = negative(invoice.Total * share)
+	return note, false, nil
+}
+
+//function to store a credit note with its lines, it returns the stored credit note with its ids
+func Save(db database.DBTX, note models.CreditNote) (models.CreditNote, error) {
+	query := "INSERT INTO credit_notes (credit_note_number, invoice_id, kind, reason, subtotal, discount, charge, tax, total, amount_refunded, tender, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	result, err := db.Exec(query, note.CreditNoteNumber, note.InvoiceId, note.Kind, note.Reason, note.SubTotal, note.Discount, note.Charge, note.Tax, note.Total, note.AmountRefunded, note.Tender, note.CreatedAt)
+	if err != nil {
+		return note, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return note, err
+	}
+	note.CreditNoteId = int(id)
+
+	query = "INSERT INTO credit_note_items (credit_note_id, invoice_item_id, item_name, quantity, amount) VALUES (?, ?, ?, ?, ?)"
+	for i, item := range note.Items {
+		item.CreditNoteId = note.CreditNoteId
+		result, err := db.Exec(query, item.CreditNoteId, item.InvoiceItemId, item.Name, item.Quantity, item.Amount)
+		if err != nil {
+			return note, err
+		}
+		itemId, err := result.LastInsertId()
+		if err != nil {
+			return note, err
+		}
+		item.CreditNoteItemId = int(itemId)
+		note.Items[i] = item
+	}
+	return note, nil
+}
+
+//function to load the credit notes issued against an invoice in the order they were issued
+func Load(db database.DBTX, invoiceId string) ([]models.CreditNote, error) {
+	return load(db, "WHERE invoice_id = ? ORDER BY credit_note_id", invoiceId)
+}
+
+//function to load one credit note, it returns sql.ErrNoRows when the credit note does not exist
+func Get(db database.DBTX, creditNoteId string) (models.CreditNote, error) {
+	notes, err := load(db, "WHERE credit_note_id = ?", creditNoteId)
+	if err != nil {
+		return models.CreditNote{}, err
+	}
+	if len(notes) == 0 {
+		return models.CreditNote{}, sql.ErrNoRows
+	}
+	return notes[0], nil
+}
+
+//function to read the credit notes matching a where clause together with their lines
+func load(db database.DBTX, clause string, args ...interface{}) ([]models.CreditNote, error) {
+	query := "SELECT credit_note_id, credit_note_number, invoice_id, kind, reason, subtotal, discount, charge, tax, total, amount_refunded, tender, created_at FROM credit_notes " + clause
+	results, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var notes []models.CreditNote
+	index := map[int]int{}
+	for results.Next() {
+		var note models.CreditNote
+		if err := results.Scan(&note.CreditNoteId, &note.CreditNoteNumber, &note.InvoiceId, &note.Kind, &note.Reason, &note.SubTotal, &note.Discount, &note.Charge, &note.Tax, &note.Total, &note.AmountRefunded, &note.Tender, &note.CreatedAt); err != nil {
+			results.Close()
+			return nil, err
+		}
+		index[note.CreditNoteId] = len(notes)
+		notes = append(notes, note)
+	}
+	results.Close()
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range notes {
+		notes[i].Items = []models.CreditNoteItem{}
+	}
+	for id, position := range index {
+		results, err := db.Query("SELECT credit_note_item_id, credit_note_id, invoice_item_id, item_name, quantity, amount FROM credit_note_items WHERE credit_note_id = ? ORDER BY credit_note_item_id", id)
+		if err != nil {
+			return nil, err
+		}
+		for results.Next() {
+			var item models.CreditNoteItem
+			if err := results.Scan(&item.CreditNoteItemId, &item.CreditNoteId, &item.InvoiceItemId, &item.Name, &item.Quantity, &item.Amount); err != nil {
+				results.Close()
+				return nil, err
+			}
+			notes[position].Items = append(notes[position].Items, item)
+		}
+		results.Close()
+		if err := results.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return notes, nil
+}
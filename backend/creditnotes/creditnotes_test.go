@@ -0,0 +1,215 @@
+package creditnotes
+
+import (
+	"reflect"
+	"testing"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+)
+
+//function to build an invoice of two pizzas with a topping each and a beverage, taxed at 10% after a 10% discount
+func sampleInvoice(status string, paid float64) (models.Invoice, []models.InvoiceItem) {
+	invoice := models.Invoice{InvoiceId: "7", Status: status, SubTotal: 30, Discount: 3, Tax: 2.7, Total: 29.7, AmountPaid: paid}
+	lines := []models.InvoiceItem{
+		{InvoiceItemId: 1, Name: "Margherita", Quantity: 2, LineTotal: 20, Modifiers: []models.InvoiceItem{
+			{InvoiceItemId: 2, Name: "Olives", Quantity: 2, LineTotal: 4},
+		}},
+		{InvoiceItemId: 3, Name: "Cola", Quantity: 1, LineTotal: 6},
+	}
+	return invoice, lines
+}
+
+//the refund of one of the two pizzas, 12 of the 30 subtotal
+var onePizza = models.CreditNote{
+	InvoiceId: 7, Kind: KindRefund, Reason: "cold",
+	SubTotal: -12, Discount: -1.2, Tax: -1.08, Total: -11.88, AmountRefunded: 11.88, Tender: payments.TenderCard,
+	Items: []models.CreditNoteItem{{InvoiceItemId: 1, Name: "Margherita", Quantity: 1, Amount: -12}},
+}
+
+func TestIssue(t *testing.T) {
+	tests := []struct {
+		name       string
+		kind       string
+		status     string
+		paid       float64
+		previous   []models.CreditNote
+		request    models.CreditNote
+		want       models.CreditNote
+		wantStatus string
+	}{
+		{
+			name:    "void of an unpaid invoice hands nothing back",
+			kind:    KindVoid,
+			status:  payments.StatusOpen,
+			request: models.CreditNote{Reason: "wrong order"},
+			want: models.CreditNote{
+				InvoiceId: 7, Kind: KindVoid, Reason: "wrong order",
+				SubTotal: -30, Discount: -3, Tax: -2.7, Total: -29.7,
+				Items: []models.CreditNoteItem{
+					{InvoiceItemId: 1, Name: "Margherita", Quantity: 2, Amount: -24},
+					{InvoiceItemId: 3, Name: "Cola", Quantity: 1, Amount: -6},
+				},
+			},
+			wantStatus: payments.StatusVoid,
+		},
+		{
+			name:    "void of a paid invoice hands back what was paid in cash",
+			kind:    KindVoid,
+			status:  payments.StatusPaid,
+			paid:    29.7,
+			request: models.CreditNote{Reason: "wrong order"},
+			want: models.CreditNote{
+				InvoiceId: 7, Kind: KindVoid, Reason: "wrong order",
+				SubTotal: -30, Discount: -3, Tax: -2.7, Total: -29.7, AmountRefunded: 29.7, Tender: payments.TenderCash,
+				Items: []models.CreditNoteItem{
+					{InvoiceItemId: 1, Name: "Margherita", Quantity: 2, Amount: -24},
+					{InvoiceItemId: 3, Name: "Cola", Quantity: 1, Amount: -6},
+				},
+			},
+			wantStatus: payments.StatusVoid,
+		},
+		{
+			name:   "refund of one unit credits its share of discount and tax",
+			kind:   KindRefund,
+			status: payments.StatusPaid,
+			paid:   29.7,
+			request: models.CreditNote{Reason: "cold", Tender: payments.TenderCard, Items: []models.CreditNoteItem{
+				{InvoiceItemId: 1, Quantity: 1},
+			}},
+			want:       onePizza,
+			wantStatus: payments.StatusPaid,
+		},
+		{
+			name:     "refund of the remaining lines takes whatever is left",
+			kind:     KindRefund,
+			status:   payments.StatusPaid,
+			paid:     29.7,
+			previous: []models.CreditNote{onePizza},
+			request:  models.CreditNote{Reason: "closing early"},
+			want: models.CreditNote{
+				InvoiceId: 7, Kind: KindRefund, Reason: "closing early",
+				SubTotal: -18, Discount: -1.8, Tax: -1.62, Total: -17.82, AmountRefunded: 17.82, Tender: payments.TenderCash,
+				Items: []models.CreditNoteItem{
+					{InvoiceItemId: 1, Name: "Margherita", Quantity: 1, Amount: -12},
+					{InvoiceItemId: 3, Name: "Cola", Quantity: 1, Amount: -6},
+				},
+			},
+			wantStatus: payments.StatusRefunded,
+		},
+		{
+			name:   "a quantity of zero refunds every unit left on the line",
+			kind:   KindRefund,
+			status: payments.StatusPaid,
+			paid:   29.7,
+			request: models.CreditNote{Reason: "spilled", Items: []models.CreditNoteItem{
+				{InvoiceItemId: 3},
+			}},
+			want: models.CreditNote{
+				InvoiceId: 7, Kind: KindRefund, Reason: "spilled",
+				SubTotal: -6, Discount: -0.6, Tax: -0.54, Total: -5.94, AmountRefunded: 5.94, Tender: payments.TenderCash,
+				Items: []models.CreditNoteItem{{InvoiceItemId: 3, Name: "Cola", Quantity: 1, Amount: -6}},
+			},
+			wantStatus: payments.StatusPaid,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice, lines := sampleInvoice(tt.status, tt.paid)
+			got, status, err := Issue(tt.kind, invoice, lines, tt.previous, tt.request)
+			if err != nil {
+				t.Fatalf("Issue() error = %v", err)
+			}
+			if got.CreatedAt.IsZero() {
+				t.Error("credit note has no creation time")
+			}
+			got.CreatedAt = tt.want.CreatedAt
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Issue() = %+v, want %+v", got, tt.want)
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestIssueRejects(t *testing.T) {
+	refund := func(items ...models.CreditNoteItem) models.CreditNote {
+		return models.CreditNote{Reason: "cold", Items: items}
+	}
+	tests := []struct {
+		name     string
+		kind     string
+		status   string
+		previous []models.CreditNote
+		request  models.CreditNote
+		locked   bool
+	}{
+		{name: "missing reason", kind: KindRefund, status: payments.StatusPaid, request: models.CreditNote{}},
+		{name: "unknown kind", kind: "discount", status: payments.StatusPaid, request: refund()},
+		{name: "void invoice", kind: KindRefund, status: payments.StatusVoid, request: refund(), locked: true},
+		{name: "refunded invoice", kind: KindVoid, status: payments.StatusRefunded, request: refund(), locked: true},
+		{name: "refund of an open invoice", kind: KindRefund, status: payments.StatusOpen, request: refund()},
+		{name: "void with lines", kind: KindVoid, status: payments.StatusPaid, request: refund(models.CreditNoteItem{InvoiceItemId: 1, Quantity: 1})},
+		{name: "void after a refund", kind: KindVoid, status: payments.StatusPaid, previous: []models.CreditNote{onePizza}, request: refund()},
+		{name: "topping without its pizza", kind: KindRefund, status: payments.StatusPaid, request: refund(models.CreditNoteItem{InvoiceItemId: 2, Quantity: 1})},
+		{name: "line of another invoice", kind: KindRefund, status: payments.StatusPaid, request: refund(models.CreditNoteItem{InvoiceItemId: 9, Quantity: 1})},
+		{name: "line listed twice", kind: KindRefund, status: payments.StatusPaid, request: refund(models.CreditNoteItem{InvoiceItemId: 1, Quantity: 1}, models.CreditNoteItem{InvoiceItemId: 1, Quantity: 1})},
+		{name: "negative quantity", kind: KindRefund, status: payments.StatusPaid, request: refund(models.CreditNoteItem{InvoiceItemId: 1, Quantity: -1})},
+		{name: "more than was sold", kind: KindRefund, status: payments.StatusPaid, request: refund(models.CreditNoteItem{InvoiceItemId: 3, Quantity: 2})},
+		{name: "more than is left", kind: KindRefund, status: payments.StatusPaid, previous: []models.CreditNote{onePizza}, request: refund(models.CreditNoteItem{InvoiceItemId: 1, Quantity: 2})},
+		{name: "unknown tender", kind: KindRefund, status: payments.StatusPaid, request: models.CreditNote{Reason: "cold", Tender: "cheque"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice, lines := sampleInvoice(tt.status, 29.7)
+			_, _, err := Issue(tt.kind, invoice, lines, tt.previous, tt.request)
+			if tt.locked {
+				if err != payments.ErrLocked {
+					t.Errorf("Issue() error = %v, want %v", err, payments.ErrLocked)
+				}
+				return
+			}
+			if _, ok := err.(CreditError); !ok {
+				t.Errorf("Issue() error = %v, want a CreditError", err)
+			}
+		})
+	}
+}
+
+func TestIssueCharges(t *testing.T) {
+	//the sample invoice delivered for a charge of 3, taxed at 10% along with the items
+	invoice, lines := sampleInvoice(payments.StatusPaid, 33)
+	invoice.Charge, invoice.Tax, invoice.Total = 3, 3, 33
+
+	first, _, err := Issue(KindRefund, invoice, lines, nil, models.CreditNote{Reason: "cold", Items: []models.CreditNoteItem{{InvoiceItemId: 1, Quantity: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Charge != 0 || first.Tax != -1.08 || first.Total != -11.88 {
+		t.Errorf("refund of one pizza credits charge %v, tax %v and total %v, want 0, -1.08 and -11.88", first.Charge, first.Tax, first.Total)
+	}
+
+	last, status, err := Issue(KindRefund, invoice, lines, []models.CreditNote{first}, models.CreditNote{Reason: "closing early"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last.Charge != -3 || last.Tax != -1.92 || last.Total != -21.12 {
+		t.Errorf("refund of the rest credits charge %v, tax %v and total %v, want -3, -1.92 and -21.12", last.Charge, last.Tax, last.Total)
+	}
+	if status != payments.StatusRefunded {
+		t.Errorf("status = %q, want %q", status, payments.StatusRefunded)
+	}
+	if sum := first.Total + last.Total; round(sum) != -invoice.Total {
+		t.Errorf("credit notes add up to %v, want %v", sum, -invoice.Total)
+	}
+
+	void, _, err := Issue(KindVoid, invoice, lines, nil, models.CreditNote{Reason: "wrong order"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if void.Charge != -3 || void.Total != -33 {
+		t.Errorf("void credits charge %v and total %v, want -3 and -33", void.Charge, void.Total)
+	}
+}
@@ -0,0 +1,54 @@
+package databasetest
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"piza_shop_billing/backend/database"
+)
+
+//function to open an empty in-memory sqlite database with every migration applied
+//the sqlite dialect is selected for the length of the test and the database is closed when it ends
+func Open(t testing.TB) *sql.DB {
+	t.Helper()
+	previous := database.Current
+	t.Cleanup(func() { database.Current = previous })
+
+	db, err := database.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+//function to open an empty sqlite database file with every migration applied, its connections
+//can run transactions side by side so tests of concurrent requests see the locks they take
+func OpenFile(t testing.TB) *sql.DB {
+	t.Helper()
+	db := open(t, "file:"+filepath.Join(t.TempDir(), "test.db")+"?_foreign_keys=on&_busy_timeout=5000")
+	migrate(t, db)
+	return db
+}
+
+func open(t testing.TB, dsn string) *sql.DB {
+	t.Helper()
+	previous := database.Current
+	database.Current = database.SQLite
+	t.Cleanup(func() { database.Current = previous })
+
+	db, err := sql.Open(database.SQLite.Driver, dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func migrate(t testing.TB, db *sql.DB) {
+	t.Helper()
+	if _, err := database.MigrateUp(db); err != nil {
+		t.Fatal(err)
+	}
+}
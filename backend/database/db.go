@@ -9,32 +9,59 @@ import (
 	"github.com/joho/godotenv" 
 	//allows us to use mysql driver to connect to the database
 	_ "github.com/go-sql-driver/mysql"  
+	//allows us to use an embedded sqlite file as the database
+	_ "github.com/mattn/go-sqlite3"
 
 )
 
 //variable to hold the database connection pool
 var DB *sql.DB 
 
-//function to connect to the database
+//DBTX is satisfied by both *sql.DB and *sql.Tx so the queries of an invoice can run inside a transaction
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+//function to connect to the database and bring its schema up to date
+//set DB_AUTO_MIGRATE=false to leave the schema to the migrate command
 func Connect()  {
-	//Load environment variables from .env file
-	err := godotenv.Load()
+	Open()
+
+	if os.Getenv("DB_AUTO_MIGRATE") == "false" {
+		return
+	}
+	applied, err := MigrateUp(DB)
 	if err != nil {
-		log.Fatalf("Error loading .env file %v", err)
+		log.Fatalf("Error migrating the database %v", err)
 	}
+	for _, migration := range applied {
+		log.Printf("Applied migration %d_%s", migration.Version, migration.Name)
+	}
+}
 
-	//retrieve the environment variables
-	dbUser := os.Getenv("DB_USER")
-	dbPassword := os.Getenv("DB_PASSWORD")
-	dbHost := os.Getenv("DB_HOST")
-	dbPort := os.Getenv("DB_PORT")
-	dbName := os.Getenv("DB_NAME")
-
-	//build the Data Souce Name (DSN)
-	DSN := dbUser + ":" + dbPassword + "@tcp(" + dbHost + ":" + dbPort + ")/" + dbName
+//function to connect to the database without touching its schema
+//DB_DRIVER selects mysql (the default) or sqlite, which stores everything in the SQLITE_PATH file
+func Open()  {
+	//Load environment variables from .env file, the real environment is enough without one
+	if err := godotenv.Load(); err != nil {
+		log.Printf("No .env file loaded, using the environment: %v", err)
+	}
 
-	//connect to the database
-	DB, err = sql.Open("mysql", DSN)
+	var err error
+	switch os.Getenv("DB_DRIVER") {
+	case "", "mysql":
+		Current = MySQL
+		DB, err = sql.Open(MySQL.Driver, mysqlDSN())
+	case "sqlite":
+		Current = SQLite
+		DB, err = sql.Open(SQLite.Driver, sqliteDSN())
+		//SQLite allows one writer at a time, a single connection keeps the API from tripping over its own locks
+		DB.SetMaxOpenConns(1)
+	default:
+		log.Fatalf("Unknown DB_DRIVER %q, expected mysql or sqlite", os.Getenv("DB_DRIVER"))
+	}
 	if err != nil {
 		log.Fatalf("Error connecting to the database %v", err)
 	}
@@ -44,6 +71,46 @@ func Connect()  {
 	}
 
 	//log a message to  the console to indicate that the connection was successful
-	log.Println("Connected to the database")
+	log.Printf("Connected to the %s database", Current.Driver)
+
+}
 
-}
\ No newline at end of file
+//function to open an empty SQLite database held in memory with every migration applied
+//it selects the SQLite dialect, and everything stored is gone once the database is closed
+func OpenMemory() (*sql.DB, error) {
+	Current = SQLite
+	db, err := sql.Open(SQLite.Driver, "file::memory:?_foreign_keys=on")
+	if err != nil {
+		return nil, err
+	}
+	//every connection to :memory: opens a database of its own
+	db.SetMaxOpenConns(1)
+	if _, err := MigrateUp(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+//function to build the MySQL Data Souce Name (DSN) from the DB_* variables
+func mysqlDSN() string {
+	//retrieve the environment variables
+	dbUser := os.Getenv("DB_USER")
+	dbPassword := os.Getenv("DB_PASSWORD")
+	dbHost := os.Getenv("DB_HOST")
+	dbPort := os.Getenv("DB_PORT")
+	dbName := os.Getenv("DB_NAME")
+
+	//parseTime lets DATETIME columns be scanned into time.Time, and loc=Local stores and reads them
+	//in local time like NOW(), so DATE() puts them on the day they happened in the shop
+	return dbUser + ":" + dbPassword + "@tcp(" + dbHost + ":" + dbPort + ")/" + dbName + "?parseTime=true&loc=Local"
+}
+
+//function to build the SQLite DSN, foreign keys are off in SQLite unless asked for
+func sqliteDSN() string {
+	path := os.Getenv("SQLITE_PATH")
+	if path == "" {
+		path = "pizza_shop.db"
+	}
+	return "file:" + path + "?_foreign_keys=on&_busy_timeout=5000"
+}
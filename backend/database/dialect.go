@@ -0,0 +1,57 @@
+package database
+
+//Dialect writes the SQL fragments that differ between the supported databases
+type Dialect struct {
+	//name of the database/sql driver
+	Driver string
+	//directory of the embedded migrations written for this database
+	Migrations string
+}
+
+//supported dialects
+var (
+	MySQL  = Dialect{Driver: "mysql", Migrations: "migrations/mysql"}
+	SQLite = Dialect{Driver: "sqlite3", Migrations: "migrations/sqlite"}
+)
+
+//dialect of the connection held in DB
+var Current = MySQL
+
+//function to format a date or datetime column as YYYY-MM-DD in local time
+func (d Dialect) FormatDate(column string) string {
+	if d == SQLite {
+		return localTime("%Y-%m-%d", column)
+	}
+	return "DATE_FORMAT(" + column + ",'%Y-%m-%d')"
+}
+
+//function to format a datetime column as YYYY-MM-DD HH:MM in local time
+func (d Dialect) FormatDateTime(column string) string {
+	if d == SQLite {
+		return localTime("%Y-%m-%d %H:%M", column)
+	}
+	return "DATE_FORMAT(" + column + ",'%Y-%m-%d %H:%i')"
+}
+
+//function to format a SQLite column holding local times with strftime
+//the driver writes time values with their offset, such as 2026-01-31 23:30:00+01:00, and strftime turns
+//those into UTC, so they are turned back into local time to fall on the same day as the times written by Now
+func localTime(format string, column string) string {
+	return "CASE WHEN " + column + " GLOB '*[+-][0-9][0-9]:[0-9][0-9]' THEN strftime('" + format + "', " + column + ", 'localtime') ELSE strftime('" + format + "', " + column + ") END"
+}
+
+//function to return the expression for the current local date and time
+func (d Dialect) Now() string {
+	if d == SQLite {
+		return "datetime('now','localtime')"
+	}
+	return "NOW()"
+}
+
+//function to return the clause that turns an insert into an update of the existing row when the keys are taken
+func (d Dialect) OnConflictUpdate(keys string, assignment string) string {
+	if d == SQLite {
+		return "ON CONFLICT(" + keys + ") DO UPDATE SET " + assignment
+	}
+	return "ON DUPLICATE KEY UPDATE " + assignment
+}
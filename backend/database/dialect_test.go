@@ -0,0 +1,52 @@
+package database_test
+
+import (
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/database/databasetest"
+)
+
+func TestFormatDateInLocalTime(t *testing.T) {
+	db := databasetest.Open(t)
+	if _, err := db.Exec("CREATE TABLE moments (at DATETIME NOT NULL)"); err != nil {
+		t.Fatal(err)
+	}
+
+	//a few minutes either side of midnight in zones east and west of the shop
+	local := time.Date(2026, 1, 31, 23, 55, 0, 0, time.Local)
+	tests := []struct {
+		name string
+		at   interface{}
+		want time.Time
+	}{
+		{name: "local time", at: local, want: local},
+		{name: "utc time", at: local.UTC(), want: local},
+		{name: "time east of the shop", at: local.In(time.FixedZone("east", 10*3600)), want: local},
+		{name: "time west of the shop", at: local.In(time.FixedZone("west", -10*3600)), want: local},
+		{name: "local text written by now", at: "2026-01-31 23:55:00", want: local},
+		{name: "date", at: "2026-01-31", want: time.Date(2026, 1, 31, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := db.Exec("DELETE FROM moments"); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := db.Exec("INSERT INTO moments (at) VALUES (?)", tt.at); err != nil {
+				t.Fatal(err)
+			}
+			var date, dateTime string
+			query := "SELECT " + database.Current.FormatDate("at") + ", " + database.Current.FormatDateTime("at") + " FROM moments"
+			if err := db.QueryRow(query).Scan(&date, &dateTime); err != nil {
+				t.Fatal(err)
+			}
+			if want := tt.want.Format("2006-01-02"); date != want {
+				t.Errorf("FormatDate = %q, want %q", date, want)
+			}
+			if want := tt.want.Format("2006-01-02 15:04"); dateTime != want {
+				t.Errorf("FormatDateTime = %q, want %q", dateTime, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//the versioned schema is compiled into the binary so every environment runs the same SQL
+//each dialect has its own directory of files named <version>_<name>.up.sql and <version>_<name>.down.sql
+//
+//go:embed migrations/mysql/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+//Migration is one versioned change to the schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+//MigrationState tells whether a migration has been applied to the database
+type MigrationState struct {
+	Migration
+	Applied   bool
+	AppliedAt string
+}
+
+const createMigrationsTable = `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version INT NOT NULL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+)`
+
+//function to read the embedded migrations of the current dialect sorted by version
+func Migrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrationFiles, Current.Migrations)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionText, name, _ := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionText)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: invalid version %q", fileName, versionText)
+		}
+
+		content, err := fs.ReadFile(migrationFiles, path.Join(Current.Migrations, fileName))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var migrations []Migration
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+//function to apply every migration that has not been applied yet, returns the applied migrations
+func MigrateUp(db *sql.DB) ([]Migration, error) {
+	states, err := MigrationStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []Migration
+	for _, state := range states {
+		if state.Applied {
+			continue
+		}
+		if err := runMigration(db, state.Migration, state.Up,
+			"INSERT INTO schema_migrations (version, name) VALUES (?, ?)", state.Version, state.Name); err != nil {
+			return applied, err
+		}
+		applied = append(applied, state.Migration)
+	}
+	return applied, nil
+}
+
+//function to revert the latest applied migrations, returns the reverted migrations
+func MigrateDown(db *sql.DB, steps int) ([]Migration, error) {
+	states, err := MigrationStatus(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted []Migration
+	for i := len(states) - 1; i >= 0 && len(reverted) < steps; i-- {
+		state := states[i]
+		if !state.Applied {
+			continue
+		}
+		if state.Down == "" {
+			return reverted, fmt.Errorf("migration %d_%s cannot be reverted, it has no down script", state.Version, state.Name)
+		}
+		if err := runMigration(db, state.Migration, state.Down,
+			"DELETE FROM schema_migrations WHERE version = ?", state.Version); err != nil {
+			return reverted, err
+		}
+		reverted = append(reverted, state.Migration)
+	}
+	return reverted, nil
+}
+
+//function to list every known migration with whether it has been applied
+func MigrationStatus(db *sql.DB) ([]MigrationState, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(createMigrationsTable); err != nil {
+		return nil, err
+	}
+
+	results, err := db.Query("SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	appliedAt := map[int]string{}
+	for results.Next() {
+		var version int
+		var at string
+		if err := results.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		appliedAt[version] = at
+	}
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+
+	var states []MigrationState
+	for _, migration := range migrations {
+		at, ok := appliedAt[migration.Version]
+		states = append(states, MigrationState{Migration: migration, Applied: ok, AppliedAt: at})
+	}
+	return states, nil
+}
+
+//function to run the statements of one migration script and record it in schema_migrations
+//MySQL commits DDL implicitly, there the transaction only keeps the bookkeeping row consistent with data changes
+func runMigration(db *sql.DB, migration Migration, script string, record string, args ...interface{}) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, statement := range splitStatements(script) {
+		if _, err := tx.Exec(statement); err != nil {
+			return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	if _, err := tx.Exec(record, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//function to split a script into statements on the semicolons ending a line
+//comment lines starting with -- are dropped
+func splitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statement := strings.TrimSuffix(strings.TrimSpace(current.String()), ";")
+			statements = append(statements, statement)
+			current.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
+}
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS pizza_toppings;
+DROP TABLE IF EXISTS beverages;
+DROP TABLE IF EXISTS toppings;
+DROP TABLE IF EXISTS pizza_types;
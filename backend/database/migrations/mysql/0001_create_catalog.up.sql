@@ -0,0 +1,33 @@
+CREATE TABLE IF NOT EXISTS pizza_types (
+    pizza_type_id VARCHAR(50) NOT NULL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    size VARCHAR(20) NOT NULL DEFAULT '',
+    base_price DECIMAL(10,2) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS toppings (
+    topping_id VARCHAR(50) NOT NULL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS beverages (
+    beverage_id VARCHAR(50) NOT NULL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pizza_toppings (
+    pizza_topping_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    pizza_type_id VARCHAR(50) NOT NULL,
+    topping_id VARCHAR(50) NOT NULL,
+    CONSTRAINT fk_pizza_toppings_pizza_type FOREIGN KEY (pizza_type_id) REFERENCES pizza_types (pizza_type_id),
+    CONSTRAINT fk_pizza_toppings_topping FOREIGN KEY (topping_id) REFERENCES toppings (topping_id)
+);
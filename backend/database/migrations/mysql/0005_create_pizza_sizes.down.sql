@@ -0,0 +1,2 @@
+ALTER TABLE invoice_items DROP COLUMN size;
+DROP TABLE IF EXISTS pizza_sizes;
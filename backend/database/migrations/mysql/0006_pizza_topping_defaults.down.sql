@@ -0,0 +1,4 @@
+-- the foreign key on pizza_type_id needs an index of its own once the unique index is gone
+CREATE INDEX idx_pizza_toppings_pizza_type ON pizza_toppings (pizza_type_id);
+DROP INDEX uq_pizza_toppings_pizza_topping ON pizza_toppings;
+ALTER TABLE pizza_toppings DROP COLUMN is_default;
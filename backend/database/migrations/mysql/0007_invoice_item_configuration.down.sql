@@ -0,0 +1 @@
+ALTER TABLE invoice_items DROP COLUMN configuration;
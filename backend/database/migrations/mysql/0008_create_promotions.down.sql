@@ -0,0 +1,4 @@
+ALTER TABLE invoices DROP COLUMN discount;
+DROP TABLE IF EXISTS invoice_discounts;
+DROP TABLE IF EXISTS invoice_coupons;
+DROP TABLE IF EXISTS promotions;
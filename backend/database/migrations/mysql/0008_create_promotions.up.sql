@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS promotions (
+    promotion_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    value DECIMAL(10,4) NOT NULL DEFAULT 0,
+    pizza_type_id VARCHAR(50) NOT NULL DEFAULT '',
+    beverage_id VARCHAR(50) NOT NULL DEFAULT '',
+    buy_quantity INT NOT NULL DEFAULT 0,
+    free_quantity INT NOT NULL DEFAULT 0,
+    coupon_code VARCHAR(50) NULL,
+    usage_limit INT NOT NULL DEFAULT 0,
+    times_used INT NOT NULL DEFAULT 0,
+    valid_from VARCHAR(10) NOT NULL DEFAULT '',
+    valid_to VARCHAR(10) NOT NULL DEFAULT '',
+    days VARCHAR(30) NOT NULL DEFAULT '',
+    start_time VARCHAR(5) NOT NULL DEFAULT '',
+    end_time VARCHAR(5) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_promotions_coupon_code UNIQUE (coupon_code)
+);
+
+-- coupon codes entered on an invoice, each one counts once against the usage limit of its promotion
+CREATE TABLE IF NOT EXISTS invoice_coupons (
+    invoice_id INT NOT NULL,
+    promotion_id INT NOT NULL,
+    coupon_code VARCHAR(50) NOT NULL,
+    PRIMARY KEY (invoice_id, promotion_id),
+    CONSTRAINT fk_invoice_coupons_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id) ON DELETE CASCADE,
+    CONSTRAINT fk_invoice_coupons_promotion FOREIGN KEY (promotion_id) REFERENCES promotions (promotion_id)
+);
+
+-- snapshot of the promotions applied to an invoice, promotion_id is NULL once the promotion is deleted
+CREATE TABLE IF NOT EXISTS invoice_discounts (
+    invoice_discount_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    invoice_id INT NOT NULL,
+    promotion_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    coupon_code VARCHAR(50) NOT NULL DEFAULT '',
+    amount DECIMAL(10,2) NOT NULL,
+    CONSTRAINT fk_invoice_discounts_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id) ON DELETE CASCADE,
+    CONSTRAINT fk_invoice_discounts_promotion FOREIGN KEY (promotion_id) REFERENCES promotions (promotion_id) ON DELETE SET NULL
+);
+
+ALTER TABLE invoices ADD COLUMN discount DECIMAL(10,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS payments (
+    payment_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    invoice_id INT NOT NULL,
+    tender VARCHAR(20) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    tendered DECIMAL(10,2) NOT NULL DEFAULT 0,
+    change_given DECIMAL(10,2) NOT NULL DEFAULT 0,
+    reference VARCHAR(100) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_payments_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id)
+);
+
+-- invoices billed before payments were recorded stay open
+ALTER TABLE invoices ADD COLUMN status VARCHAR(10) NOT NULL DEFAULT 'open';
+ALTER TABLE invoices ADD COLUMN amount_paid DECIMAL(10,2) NOT NULL DEFAULT 0;
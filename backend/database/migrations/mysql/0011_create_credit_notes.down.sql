@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS credit_note_items;
+DROP TABLE IF EXISTS credit_notes;
@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS credit_notes (
+    credit_note_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    credit_note_number VARCHAR(20) NULL,
+    invoice_id INT NOT NULL,
+    kind VARCHAR(10) NOT NULL,
+    reason VARCHAR(255) NOT NULL,
+    subtotal DECIMAL(10,2) NOT NULL,
+    discount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    tax DECIMAL(10,2) NOT NULL,
+    total DECIMAL(10,2) NOT NULL,
+    amount_refunded DECIMAL(10,2) NOT NULL DEFAULT 0,
+    tender VARCHAR(20) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_credit_notes_number UNIQUE (credit_note_number),
+    CONSTRAINT fk_credit_notes_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id)
+);
+
+CREATE TABLE IF NOT EXISTS credit_note_items (
+    credit_note_item_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    credit_note_id INT NOT NULL,
+    invoice_item_id INT NOT NULL,
+    item_name VARCHAR(100) NOT NULL DEFAULT '',
+    quantity INT NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    CONSTRAINT fk_credit_note_items_note FOREIGN KEY (credit_note_id) REFERENCES credit_notes (credit_note_id) ON DELETE CASCADE,
+    CONSTRAINT fk_credit_note_items_item FOREIGN KEY (invoice_item_id) REFERENCES invoice_items (invoice_item_id)
+);
@@ -0,0 +1,3 @@
+DROP INDEX uq_invoices_number ON invoices;
+ALTER TABLE invoices DROP COLUMN invoice_number;
+DROP TABLE IF EXISTS number_sequences;
@@ -0,0 +1,11 @@
+-- last number handed out per prefix and year, the row is locked while a document takes its number
+CREATE TABLE IF NOT EXISTS number_sequences (
+    prefix VARCHAR(20) NOT NULL,
+    year INT NOT NULL,
+    last_number INT NOT NULL,
+    PRIMARY KEY (prefix, year)
+);
+
+-- invoices billed before numbering keep a NULL number and are shown by their id
+ALTER TABLE invoices ADD COLUMN invoice_number VARCHAR(40) NULL;
+CREATE UNIQUE INDEX uq_invoices_number ON invoices (invoice_number);
@@ -0,0 +1,4 @@
+DROP INDEX idx_invoices_customer ON invoices;
+ALTER TABLE invoices DROP COLUMN customer_id;
+DROP TABLE IF EXISTS customer_addresses;
+DROP TABLE IF EXISTS customers;
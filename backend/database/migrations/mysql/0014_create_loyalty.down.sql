@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS loyalty_ledger;
+DROP TABLE IF EXISTS loyalty_redemptions;
+DROP TABLE IF EXISTS loyalty_rules;
@@ -0,0 +1,40 @@
+CREATE TABLE IF NOT EXISTS loyalty_rules (
+    rule_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    points DECIMAL(10,2) NOT NULL,
+    item_kind VARCHAR(20) NOT NULL DEFAULT '',
+    item_id VARCHAR(50) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS loyalty_redemptions (
+    redemption_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    invoice_id INT NOT NULL,
+    customer_id INT NOT NULL,
+    reward VARCHAR(20) NOT NULL,
+    points INT NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    beverage_id VARCHAR(50) NOT NULL DEFAULT '',
+    created_by VARCHAR(50) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_loyalty_redemptions_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id)
+);
+
+-- every movement of points is kept with the balance it left, so a balance can be traced back entry by entry
+CREATE TABLE IF NOT EXISTS loyalty_ledger (
+    entry_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    customer_id INT NOT NULL,
+    invoice_id INT NULL,
+    redemption_id INT NULL,
+    kind VARCHAR(10) NOT NULL,
+    points INT NOT NULL,
+    balance INT NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_by VARCHAR(50) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX idx_loyalty_ledger_customer ON loyalty_ledger (customer_id);
+CREATE INDEX idx_loyalty_ledger_invoice ON loyalty_ledger (invoice_id);
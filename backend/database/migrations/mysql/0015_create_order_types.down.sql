@@ -0,0 +1,7 @@
+ALTER TABLE invoices DROP COLUMN charge;
+ALTER TABLE invoices DROP COLUMN table_number;
+ALTER TABLE invoices DROP COLUMN order_type;
+DROP TABLE IF EXISTS invoice_deliveries;
+DROP TABLE IF EXISTS invoice_charges;
+DROP TABLE IF EXISTS order_charges;
+DROP TABLE IF EXISTS delivery_zones;
@@ -0,0 +1,56 @@
+-- delivery areas of the shop, the zone of a delivery decides its fee
+CREATE TABLE IF NOT EXISTS delivery_zones (
+    zone_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    fee DECIMAL(10,2) NOT NULL DEFAULT 0,
+    free_over DECIMAL(10,2) NOT NULL DEFAULT 0,
+    postal_codes VARCHAR(500) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- charges added to every invoice of an order type, such as packaging on takeaway or service on dine-in
+CREATE TABLE IF NOT EXISTS order_charges (
+    charge_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    order_type VARCHAR(20) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    effective_from DATE NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- snapshot of the charges and delivery fee added to an invoice, charge_id and zone_id are NULL once they are deleted
+CREATE TABLE IF NOT EXISTS invoice_charges (
+    invoice_charge_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    invoice_id INT NOT NULL,
+    charge_id INT NULL,
+    zone_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    CONSTRAINT fk_invoice_charges_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id) ON DELETE CASCADE,
+    CONSTRAINT fk_invoice_charges_charge FOREIGN KEY (charge_id) REFERENCES order_charges (charge_id) ON DELETE SET NULL,
+    CONSTRAINT fk_invoice_charges_zone FOREIGN KEY (zone_id) REFERENCES delivery_zones (zone_id) ON DELETE SET NULL
+);
+
+-- where a delivery goes, copied from the address book of the customer or typed in at the counter
+CREATE TABLE IF NOT EXISTS invoice_deliveries (
+    invoice_id INT NOT NULL PRIMARY KEY,
+    zone_id INT NULL,
+    address_id INT NULL,
+    line1 VARCHAR(150) NOT NULL,
+    line2 VARCHAR(150) NOT NULL DEFAULT '',
+    city VARCHAR(100) NOT NULL DEFAULT '',
+    postal_code VARCHAR(20) NOT NULL DEFAULT '',
+    instructions VARCHAR(255) NOT NULL DEFAULT '',
+    CONSTRAINT fk_invoice_deliveries_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id) ON DELETE CASCADE,
+    CONSTRAINT fk_invoice_deliveries_zone FOREIGN KEY (zone_id) REFERENCES delivery_zones (zone_id) ON DELETE SET NULL
+);
+
+-- invoices billed before order types are counted as takeaway
+ALTER TABLE invoices ADD COLUMN order_type VARCHAR(20) NOT NULL DEFAULT 'takeaway';
+ALTER TABLE invoices ADD COLUMN table_number VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE invoices ADD COLUMN charge DECIMAL(10,2) NOT NULL DEFAULT 0;
@@ -0,0 +1,37 @@
+-- places in the kitchen the pizzas are made at, a station without pizza types takes every pizza no other station makes
+CREATE TABLE IF NOT EXISTS kitchen_stations (
+    station_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    pizza_types VARCHAR(500) NOT NULL DEFAULT '',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- one ticket for every pizza line of an invoice and every pizza of a combo line, component is 0 for a pizza line
+-- the time a ticket reaches each status is kept so prep times can be reported
+CREATE TABLE IF NOT EXISTS kitchen_tickets (
+    ticket_id INT NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    invoice_id INT NOT NULL,
+    invoice_item_id INT NOT NULL,
+    component INT NOT NULL DEFAULT 0,
+    station_id INT NULL,
+    item_id VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    size VARCHAR(20) NOT NULL DEFAULT '',
+    quantity INT NOT NULL,
+    notes TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    created_at DATETIME NOT NULL,
+    started_at DATETIME NULL,
+    baking_at DATETIME NULL,
+    ready_at DATETIME NULL,
+    served_at DATETIME NULL,
+    wait_seconds INT NOT NULL DEFAULT 0,
+    prep_seconds INT NOT NULL DEFAULT 0,
+    updated_at DATETIME NOT NULL,
+    CONSTRAINT fk_kitchen_tickets_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id) ON DELETE CASCADE,
+    CONSTRAINT fk_kitchen_tickets_station FOREIGN KEY (station_id) REFERENCES kitchen_stations (station_id) ON DELETE SET NULL
+);
+CREATE INDEX idx_kitchen_tickets_invoice ON kitchen_tickets (invoice_id);
+CREATE INDEX idx_kitchen_tickets_status ON kitchen_tickets (status);
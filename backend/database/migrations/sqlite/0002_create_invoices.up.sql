@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS invoices (
+    invoice_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    invoice_date DATETIME NOT NULL,
+    subtotal DECIMAL(10,2) NOT NULL DEFAULT 0,
+    tax DECIMAL(10,2) NOT NULL DEFAULT 0,
+    total DECIMAL(10,2) NOT NULL DEFAULT 0,
+    customer_name VARCHAR(100) NOT NULL DEFAULT '',
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- topping lines point at the pizza line they belong to through parent_item_id
+CREATE TABLE IF NOT EXISTS invoice_items (
+    invoice_item_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    invoice_id INT NOT NULL,
+    parent_item_id INT NULL,
+    item_kind VARCHAR(20) NOT NULL,
+    item_id VARCHAR(50) NOT NULL,
+    item_name VARCHAR(100) NOT NULL DEFAULT '',
+    quantity INT NOT NULL,
+    unit_price DECIMAL(10,2) NOT NULL,
+    CONSTRAINT fk_invoice_items_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id) ON DELETE CASCADE,
+    CONSTRAINT fk_invoice_items_parent FOREIGN KEY (parent_item_id) REFERENCES invoice_items (invoice_item_id) ON DELETE CASCADE
+);
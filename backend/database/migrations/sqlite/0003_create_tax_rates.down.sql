@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS invoice_taxes;
+DROP TABLE IF EXISTS tax_rates;
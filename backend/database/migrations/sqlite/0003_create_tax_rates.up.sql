@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS tax_rates (
+    tax_rate_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    category VARCHAR(20) NOT NULL DEFAULT 'all',
+    rate DECIMAL(6,4) NOT NULL,
+    inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    effective_from DATE NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- snapshot of the rules applied to an invoice, tax_rate_id is NULL for the built in default rate
+CREATE TABLE IF NOT EXISTS invoice_taxes (
+    invoice_tax_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    invoice_id INT NOT NULL,
+    tax_rate_id INT NULL,
+    name VARCHAR(100) NOT NULL,
+    category VARCHAR(20) NOT NULL,
+    rate DECIMAL(6,4) NOT NULL,
+    inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    taxable_amount DECIMAL(10,2) NOT NULL,
+    tax_amount DECIMAL(10,2) NOT NULL,
+    CONSTRAINT fk_invoice_taxes_invoice FOREIGN KEY (invoice_id) REFERENCES invoices (invoice_id) ON DELETE CASCADE
+);
@@ -0,0 +1,15 @@
+CREATE TABLE pizza_sizes (
+    pizza_type_id VARCHAR(50) NOT NULL,
+    size VARCHAR(20) NOT NULL,
+    price DECIMAL(10,2) NOT NULL,
+    topping_multiplier DECIMAL(6,3) NOT NULL DEFAULT 1,
+    PRIMARY KEY (pizza_type_id, size),
+    CONSTRAINT fk_pizza_sizes_pizza_type FOREIGN KEY (pizza_type_id) REFERENCES pizza_types (pizza_type_id) ON DELETE CASCADE
+);
+
+-- pizza types created with a single known size keep selling at their base price in that size
+INSERT INTO pizza_sizes (pizza_type_id, size, price, topping_multiplier)
+SELECT pizza_type_id, LOWER(size), base_price, 1 FROM pizza_types
+WHERE LOWER(size) IN ('small', 'medium', 'large', 'custom');
+
+ALTER TABLE invoice_items ADD COLUMN size VARCHAR(20) NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS uq_pizza_toppings_pizza_topping;
+ALTER TABLE pizza_toppings DROP COLUMN is_default;
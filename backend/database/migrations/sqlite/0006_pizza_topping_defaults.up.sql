@@ -0,0 +1,8 @@
+-- a pizza type lists each topping once, keep the oldest of any duplicated links
+DELETE FROM pizza_toppings WHERE pizza_topping_id NOT IN (
+    SELECT MIN(pizza_topping_id) FROM pizza_toppings GROUP BY pizza_type_id, topping_id
+);
+
+-- existing links are the toppings a pizza is made with, so they stay included in its price
+ALTER TABLE pizza_toppings ADD COLUMN is_default BOOLEAN NOT NULL DEFAULT 1;
+CREATE UNIQUE INDEX uq_pizza_toppings_pizza_topping ON pizza_toppings (pizza_type_id, topping_id);
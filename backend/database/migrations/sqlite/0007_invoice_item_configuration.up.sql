@@ -0,0 +1,2 @@
+-- configured pizzas keep the toppings they were built with as JSON on their line
+ALTER TABLE invoice_items ADD COLUMN configuration TEXT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE invoice_items DROP COLUMN components;
+DROP TABLE IF EXISTS combo_slot_items;
+DROP TABLE IF EXISTS combo_slots;
+DROP TABLE IF EXISTS combos;
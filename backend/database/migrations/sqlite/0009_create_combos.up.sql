@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS combos (
+    combo_id VARCHAR(50) NOT NULL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    price DECIMAL(10,2) NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- the parts of a combo, each slot is filled with quantity items of its kind
+CREATE TABLE IF NOT EXISTS combo_slots (
+    combo_slot_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    combo_id VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    item_kind VARCHAR(20) NOT NULL,
+    size VARCHAR(20) NOT NULL DEFAULT '',
+    quantity INT NOT NULL DEFAULT 1,
+    CONSTRAINT fk_combo_slots_combo FOREIGN KEY (combo_id) REFERENCES combos (combo_id) ON DELETE CASCADE
+);
+
+-- the pizza types or beverages a slot can be filled with, a slot without rows takes any item of its kind
+CREATE TABLE IF NOT EXISTS combo_slot_items (
+    combo_slot_id INT NOT NULL,
+    item_id VARCHAR(50) NOT NULL,
+    PRIMARY KEY (combo_slot_id, item_id),
+    CONSTRAINT fk_combo_slot_items_slot FOREIGN KEY (combo_slot_id) REFERENCES combo_slots (combo_slot_id) ON DELETE CASCADE
+);
+
+-- combo lines keep the items chosen for their slots as JSON on their line
+ALTER TABLE invoice_items ADD COLUMN components TEXT NULL;
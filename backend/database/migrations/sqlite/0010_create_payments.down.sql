@@ -0,0 +1,3 @@
+ALTER TABLE invoices DROP COLUMN amount_paid;
+ALTER TABLE invoices DROP COLUMN status;
+DROP TABLE IF EXISTS payments;
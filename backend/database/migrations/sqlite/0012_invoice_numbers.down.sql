@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS uq_invoices_number;
+ALTER TABLE invoices DROP COLUMN invoice_number;
+DROP TABLE IF EXISTS number_sequences;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_invoices_customer;
+ALTER TABLE invoices DROP COLUMN customer_id;
+DROP TABLE IF EXISTS customer_addresses;
+DROP TABLE IF EXISTS customers;
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS customers (
+    customer_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(100) NOT NULL,
+    phone VARCHAR(30) NULL,
+    email VARCHAR(100) NOT NULL DEFAULT '',
+    notes VARCHAR(500) NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT uq_customers_phone UNIQUE (phone)
+);
+
+CREATE TABLE IF NOT EXISTS customer_addresses (
+    address_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    customer_id INT NOT NULL,
+    label VARCHAR(30) NOT NULL DEFAULT '',
+    line1 VARCHAR(150) NOT NULL,
+    line2 VARCHAR(150) NOT NULL DEFAULT '',
+    city VARCHAR(100) NOT NULL DEFAULT '',
+    postal_code VARCHAR(20) NOT NULL DEFAULT '',
+    instructions VARCHAR(255) NOT NULL DEFAULT '',
+    CONSTRAINT fk_customer_addresses_customer FOREIGN KEY (customer_id) REFERENCES customers (customer_id) ON DELETE CASCADE
+);
+
+-- invoices keep the customer name they were billed to, walk-in invoices have no customer
+ALTER TABLE invoices ADD COLUMN customer_id INT NULL;
+CREATE INDEX idx_invoices_customer ON invoices (customer_id);
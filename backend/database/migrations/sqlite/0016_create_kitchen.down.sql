@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS kitchen_tickets;
+DROP TABLE IF EXISTS kitchen_stations;
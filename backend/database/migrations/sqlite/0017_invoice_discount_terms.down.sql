@@ -0,0 +1 @@
+ALTER TABLE invoice_discounts DROP COLUMN terms;
@@ -0,0 +1,2 @@
+-- the terms of a promotion as they were when it was applied, so an invoice keeps its discount when the promotion is edited or deleted
+ALTER TABLE invoice_discounts ADD COLUMN terms TEXT NULL;
@@ -0,0 +1 @@
+ALTER TABLE credit_notes DROP COLUMN charge;
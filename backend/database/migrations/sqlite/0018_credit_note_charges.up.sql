@@ -0,0 +1,2 @@
+-- delivery, packaging and service charges given back by a credit note, only the credit note that clears the invoice gives them back
+ALTER TABLE credit_notes ADD COLUMN charge DECIMAL(10,2) NOT NULL DEFAULT 0;
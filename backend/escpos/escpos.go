@@ -0,0 +1,141 @@
+package escpos
+
+import (
+	"bytes"
+	"io"
+)
+
+//control bytes used by the ESC/POS command set
+const (
+	esc = 0x1B
+	gs  = 0x1D
+	lf  = 0x0A
+)
+
+//text alignments accepted by Align
+const (
+	AlignLeft   = 0
+	AlignCenter = 1
+	AlignRight  = 2
+)
+
+//character code tables accepted by CodePage
+const (
+	CodePagePC437 = 0
+	CodePagePC850 = 2
+)
+
+//Encoder builds an ESC/POS byte stream in memory, the output only depends on the
+//commands issued so it can be compared byte for byte against golden files
+type Encoder struct {
+	buf bytes.Buffer
+}
+
+//function to create an encoder that starts by resetting the printer
+//a reset keeps the code table stored in the printer settings so PC437 is selected explicitly
+func NewEncoder() *Encoder {
+	e := &Encoder{}
+	e.buf.Write([]byte{esc, '@'})
+	return e.CodePage(CodePagePC437)
+}
+
+//method to select the character code table used for the following text
+func (e *Encoder) CodePage(page byte) *Encoder {
+	e.buf.Write([]byte{esc, 't', page})
+	return e
+}
+
+//method to set the alignment of the following lines
+func (e *Encoder) Align(alignment byte) *Encoder {
+	e.buf.Write([]byte{esc, 'a', alignment})
+	return e
+}
+
+//method to switch emphasised (bold) printing on or off
+func (e *Encoder) Bold(on bool) *Encoder {
+	e.buf.Write([]byte{esc, 'E', flag(on)})
+	return e
+}
+
+//method to switch double width and double height characters on or off
+func (e *Encoder) DoubleSize(on bool) *Encoder {
+	size := byte(0x00)
+	if on {
+		size = 0x11
+	}
+	e.buf.Write([]byte{gs, '!', size})
+	return e
+}
+
+//method to print a line of text, characters outside printable ASCII are replaced by '?'
+func (e *Encoder) Line(text string) *Encoder {
+	for _, r := range text {
+		if r < 0x20 || r > 0x7E {
+			r = '?'
+		}
+		e.buf.WriteByte(byte(r))
+	}
+	e.buf.WriteByte(lf)
+	return e
+}
+
+//method to feed the paper by n lines
+func (e *Encoder) Feed(lines byte) *Encoder {
+	e.buf.Write([]byte{esc, 'd', lines})
+	return e
+}
+
+//method to feed past the tear bar and partially cut the paper
+func (e *Encoder) Cut() *Encoder {
+	e.buf.Write([]byte{gs, 'V', 66, 0})
+	return e
+}
+
+//method to send a pulse on pin 2 which opens the cash drawer
+func (e *Encoder) KickDrawer() *Encoder {
+	e.buf.Write([]byte{esc, 'p', 0, 25, 250})
+	return e
+}
+
+//method to print a QR code (model 2, error correction M) holding data
+//moduleSize is the size of one dot of the code, from 1 to 16
+func (e *Encoder) QRCode(data string, moduleSize byte) *Encoder {
+	if moduleSize < 1 {
+		moduleSize = 1
+	}
+	if moduleSize > 16 {
+		moduleSize = 16
+	}
+
+	//select model 2
+	e.buf.Write([]byte{gs, '(', 'k', 4, 0, 49, 65, 50, 0})
+	//set the module size
+	e.buf.Write([]byte{gs, '(', 'k', 3, 0, 49, 67, moduleSize})
+	//set error correction level M
+	e.buf.Write([]byte{gs, '(', 'k', 3, 0, 49, 69, 49})
+	//store the data in the symbol storage area
+	length := len(data) + 3
+	e.buf.Write([]byte{gs, '(', 'k', byte(length % 256), byte(length / 256), 49, 80, 48})
+	e.buf.WriteString(data)
+	//print the stored symbol
+	e.buf.Write([]byte{gs, '(', 'k', 3, 0, 49, 81, 48})
+	return e
+}
+
+//method to return the encoded bytes
+func (e *Encoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+//method to write the encoded bytes to w
+func (e *Encoder) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(e.buf.Bytes())
+	return int64(n), err
+}
+
+func flag(on bool) byte {
+	if on {
+		return 1
+	}
+	return 0
+}
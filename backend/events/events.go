@@ -0,0 +1,159 @@
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+//topics clients can subscribe to
+const (
+	TopicInvoices = "invoices"
+	TopicItems    = "items"
+	TopicPayments = "payments"
+	TopicKitchen  = "kitchen"
+)
+
+//Topics lists every topic, a client subscribing to none gets them all
+var Topics = []string{TopicInvoices, TopicItems, TopicPayments, TopicKitchen}
+
+//number of recent events kept so a client that reconnects can catch up
+const DefaultHistory = 500
+
+//number of events a subscriber can fall behind before it is dropped and has to reconnect
+const subscriberBuffer = 64
+
+//Event is a change published to the subscribers of its topic
+type Event struct {
+	//ids increase by one for every event so a client can resume after the last one it saw
+	Id        int64       `json:"id"`
+	Topic     string      `json:"topic"`
+	//what happened, such as invoice.created or ticket.updated
+	Type      string      `json:"type"`
+	Data      interface{} `json:"data"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+//Broker hands the events published by the server to the clients subscribed to their topic
+//events only live in the memory of one server process, nothing is read back from the database
+type Broker struct {
+	mu          sync.Mutex
+	nextId      int64
+	history     []Event
+	size        int
+	subscribers map[*Subscription]bool
+}
+
+//Subscription receives the events of its topics until it is closed, Events is closed when the
+//subscriber fell too far behind and was dropped
+type Subscription struct {
+	Events chan Event
+	topics map[string]bool
+}
+
+//function to create a broker keeping the last history events for clients that reconnect
+func NewBroker(history int) *Broker {
+	return &Broker{size: history, subscribers: map[*Subscription]bool{}}
+}
+
+//function to check whether a name is a known topic
+func ValidTopic(topic string) bool {
+	for _, t := range Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+//function to read a comma separated list of topics, an empty list means every topic
+//the first unknown topic is returned with ok false
+func ParseTopics(value string) ([]string, string, bool) {
+	var topics []string
+	for _, topic := range strings.Split(value, ",") {
+		if topic = strings.TrimSpace(topic); topic == "" {
+			continue
+		}
+		if !ValidTopic(topic) {
+			return nil, topic, false
+		}
+		topics = append(topics, topic)
+	}
+	if len(topics) == 0 {
+		topics = Topics
+	}
+	return topics, "", true
+}
+
+//method to publish an event to every subscriber of its topic, it never waits on a slow subscriber
+func (b *Broker) Publish(topic string, kind string, data interface{}) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextId++
+	event := Event{Id: b.nextId, Topic: topic, Type: kind, Data: data, CreatedAt: time.Now()}
+	if b.size > 0 {
+		if len(b.history) == b.size {
+			b.history = b.history[1:]
+		}
+		b.history = append(b.history, event)
+	}
+
+	for subscription := range b.subscribers {
+		if !subscription.topics[topic] {
+			continue
+		}
+		select {
+		case subscription.Events <- event:
+		default:
+			//the client catches up from the history when it reconnects
+			b.drop(subscription)
+		}
+	}
+	return event
+}
+
+//method to subscribe to topics, it returns the events published after lastEventId that are still
+//in the history, complete is false when some of them are no longer kept and the client has to reload
+func (b *Broker) Subscribe(topics []string, lastEventId int64) (subscription *Subscription, backlog []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subscription = &Subscription{Events: make(chan Event, subscriberBuffer), topics: map[string]bool{}}
+	for _, topic := range topics {
+		subscription.topics[topic] = true
+	}
+	b.subscribers[subscription] = true
+
+	complete = true
+	if lastEventId <= 0 {
+		return subscription, nil, complete
+	}
+	//an id the broker never handed out comes from before the server restarted
+	if lastEventId > b.nextId || (lastEventId < b.nextId && (len(b.history) == 0 || b.history[0].Id > lastEventId+1)) {
+		complete = false
+	}
+	for _, event := range b.history {
+		if event.Id > lastEventId && subscription.topics[event.Topic] {
+			backlog = append(backlog, event)
+		}
+	}
+	return subscription, backlog, complete
+}
+
+//method to stop a subscription once its client is gone
+func (b *Broker) Unsubscribe(subscription *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.drop(subscription)
+}
+
+//function to remove a subscriber and close its channel, the caller holds the lock
+func (b *Broker) drop(subscription *Subscription) {
+	if !b.subscribers[subscription] {
+		return
+	}
+	delete(b.subscribers, subscription)
+	close(subscription.Events)
+}
@@ -0,0 +1,152 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+)
+
+//function to read the events waiting on a subscription without blocking
+func pending(subscription *Subscription) (events []Event, open bool) {
+	for {
+		select {
+		case event, ok := <-subscription.Events:
+			if !ok {
+				return events, false
+			}
+			events = append(events, event)
+		default:
+			return events, true
+		}
+	}
+}
+
+func TestPublishOnlyReachesSubscribedTopics(t *testing.T) {
+	broker := NewBroker(DefaultHistory)
+	kitchen, _, _ := broker.Subscribe([]string{TopicKitchen}, 0)
+	all, _, _ := broker.Subscribe(Topics, 0)
+
+	broker.Publish(TopicInvoices, "invoice.created", nil)
+	broker.Publish(TopicKitchen, "ticket.updated", nil)
+	broker.Publish(TopicPayments, "payment.received", nil)
+
+	got, _ := pending(kitchen)
+	if len(got) != 1 || got[0].Type != "ticket.updated" {
+		t.Errorf("kitchen subscriber got %+v, want only ticket.updated", got)
+	}
+	got, _ = pending(all)
+	if len(got) != 3 {
+		t.Errorf("subscriber to every topic got %d events, want 3", len(got))
+	}
+	for i, event := range got {
+		if event.Id != int64(i+1) {
+			t.Errorf("event %d has id %d, want %d", i, event.Id, i+1)
+		}
+	}
+}
+
+func TestUnsubscribeClosesEvents(t *testing.T) {
+	broker := NewBroker(DefaultHistory)
+	subscription, _, _ := broker.Subscribe(Topics, 0)
+
+	broker.Unsubscribe(subscription)
+	//a second unsubscribe, as when a dropped client goes away, must not close the channel again
+	broker.Unsubscribe(subscription)
+	broker.Publish(TopicInvoices, "invoice.created", nil)
+
+	if got, open := pending(subscription); open || len(got) != 0 {
+		t.Errorf("unsubscribed subscriber got %d events and open %v, want none and closed", len(got), open)
+	}
+	if len(broker.subscribers) != 0 {
+		t.Errorf("broker still holds %d subscribers", len(broker.subscribers))
+	}
+}
+
+func TestSlowSubscriberIsDropped(t *testing.T) {
+	broker := NewBroker(DefaultHistory)
+	slow, _, _ := broker.Subscribe(Topics, 0)
+	other, _, _ := broker.Subscribe([]string{TopicKitchen}, 0)
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		broker.Publish(TopicInvoices, "invoice.updated", i)
+	}
+
+	got, open := pending(slow)
+	if open {
+		t.Fatal("a subscriber that fell behind was not dropped")
+	}
+	if len(got) != subscriberBuffer {
+		t.Errorf("dropped subscriber kept %d events, want the %d that fit its buffer", len(got), subscriberBuffer)
+	}
+	//publishing never waits on a subscriber, the others keep receiving
+	broker.Publish(TopicKitchen, "ticket.updated", nil)
+	if got, open := pending(other); !open || len(got) != 1 {
+		t.Errorf("other subscriber got %d events and open %v, want 1 and open", len(got), open)
+	}
+}
+
+func TestSubscribeReplaysMissedEvents(t *testing.T) {
+	tests := []struct {
+		name        string
+		history     int
+		published   int
+		topics      []string
+		lastEventId int64
+		want        []int64
+		complete    bool
+	}{
+		{name: "new client", history: 5, published: 3, topics: Topics, lastEventId: 0, want: nil, complete: true},
+		{name: "caught up", history: 5, published: 3, topics: Topics, lastEventId: 3, want: nil, complete: true},
+		{name: "missed two", history: 5, published: 3, topics: Topics, lastEventId: 1, want: []int64{2, 3}, complete: true},
+		{name: "only its topics", history: 5, published: 4, topics: []string{TopicKitchen}, lastEventId: 1, want: []int64{3}, complete: true},
+		{name: "history rolled over", history: 2, published: 5, topics: Topics, lastEventId: 1, want: []int64{4, 5}, complete: false},
+		{name: "oldest kept is next", history: 2, published: 5, topics: Topics, lastEventId: 3, want: []int64{4, 5}, complete: true},
+		{name: "id from before a restart", history: 5, published: 2, topics: Topics, lastEventId: 9, want: nil, complete: false},
+		{name: "no history", history: 0, published: 2, topics: Topics, lastEventId: 1, want: nil, complete: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			broker := NewBroker(tt.history)
+			for i := 1; i <= tt.published; i++ {
+				//every other event goes to the kitchen
+				topic := TopicInvoices
+				if i%2 == 1 {
+					topic = TopicKitchen
+				}
+				broker.Publish(topic, "changed", i)
+			}
+
+			_, backlog, complete := broker.Subscribe(tt.topics, tt.lastEventId)
+			var ids []int64
+			for _, event := range backlog {
+				ids = append(ids, event.Id)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("backlog ids = %v, want %v", ids, tt.want)
+			}
+			if complete != tt.complete {
+				t.Errorf("complete = %v, want %v", complete, tt.complete)
+			}
+		})
+	}
+}
+
+func TestParseTopics(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		unknown string
+		ok      bool
+	}{
+		{value: "", want: Topics, ok: true},
+		{value: " , ", want: Topics, ok: true},
+		{value: "kitchen", want: []string{TopicKitchen}, ok: true},
+		{value: "invoices, payments", want: []string{TopicInvoices, TopicPayments}, ok: true},
+		{value: "invoices,orders", unknown: "orders", ok: false},
+	}
+	for _, tt := range tests {
+		got, unknown, ok := ParseTopics(tt.value)
+		if !reflect.DeepEqual(got, tt.want) || unknown != tt.unknown || ok != tt.ok {
+			t.Errorf("ParseTopics(%q) = %v, %q, %v, want %v, %q, %v", tt.value, got, unknown, ok, tt.want, tt.unknown, tt.ok)
+		}
+	}
+}
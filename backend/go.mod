@@ -4,9 +4,13 @@ go 1.23.4
 
 require (
 	github.com/go-sql-driver/mysql v1.8.1
+	github.com/golang-jwt/jwt/v5 v5.2.2
 	github.com/gorilla/mux v1.8.1
 	github.com/joho/godotenv v1.5.1
+	github.com/jung-kurt/gofpdf v1.16.2
+	github.com/mattn/go-sqlite3 v1.14.33
 	github.com/rs/cors v1.11.1
+	golang.org/x/crypto v0.31.0
 )
 
 require filippo.io/edwards25519 v1.1.0 // indirect
@@ -0,0 +1,422 @@
+package kitchen
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+)
+
+//statuses a ticket moves through, in order
+const (
+	StatusQueued    = "queued"
+	StatusPreparing = "preparing"
+	StatusBaking    = "baking"
+	StatusReady     = "ready"
+	StatusServed    = "served"
+)
+
+//status of a ticket whose pizza was taken off the invoice or whose invoice was voided
+const StatusCancelled = "cancelled"
+
+//Flow is the order tickets are bumped through
+var Flow = []string{StatusQueued, StatusPreparing, StatusBaking, StatusReady, StatusServed}
+
+//KitchenError is returned when a ticket cannot be moved to a status, as opposed to a database failure
+type KitchenError string
+
+func (e KitchenError) Error() string { return string(e) }
+
+//function to check whether a status is part of the flow of a ticket
+func ValidStatus(status string) bool {
+	return stage(status) >= 0
+}
+
+//function to check whether a ticket is still on the kitchen screens, tickets leave them once served
+func Active(status string) bool {
+	return status != StatusServed && ValidStatus(status)
+}
+
+//function to find the position of a status in the flow, -1 when it is not part of it
+func stage(status string) int {
+	for i, s := range Flow {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+//function to build the tickets the items of an invoice need, one for every pizza line
+//and one for every pizza of a combo line, items are lines with their modifiers nested under them
+func Tickets(invoiceId int, items []models.InvoiceItem) []models.KitchenTicket {
+	var tickets []models.KitchenTicket
+	for _, item := range items {
+		if item.ItemKind == pricing.KindCombo {
+			for i, component := range item.Components {
+				if component.ItemKind != pricing.KindPizza {
+					continue
+				}
+				tickets = append(tickets, models.KitchenTicket{
+					InvoiceId:     invoiceId,
+					InvoiceItemId: item.InvoiceItemId,
+					Component:     i + 1,
+					ItemId:        component.ItemId,
+					Name:          component.Name,
+					Size:          component.Size,
+					Quantity:      item.Quantity * component.Quantity,
+					Notes:         []string{"from " + item.Name},
+				})
+			}
+			continue
+		}
+		if item.ItemKind != pricing.KindPizza {
+			continue
+		}
+		tickets = append(tickets, models.KitchenTicket{
+			InvoiceId:     invoiceId,
+			InvoiceItemId: item.InvoiceItemId,
+			ItemId:        item.ItemId,
+			Name:          item.Name,
+			Size:          item.Size,
+			Quantity:      item.Quantity,
+			Notes:         notes(item),
+		})
+	}
+	return tickets
+}
+
+//function to describe the toppings added to or left off a pizza line
+func notes(item models.InvoiceItem) []string {
+	var notes []string
+	for _, modifier := range item.Modifiers {
+		if modifier.Quantity > 1 {
+			notes = append(notes, fmt.Sprintf("+ %d x %s", modifier.Quantity, modifier.Name))
+			continue
+		}
+		notes = append(notes, "+ "+modifier.Name)
+	}
+	if config := item.Configuration; config != nil {
+		for _, choice := range config.Removed {
+			notes = append(notes, "- NO "+choiceName(choice))
+		}
+		for _, choice := range config.Added {
+			notes = append(notes, "+ "+choiceName(choice))
+		}
+	}
+	return notes
+}
+
+//function to name a topping of a configured pizza, toppings on one half say which
+func choiceName(choice models.ToppingChoice) string {
+	if choice.Placement == "" || choice.Placement == billing.PlacementWhole {
+		return choice.Name
+	}
+	return choice.Name + " (" + choice.Placement + " half)"
+}
+
+//function to pick the station a pizza is made at, a station listing the pizza type comes before
+//a station taking every pizza, 0 when no active station makes it
+func Route(stations []models.KitchenStation, pizzaTypeId string) int {
+	fallback := 0
+	for _, station := range stations {
+		if !station.Active {
+			continue
+		}
+		if strings.TrimSpace(station.PizzaTypes) == "" {
+			if fallback == 0 {
+				fallback = station.StationId
+			}
+			continue
+		}
+		for _, id := range strings.Split(station.PizzaTypes, ",") {
+			if strings.TrimSpace(id) == pizzaTypeId {
+				return station.StationId
+			}
+		}
+	}
+	return fallback
+}
+
+//function to match the tickets an invoice has with the tickets its items need now
+//a queued ticket follows its line, pizzas the kitchen has started are kept and only the extra quantity
+//of a line is queued, a line taken off the invoice cancels its tickets that were not served yet
+//added holds the tickets to create and changed the existing tickets to store again
+func Reconcile(existing []models.KitchenTicket, wanted []models.KitchenTicket, stations []models.KitchenStation, now time.Time) (added []models.KitchenTicket, changed []models.KitchenTicket) {
+	key := func(t models.KitchenTicket) string {
+		return strconv.Itoa(t.InvoiceItemId) + "|" + strconv.Itoa(t.Component)
+	}
+	needed := map[string]models.KitchenTicket{}
+	for _, ticket := range wanted {
+		needed[key(ticket)] = ticket
+	}
+
+	//quantity the kitchen has started on for every line, and the ticket still waiting for it
+	started := map[string]int{}
+	queued := map[string]int{}
+	for i, ticket := range existing {
+		switch ticket.Status {
+		case StatusCancelled:
+		case StatusQueued:
+			queued[key(ticket)] = i
+		default:
+			started[key(ticket)] += ticket.Quantity
+		}
+	}
+
+	for i, ticket := range existing {
+		want, ok := needed[key(ticket)]
+		switch {
+		case ticket.Status == StatusCancelled || ticket.Status == StatusServed:
+			continue
+		case !ok:
+			//nobody pays for the pizza any more
+		case ticket.Status != StatusQueued || queued[key(ticket)] != i:
+			continue
+		case want.Quantity > started[key(ticket)]:
+			if ticket.Quantity == want.Quantity-started[key(ticket)] && ticket.ItemId == want.ItemId && ticket.Name == want.Name && ticket.Size == want.Size && strings.Join(ticket.Notes, "\n") == strings.Join(want.Notes, "\n") {
+				continue
+			}
+			ticket.Quantity = want.Quantity - started[key(ticket)]
+			ticket.ItemId, ticket.Name, ticket.Size, ticket.Notes = want.ItemId, want.Name, want.Size, want.Notes
+			ticket.UpdatedAt = now
+			changed = append(changed, ticket)
+			continue
+		}
+		ticket.Status = StatusCancelled
+		ticket.UpdatedAt = now
+		changed = append(changed, ticket)
+	}
+
+	for _, want := range wanted {
+		if _, ok := queued[key(want)]; ok {
+			continue
+		}
+		if want.Quantity <= started[key(want)] {
+			continue
+		}
+		want.Quantity -= started[key(want)]
+		want.StationId = Route(stations, want.ItemId)
+		want.Status = StatusQueued
+		want.CreatedAt = now
+		want.UpdatedAt = now
+		added = append(added, want)
+	}
+	return added, changed
+}
+
+//function to move a ticket to a status, an empty status bumps it to the next one
+//a ticket can be moved back when it was bumped by mistake, the times of the statuses it goes back
+//over are cleared and a status that was skipped gets the same time as the status moved to
+func Advance(ticket models.KitchenTicket, status string, now time.Time) (models.KitchenTicket, error) {
+	if ticket.Status == StatusCancelled {
+		return ticket, KitchenError("Ticket " + strconv.Itoa(ticket.TicketId) + " was cancelled")
+	}
+	if status == "" {
+		next := stage(ticket.Status) + 1
+		if next >= len(Flow) {
+			return ticket, KitchenError("Ticket " + strconv.Itoa(ticket.TicketId) + " has already been served")
+		}
+		status = Flow[next]
+	}
+	to := stage(status)
+	if to < 0 {
+		return ticket, KitchenError("status must be one of queued, preparing, baking, ready or served")
+	}
+
+	times := []**time.Time{&ticket.StartedAt, &ticket.BakingAt, &ticket.ReadyAt, &ticket.ServedAt}
+	for i, t := range times {
+		switch {
+		case i+1 > to:
+			*t = nil
+		case *t == nil:
+			at := now
+			*t = &at
+		}
+	}
+	ticket.WaitSeconds, ticket.PrepSeconds = 0, 0
+	if ticket.StartedAt != nil {
+		ticket.WaitSeconds = seconds(ticket.CreatedAt, *ticket.StartedAt)
+	}
+	if ticket.ReadyAt != nil {
+		ticket.PrepSeconds = seconds(ticket.CreatedAt, *ticket.ReadyAt)
+	}
+	ticket.Status = status
+	ticket.UpdatedAt = now
+	return ticket, nil
+}
+
+//function to count the whole seconds between two times, never negative
+func seconds(from time.Time, to time.Time) int {
+	if to.Before(from) {
+		return 0
+	}
+	return int(to.Sub(from).Seconds())
+}
+
+//columns of the kitchen_stations table in the order they are scanned
+const stationColumns = "station_id, name, pizza_types, active, created_at, updated_at"
+
+//function to load every kitchen station
+func LoadStations(db database.DBTX) ([]models.KitchenStation, error) {
+	return loadStations(db, "ORDER BY station_id")
+}
+
+//function to load one kitchen station, sql.ErrNoRows is returned when it does not exist
+func FindStation(db database.DBTX, stationId string) (models.KitchenStation, error) {
+	found, err := loadStations(db, "WHERE station_id = ?", stationId)
+	if err != nil {
+		return models.KitchenStation{}, err
+	}
+	if len(found) == 0 {
+		return models.KitchenStation{}, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+func loadStations(db database.DBTX, clause string, args ...interface{}) ([]models.KitchenStation, error) {
+	results, err := db.Query("SELECT "+stationColumns+" FROM kitchen_stations "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	stations := []models.KitchenStation{}
+	for results.Next() {
+		var s models.KitchenStation
+		if err := results.Scan(&s.StationId, &s.Name, &s.PizzaTypes, &s.Active, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		stations = append(stations, s)
+	}
+	return stations, results.Err()
+}
+
+//columns of a ticket joined with its invoice in the order they are scanned
+const ticketColumns = `t.ticket_id, t.invoice_id, COALESCE(i.invoice_number, ''), t.invoice_item_id, t.component, COALESCE(t.station_id, 0),
+	t.item_id, t.name, t.size, t.quantity, t.notes, i.order_type, i.table_number, t.status,
+	t.created_at, t.started_at, t.baking_at, t.ready_at, t.served_at, t.wait_seconds, t.prep_seconds, t.updated_at`
+
+//function to load the tickets on the kitchen screens oldest first, only those of one station when stationId is given
+func Queue(db database.DBTX, stationId string) ([]models.KitchenTicket, error) {
+	clause := "WHERE t.status IN (?,?,?,?)"
+	args := []interface{}{StatusQueued, StatusPreparing, StatusBaking, StatusReady}
+	if stationId != "" {
+		clause += " AND t.station_id = ?"
+		args = append(args, stationId)
+	}
+	return loadTickets(db, clause+" ORDER BY t.created_at, t.ticket_id", args...)
+}
+
+//function to load every ticket of an invoice, cancelled tickets included
+func InvoiceTickets(db database.DBTX, invoiceId string) ([]models.KitchenTicket, error) {
+	return loadTickets(db, "WHERE t.invoice_id = ? ORDER BY t.ticket_id", invoiceId)
+}
+
+//function to load one ticket, sql.ErrNoRows is returned when it does not exist
+func FindTicket(db database.DBTX, ticketId string) (models.KitchenTicket, error) {
+	found, err := loadTickets(db, "WHERE t.ticket_id = ?", ticketId)
+	if err != nil {
+		return models.KitchenTicket{}, err
+	}
+	if len(found) == 0 {
+		return models.KitchenTicket{}, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+func loadTickets(db database.DBTX, clause string, args ...interface{}) ([]models.KitchenTicket, error) {
+	results, err := db.Query("SELECT "+ticketColumns+" FROM kitchen_tickets t INNER JOIN invoices i ON i.invoice_id = t.invoice_id "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	tickets := []models.KitchenTicket{}
+	for results.Next() {
+		var t models.KitchenTicket
+		var notes string
+		var started, baking, ready, served sql.NullTime
+		if err := results.Scan(&t.TicketId, &t.InvoiceId, &t.InvoiceNumber, &t.InvoiceItemId, &t.Component, &t.StationId,
+			&t.ItemId, &t.Name, &t.Size, &t.Quantity, &notes, &t.OrderType, &t.TableNumber, &t.Status,
+			&t.CreatedAt, &started, &baking, &ready, &served, &t.WaitSeconds, &t.PrepSeconds, &t.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if notes != "" {
+			t.Notes = strings.Split(notes, "\n")
+		}
+		t.StartedAt, t.BakingAt, t.ReadyAt, t.ServedAt = timeOf(started), timeOf(baking), timeOf(ready), timeOf(served)
+		tickets = append(tickets, t)
+	}
+	return tickets, results.Err()
+}
+
+//function to bring the tickets of an invoice in line with its items, items are lines with their
+//modifiers nested under them and no items cancel every ticket the kitchen has not served
+func Sync(db database.DBTX, invoiceId string, items []models.InvoiceItem, now time.Time) error {
+	existing, err := InvoiceTickets(db, invoiceId)
+	if err != nil {
+		return err
+	}
+	stations, err := LoadStations(db)
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(invoiceId)
+	if err != nil {
+		return err
+	}
+
+	added, changed := Reconcile(existing, Tickets(id, items), stations, now)
+	for _, ticket := range added {
+		query := `INSERT INTO kitchen_tickets (invoice_id, invoice_item_id, component, station_id, item_id, name, size, quantity, notes, status, created_at, updated_at)
+			VALUES (?,?,?,?,?,?,?,?,?,?,?,?)`
+		if _, err := db.Exec(query, ticket.InvoiceId, ticket.InvoiceItemId, ticket.Component, nullable(ticket.StationId), ticket.ItemId, ticket.Name, ticket.Size, ticket.Quantity, strings.Join(ticket.Notes, "\n"), ticket.Status, ticket.CreatedAt, ticket.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	for _, ticket := range changed {
+		query := "UPDATE kitchen_tickets SET item_id=?, name=?, size=?, quantity=?, notes=?, status=?, updated_at=? WHERE ticket_id=?"
+		if _, err := db.Exec(query, ticket.ItemId, ticket.Name, ticket.Size, ticket.Quantity, strings.Join(ticket.Notes, "\n"), ticket.Status, ticket.UpdatedAt, ticket.TicketId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//function to store the status of a ticket with the times it reached each status
+func SaveStatus(db database.DBTX, ticket models.KitchenTicket) error {
+	query := "UPDATE kitchen_tickets SET status=?, started_at=?, baking_at=?, ready_at=?, served_at=?, wait_seconds=?, prep_seconds=?, updated_at=? WHERE ticket_id=?"
+	_, err := db.Exec(query, ticket.Status, nullableTime(ticket.StartedAt), nullableTime(ticket.BakingAt), nullableTime(ticket.ReadyAt), nullableTime(ticket.ServedAt), ticket.WaitSeconds, ticket.PrepSeconds, ticket.UpdatedAt, ticket.TicketId)
+	return err
+}
+
+//function to turn a nullable time column into a pointer, nil for NULL
+func timeOf(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+//function to store a missing time as NULL
+func nullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+//function to store a missing station as NULL
+func nullable(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
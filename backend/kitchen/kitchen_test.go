@@ -0,0 +1,258 @@
+package kitchen
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+)
+
+var created = time.Date(2026, 3, 13, 18, 0, 0, 0, time.UTC)
+
+//function to return the time a number of minutes after the ticket was created
+func after(minutes int) *time.Time {
+	at := created.Add(time.Duration(minutes) * time.Minute)
+	return &at
+}
+
+func TestAdvance(t *testing.T) {
+	now := *after(5)
+	ticket := func(status string, times ...*time.Time) models.KitchenTicket {
+		ticket := models.KitchenTicket{TicketId: 9, Status: status, CreatedAt: created}
+		fields := []**time.Time{&ticket.StartedAt, &ticket.BakingAt, &ticket.ReadyAt, &ticket.ServedAt}
+		for i, at := range times {
+			*fields[i] = at
+		}
+		return ticket
+	}
+
+	tests := []struct {
+		name   string
+		ticket models.KitchenTicket
+		status string
+		want   string
+		//the started, baking, ready and served times as minutes after creation, -1 when not set
+		times      [4]int
+		wait, prep int
+		wantErr    bool
+	}{
+		{name: "bump a queued ticket", ticket: ticket(StatusQueued), want: StatusPreparing, times: [4]int{5, -1, -1, -1}, wait: 300},
+		{name: "bump a preparing ticket", ticket: ticket(StatusPreparing, after(1)), want: StatusBaking, times: [4]int{1, 5, -1, -1}, wait: 60},
+		{name: "bump a baking ticket", ticket: ticket(StatusBaking, after(1), after(2)), want: StatusReady, times: [4]int{1, 2, 5, -1}, wait: 60, prep: 300},
+		{name: "bump a ready ticket", ticket: ticket(StatusReady, after(1), after(2), after(4)), want: StatusServed, times: [4]int{1, 2, 4, 5}, wait: 60, prep: 240},
+		{name: "skip to ready", ticket: ticket(StatusQueued), status: StatusReady, want: StatusReady, times: [4]int{5, 5, 5, -1}, wait: 300, prep: 300},
+		{name: "skip baking", ticket: ticket(StatusPreparing, after(1)), status: StatusReady, want: StatusReady, times: [4]int{1, 5, 5, -1}, wait: 60, prep: 300},
+		{name: "move back a step", ticket: ticket(StatusReady, after(1), after(2), after(4)), status: StatusBaking, want: StatusBaking, times: [4]int{1, 2, -1, -1}, wait: 60},
+		{name: "move back to the queue", ticket: ticket(StatusBaking, after(1), after(2)), status: StatusQueued, want: StatusQueued, times: [4]int{-1, -1, -1, -1}},
+		{name: "set the status it has", ticket: ticket(StatusPreparing, after(1)), status: StatusPreparing, want: StatusPreparing, times: [4]int{1, -1, -1, -1}, wait: 60},
+		{name: "bump a served ticket", ticket: ticket(StatusServed, after(1), after(2), after(3), after(4)), wantErr: true},
+		{name: "bump a cancelled ticket", ticket: ticket(StatusCancelled), wantErr: true},
+		{name: "move a cancelled ticket", ticket: ticket(StatusCancelled), status: StatusQueued, wantErr: true},
+		{name: "unknown status", ticket: ticket(StatusQueued), status: "burnt", wantErr: true},
+		{name: "cancel through the flow", ticket: ticket(StatusQueued), status: StatusCancelled, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Advance(tt.ticket, tt.status, now)
+			if tt.wantErr {
+				if _, ok := err.(KitchenError); !ok {
+					t.Errorf("Advance() error = %v, want a KitchenError", err)
+				}
+				if !reflect.DeepEqual(got, tt.ticket) {
+					t.Errorf("Advance() changed a ticket it refused to move: %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Advance() error = %v", err)
+			}
+			if got.Status != tt.want {
+				t.Errorf("status = %q, want %q", got.Status, tt.want)
+			}
+			for i, at := range []*time.Time{got.StartedAt, got.BakingAt, got.ReadyAt, got.ServedAt} {
+				minutes := -1
+				if at != nil {
+					minutes = int(at.Sub(created).Minutes())
+				}
+				if minutes != tt.times[i] {
+					t.Errorf("time %d is at minute %d, want %d", i, minutes, tt.times[i])
+				}
+			}
+			if got.WaitSeconds != tt.wait || got.PrepSeconds != tt.prep {
+				t.Errorf("wait and prep = %d, %d seconds, want %d, %d", got.WaitSeconds, got.PrepSeconds, tt.wait, tt.prep)
+			}
+			if !got.UpdatedAt.Equal(now) {
+				t.Errorf("updated at %v, want %v", got.UpdatedAt, now)
+			}
+		})
+	}
+}
+
+func TestActive(t *testing.T) {
+	tests := []struct {
+		status string
+		want   bool
+	}{
+		{status: StatusQueued, want: true},
+		{status: StatusPreparing, want: true},
+		{status: StatusBaking, want: true},
+		{status: StatusReady, want: true},
+		{status: StatusServed, want: false},
+		{status: StatusCancelled, want: false},
+		{status: "", want: false},
+	}
+	for _, tt := range tests {
+		if got := Active(tt.status); got != tt.want {
+			t.Errorf("Active(%q) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestTickets(t *testing.T) {
+	items := []models.InvoiceItem{
+		{InvoiceItemId: 1, ItemKind: pricing.KindPizza, ItemId: "P1", Name: "Margherita", Size: "large", Quantity: 2, Modifiers: []models.InvoiceItem{
+			{InvoiceItemId: 2, ItemKind: pricing.KindTopping, Name: "Olives", Quantity: 2},
+			{InvoiceItemId: 3, ItemKind: pricing.KindTopping, Name: "Basil", Quantity: 1},
+		}},
+		{InvoiceItemId: 4, ItemKind: pricing.KindBeverage, ItemId: "B1", Name: "Cola", Quantity: 1},
+		{InvoiceItemId: 5, ItemKind: pricing.KindCombo, ItemId: "C1", Name: "Family deal", Quantity: 2, Components: []models.ComboComponent{
+			{ItemKind: pricing.KindPizza, ItemId: "P2", Name: "Pepperoni", Size: "medium", Quantity: 1},
+			{ItemKind: pricing.KindBeverage, ItemId: "B1", Name: "Cola", Quantity: 2},
+			{ItemKind: pricing.KindPizza, ItemId: "P1", Name: "Margherita", Size: "small", Quantity: 2},
+		}},
+	}
+	want := []models.KitchenTicket{
+		{InvoiceId: 7, InvoiceItemId: 1, ItemId: "P1", Name: "Margherita", Size: "large", Quantity: 2, Notes: []string{"+ 2 x Olives", "+ Basil"}},
+		{InvoiceId: 7, InvoiceItemId: 5, Component: 1, ItemId: "P2", Name: "Pepperoni", Size: "medium", Quantity: 2, Notes: []string{"from Family deal"}},
+		{InvoiceId: 7, InvoiceItemId: 5, Component: 3, ItemId: "P1", Name: "Margherita", Size: "small", Quantity: 4, Notes: []string{"from Family deal"}},
+	}
+	if got := Tickets(7, items); !reflect.DeepEqual(got, want) {
+		t.Errorf("Tickets() = %+v, want %+v", got, want)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	stations := []models.KitchenStation{
+		{StationId: 1, PizzaTypes: "", Active: true},
+		{StationId: 2, PizzaTypes: "P1, P2", Active: true},
+		{StationId: 3, PizzaTypes: "P3", Active: false},
+		{StationId: 4, PizzaTypes: " ", Active: true},
+	}
+	tests := []struct {
+		name        string
+		stations    []models.KitchenStation
+		pizzaTypeId string
+		want        int
+	}{
+		{name: "station listing the pizza", stations: stations, pizzaTypeId: "P2", want: 2},
+		{name: "inactive station is skipped", stations: stations, pizzaTypeId: "P3", want: 1},
+		{name: "first station taking every pizza", stations: stations, pizzaTypeId: "P9", want: 1},
+		{name: "no station makes it", stations: stations[1:3], pizzaTypeId: "P9", want: 0},
+		{name: "no stations", pizzaTypeId: "P1", want: 0},
+	}
+	for _, tt := range tests {
+		if got := Route(tt.stations, tt.pizzaTypeId); got != tt.want {
+			t.Errorf("%s: Route() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	now := *after(5)
+	stations := []models.KitchenStation{{StationId: 2, PizzaTypes: "P1", Active: true}}
+	ticket := func(id, line, quantity int, status string) models.KitchenTicket {
+		return models.KitchenTicket{TicketId: id, InvoiceItemId: line, ItemId: "P1", Name: "Margherita", Quantity: quantity, Status: status, CreatedAt: created}
+	}
+	want := func(line, quantity int) models.KitchenTicket {
+		return models.KitchenTicket{InvoiceItemId: line, ItemId: "P1", Name: "Margherita", Quantity: quantity}
+	}
+
+	tests := []struct {
+		name     string
+		existing []models.KitchenTicket
+		wanted   []models.KitchenTicket
+		//added tickets as line x quantity @ station and changed tickets as id status x quantity
+		added   []string
+		changed []string
+	}{
+		{
+			name:   "new line is queued at its station",
+			wanted: []models.KitchenTicket{want(1, 2)},
+			added:  []string{"1 x2 @2"},
+		},
+		{
+			name:     "unchanged line",
+			existing: []models.KitchenTicket{ticket(10, 1, 2, StatusQueued)},
+			wanted:   []models.KitchenTicket{want(1, 2)},
+		},
+		{
+			name:     "queued ticket follows its line",
+			existing: []models.KitchenTicket{ticket(10, 1, 2, StatusQueued)},
+			wanted:   []models.KitchenTicket{want(1, 3)},
+			changed:  []string{"10 queued x3"},
+		},
+		{
+			name:     "extra quantity of a started line is queued",
+			existing: []models.KitchenTicket{ticket(10, 1, 2, StatusBaking)},
+			wanted:   []models.KitchenTicket{want(1, 3)},
+			added:    []string{"1 x1 @2"},
+		},
+		{
+			name:     "started pizzas are kept when the quantity goes down",
+			existing: []models.KitchenTicket{ticket(10, 1, 2, StatusPreparing)},
+			wanted:   []models.KitchenTicket{want(1, 1)},
+		},
+		{
+			name:     "waiting extra is cancelled when the quantity goes down",
+			existing: []models.KitchenTicket{ticket(10, 1, 2, StatusPreparing), ticket(11, 1, 1, StatusQueued)},
+			wanted:   []models.KitchenTicket{want(1, 2)},
+			changed:  []string{"11 cancelled x1"},
+		},
+		{
+			name:     "removed line cancels what was not served",
+			existing: []models.KitchenTicket{ticket(10, 1, 1, StatusServed), ticket(11, 1, 1, StatusReady), ticket(12, 1, 1, StatusQueued)},
+			changed:  []string{"11 cancelled x1", "12 cancelled x1"},
+		},
+		{
+			name:     "cancelled tickets are queued again",
+			existing: []models.KitchenTicket{ticket(10, 1, 2, StatusCancelled)},
+			wanted:   []models.KitchenTicket{want(1, 2)},
+			added:    []string{"1 x2 @2"},
+		},
+		{
+			name:     "pizzas of a combo are matched by their position",
+			existing: []models.KitchenTicket{{TicketId: 10, InvoiceItemId: 1, Component: 1, ItemId: "P1", Name: "Margherita", Quantity: 1, Status: StatusQueued}},
+			wanted:   []models.KitchenTicket{{InvoiceItemId: 1, Component: 2, ItemId: "P1", Name: "Margherita", Quantity: 1}},
+			added:    []string{"1 x1 @2"},
+			changed:  []string{"10 cancelled x1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, changed := Reconcile(tt.existing, tt.wanted, stations, now)
+
+			var gotAdded, gotChanged []string
+			for _, ticket := range added {
+				gotAdded = append(gotAdded, fmt.Sprintf("%d x%d @%d", ticket.InvoiceItemId, ticket.Quantity, ticket.StationId))
+				if ticket.Status != StatusQueued || !ticket.CreatedAt.Equal(now) {
+					t.Errorf("added ticket is %s since %v, want queued since %v", ticket.Status, ticket.CreatedAt, now)
+				}
+			}
+			for _, ticket := range changed {
+				gotChanged = append(gotChanged, fmt.Sprintf("%d %s x%d", ticket.TicketId, ticket.Status, ticket.Quantity))
+				if !ticket.UpdatedAt.Equal(now) {
+					t.Errorf("changed ticket %d updated at %v, want %v", ticket.TicketId, ticket.UpdatedAt, now)
+				}
+			}
+			if !reflect.DeepEqual(gotAdded, tt.added) {
+				t.Errorf("added = %v, want %v", gotAdded, tt.added)
+			}
+			if !reflect.DeepEqual(gotChanged, tt.changed) {
+				t.Errorf("changed = %v, want %v", gotChanged, tt.changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,424 @@
+package listing
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"piza_shop_billing/backend/database"
+)
+
+//types of the fields a list can be filtered and sorted on
+const (
+	TypeString = "string"
+	TypeNumber = "number"
+	TypeDate   = "date"
+)
+
+//size of a page when the request does not ask for one, and the largest page that can be asked for
+const (
+	DefaultLimit = 50
+	MaxLimit     = 200
+)
+
+//layout of the values of date fields
+const DateFormat = "2006-01-02"
+
+//Field is a field of a list, named after the json field of the records listed
+type Field struct {
+	//column or SQL expression the field is read from
+	Column string
+	Type   string
+	//text fields matched by the q parameter
+	Search bool
+}
+
+//Schema describes the fields of one list
+type Schema struct {
+	Fields map[string]Field
+	//field that tells two records apart, it breaks ties so pages never overlap
+	Key string
+	//order used when the request asks for none, such as -invoice_id
+	Sort string
+}
+
+//Condition is one filter of a query, such as total[gte]=10
+type Condition struct {
+	Field string
+	Op    string
+	Value string
+}
+
+//SortKey is one field a list is ordered by
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+//Query is a parsed list request
+type Query struct {
+	Conditions []Condition
+	Search     string
+	//sort keys, the key of the schema is always the last one
+	Sort  []SortKey
+	Limit int
+	//values of the sort keys of the last record of the previous page
+	After []string
+}
+
+//Page describes the page of a list that was returned
+type Page struct {
+	Limit int `json:"limit"`
+	//records on this page and records matching the filters over all pages
+	Count int    `json:"count"`
+	Total int    `json:"total"`
+	Sort  string `json:"sort"`
+	//cursor to send back to get the next page
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+//QueryError is returned for a list request that cannot be understood
+type QueryError string
+
+func (e QueryError) Error() string { return string(e) }
+
+//operators a filter can use, eq is used when none is given
+var operators = map[string]string{"eq": "=", "ne": "<>", "gt": ">", "gte": ">=", "lt": "<", "lte": "<=", "like": "LIKE"}
+
+//parameters that are not filters
+var reserved = map[string]bool{"q": true, "sort": true, "limit": true, "cursor": true}
+
+var filterParam = regexp.MustCompile(`^([a-z_]+)(?:\[([a-z]+)\])?$`)
+
+//function to parse the query string of a list request against the schema of the list
+//filters are written field=value or field[op]=value, q searches the text fields, sort takes a comma
+//separated list of fields with a leading - for descending order, and cursor continues a previous page
+func Parse(values url.Values, schema Schema) (Query, error) {
+	q := Query{Limit: DefaultLimit, Search: strings.TrimSpace(values.Get("q"))}
+
+	if limit := values.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return q, QueryError(fmt.Sprintf("limit must be a number from 1 to %d", MaxLimit))
+		}
+		q.Limit = n
+	}
+
+	sortParam := values.Get("sort")
+	if sortParam == "" {
+		sortParam = schema.Sort
+	}
+	keyed := false
+	for _, name := range strings.Split(sortParam, ",") {
+		name = strings.TrimSpace(name)
+		key := SortKey{Field: strings.TrimPrefix(name, "-"), Desc: strings.HasPrefix(name, "-")}
+		if _, ok := schema.Fields[key.Field]; !ok {
+			return q, QueryError("cannot sort on " + key.Field)
+		}
+		q.Sort = append(q.Sort, key)
+		if key.Field == schema.Key {
+			keyed = true
+			break
+		}
+	}
+	if !keyed {
+		q.Sort = append(q.Sort, SortKey{Field: schema.Key})
+	}
+
+	//filters are read in a fixed order so the same request always builds the same query
+	var names []string
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if reserved[name] {
+			continue
+		}
+		match := filterParam.FindStringSubmatch(name)
+		if match == nil {
+			return q, QueryError("cannot filter on " + name)
+		}
+		field, ok := schema.Fields[match[1]]
+		if !ok {
+			return q, QueryError("cannot filter on " + match[1])
+		}
+		op := match[2]
+		if op == "" {
+			op = "eq"
+		}
+		if _, ok := operators[op]; !ok {
+			return q, QueryError("unknown operator " + op + ", expected eq, ne, gt, gte, lt, lte or like")
+		}
+		if op == "like" && field.Type != TypeString {
+			return q, QueryError("like only works on text fields")
+		}
+		for _, value := range values[name] {
+			if err := checkValue(match[1], field, value); err != nil {
+				return q, err
+			}
+			q.Conditions = append(q.Conditions, Condition{Field: match[1], Op: op, Value: value})
+		}
+	}
+
+	if cursor := values.Get("cursor"); cursor != "" {
+		after, err := decodeCursor(cursor, q.SortString())
+		if err != nil {
+			return q, err
+		}
+		q.After = after
+	}
+	return q, nil
+}
+
+//function to check that a filter value fits the type of its field
+func checkValue(name string, field Field, value string) error {
+	switch field.Type {
+	case TypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return QueryError(name + " must be a number")
+		}
+	case TypeDate:
+		if _, err := time.Parse(DateFormat, value); err != nil {
+			return QueryError(name + " must be a date in YYYY-MM-DD format")
+		}
+	}
+	return nil
+}
+
+//function to write the sort keys of a query the way they are sent in the sort parameter
+func (q Query) SortString() string {
+	var names []string
+	for _, key := range q.Sort {
+		if key.Desc {
+			names = append(names, "-"+key.Field)
+		} else {
+			names = append(names, key.Field)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+//cursor sent to the client, it remembers the sort it was made for
+type cursor struct {
+	Sort   string   `json:"s"`
+	Values []string `json:"v"`
+}
+
+func encodeCursor(sortString string, values []string) string {
+	data, _ := json.Marshal(cursor{Sort: sortString, Values: values})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string, sortString string) ([]string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	var c cursor
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || len(c.Values) != len(strings.Split(sortString, ",")) {
+		return nil, QueryError("cursor is not valid")
+	}
+	if c.Sort != sortString {
+		return nil, QueryError("cursor was made for another sort order")
+	}
+	return c.Values, nil
+}
+
+//function to return the SQL expression of a field, dates are compared and sorted by day
+func column(field Field) string {
+	if field.Type == TypeDate {
+		return database.Current.FormatDate(field.Column)
+	}
+	return field.Column
+}
+
+//function to turn a value of a field into a query argument
+func argument(field Field, value string) interface{} {
+	switch field.Type {
+	case TypeNumber:
+		n, _ := strconv.ParseFloat(value, 64)
+		return n
+	case TypeDate:
+		return day(value)
+	}
+	return value
+}
+
+//function to return the day of the value of a date field as YYYY-MM-DD
+//a cursor holds the json value of the field, such as an RFC 3339 time, which is compared by its local day
+//like the column, or the date and time of an invoice, which starts with its day
+func day(value string) string {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t.In(time.Local).Format(DateFormat)
+	}
+	if len(value) > len(DateFormat) {
+		return value[:len(DateFormat)]
+	}
+	return value
+}
+
+//function to build the conditions of the filters and the search of a query, joined with AND
+//the cursor is left out so the result can also be used to count every matching record
+func (q Query) Filter(schema Schema) (string, []interface{}) {
+	var clauses []string
+	var args []interface{}
+	for _, c := range q.Conditions {
+		field := schema.Fields[c.Field]
+		if c.Op == "like" {
+			clauses = append(clauses, "LOWER("+column(field)+") LIKE ?")
+			args = append(args, "%"+strings.ToLower(c.Value)+"%")
+			continue
+		}
+		clauses = append(clauses, column(field)+" "+operators[c.Op]+" ?")
+		args = append(args, argument(field, c.Value))
+	}
+
+	if q.Search != "" {
+		var matches []string
+		for _, name := range sortedFields(schema) {
+			if field := schema.Fields[name]; field.Search {
+				matches = append(matches, "LOWER("+column(field)+") LIKE ?")
+				args = append(args, "%"+strings.ToLower(q.Search)+"%")
+			}
+		}
+		if len(matches) > 0 {
+			clauses = append(clauses, "("+strings.Join(matches, " OR ")+")")
+		}
+	}
+	return strings.Join(clauses, " AND "), args
+}
+
+//function to build the condition that skips the records of the pages already returned
+//records come after the cursor when they are past it on the first sort key, or equal on it and past it on the next
+func (q Query) Seek(schema Schema) (string, []interface{}) {
+	if len(q.After) == 0 {
+		return "", nil
+	}
+	var alternatives []string
+	var args []interface{}
+	for i, key := range q.Sort {
+		var parts []string
+		for j := 0; j < i; j++ {
+			field := schema.Fields[q.Sort[j].Field]
+			parts = append(parts, column(field)+" = ?")
+			args = append(args, argument(field, q.After[j]))
+		}
+		field := schema.Fields[key.Field]
+		op := " > ?"
+		if key.Desc {
+			op = " < ?"
+		}
+		parts = append(parts, column(field)+op)
+		args = append(args, argument(field, q.After[i]))
+		alternatives = append(alternatives, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(alternatives, " OR ") + ")", args
+}
+
+//function to build the where, order by and limit clauses that read one page of a list
+//one record more than the page is read to tell whether another page follows
+func (q Query) Clauses(schema Schema) (string, []interface{}) {
+	filter, args := q.Filter(schema)
+	seek, seekArgs := q.Seek(schema)
+	var conditions []string
+	for _, clause := range []string{filter, seek} {
+		if clause != "" {
+			conditions = append(conditions, clause)
+		}
+	}
+
+	sql := ""
+	if len(conditions) > 0 {
+		sql = " WHERE " + strings.Join(conditions, " AND ")
+	}
+	var order []string
+	for _, key := range q.Sort {
+		direction := " ASC"
+		if key.Desc {
+			direction = " DESC"
+		}
+		order = append(order, column(schema.Fields[key.Field])+direction)
+	}
+	sql += " ORDER BY " + strings.Join(order, ", ") + " LIMIT ?"
+	return sql, append(append(args, seekArgs...), q.Limit+1)
+}
+
+//function to count the records matching the filters of a query, from is the table the list reads
+func (q Query) Count(db interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}, from string, schema Schema) (int, error) {
+	filter, args := q.Filter(schema)
+	query := "SELECT COUNT(*) FROM " + from
+	if filter != "" {
+		query += " WHERE " + filter
+	}
+	var total int
+	err := db.QueryRow(query, args...).Scan(&total)
+	return total, err
+}
+
+//function to cut the records read for a page down to its limit and describe the page
+//the cursor is made of the json values of the sort fields of the last record on the page
+func Paginate[T any](q Query, records []T, total int) ([]T, Page, error) {
+	page := Page{Limit: q.Limit, Total: total, Sort: q.SortString()}
+	if len(records) > q.Limit {
+		records = records[:q.Limit]
+		page.HasMore = true
+	}
+	page.Count = len(records)
+	if page.HasMore {
+		fields, err := jsonFields(records[len(records)-1])
+		if err != nil {
+			return nil, page, err
+		}
+		var values []string
+		for _, key := range q.Sort {
+			values = append(values, text(fields[key.Field]))
+		}
+		page.NextCursor = encodeCursor(page.Sort, values)
+	}
+	return records, page, nil
+}
+
+//function to read the json fields of a record
+func jsonFields(record interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]interface{}{}
+	err = json.Unmarshal(data, &fields)
+	return fields, err
+}
+
+//function to write a json value as the text used in filters and cursors
+func text(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+//function to return the names of the fields of a schema in a fixed order
+func sortedFields(schema Schema) []string {
+	var names []string
+	for name := range schema.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,66 @@
+package listing
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDay(t *testing.T) {
+	local := time.Date(2026, 1, 31, 23, 55, 0, 0, time.Local)
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "date", value: "2026-01-31", want: "2026-01-31"},
+		{name: "date and time of an invoice", value: "2026-01-31 23:55", want: "2026-01-31"},
+		{name: "local time", value: local.Format(time.RFC3339Nano), want: "2026-01-31"},
+		{name: "time in utc", value: local.UTC().Format(time.RFC3339Nano), want: "2026-01-31"},
+		{name: "time east of the shop", value: local.In(time.FixedZone("east", 10*3600)).Format(time.RFC3339Nano), want: "2026-01-31"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := day(tt.value); got != tt.want {
+				t.Errorf("day(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDateCursor(t *testing.T) {
+	schema := Schema{
+		Fields: map[string]Field{
+			"id":         {Column: "id", Type: TypeNumber},
+			"created_at": {Column: "created_at", Type: TypeDate},
+		},
+		Key:  "id",
+		Sort: "id",
+	}
+	type record struct {
+		Id        int       `json:"id"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	at := time.Date(2026, 1, 31, 12, 30, 15, 500, time.Local)
+	records := []record{{1, at}, {2, at}, {3, at.Add(time.Minute)}}
+
+	q, err := Parse(url.Values{"sort": {"created_at"}, "limit": {"2"}}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, page, err := Paginate(q, records, len(records))
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := Parse(url.Values{"sort": {"created_at"}, "limit": {"2"}, "cursor": {page.NextCursor}}, schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the rows after the second record are those of a later day, or of the same day with a greater id
+	_, args := next.Seek(schema)
+	if want := []interface{}{"2026-01-31", "2026-01-31", float64(2)}; !reflect.DeepEqual(args, want) {
+		t.Errorf("Seek() args = %v, want %v", args, want)
+	}
+}
@@ -0,0 +1,497 @@
+package loyalty
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/promotions"
+	"piza_shop_billing/backend/tax"
+)
+
+//kinds of earning rule
+const (
+	RulePerAmount = "per_amount"
+	RuleItemBonus = "item_bonus"
+)
+
+//rewards points can be redeemed for
+const (
+	RewardDiscount = "discount"
+	RewardBeverage = "beverage"
+)
+
+//kinds of ledger entry, points are earned on paid invoices and reversed when the invoice is credited,
+//redeemed on open invoices and restored when the redemption is cancelled or the invoice credited
+const (
+	EntryEarn    = "earn"
+	EntryRedeem  = "redeem"
+	EntryReverse = "reverse"
+	EntryRestore = "restore"
+	EntryAdjust  = "adjust"
+)
+
+//LoyaltyError is returned when points cannot be redeemed, as opposed to a database failure
+type LoyaltyError string
+
+func (e LoyaltyError) Error() string { return string(e) }
+
+//error returned when the customer of an invoice is changed while points of theirs are redeemed on it
+const ErrRedeemed = LoyaltyError("cancel the loyalty redemptions on the invoice before changing its customer")
+
+//Settings are the redemption terms of the program
+type Settings struct {
+	//value of one point redeemed as a discount
+	PointValue float64 `json:"point_value"`
+	//fewest points that can be redeemed as a discount at once
+	MinimumRedeem int `json:"minimum_redeem"`
+	//points a free beverage costs
+	BeveragePoints int `json:"beverage_points"`
+}
+
+//function to read the redemption terms from the environment, by default 100 points are worth 1.00
+//and a free beverage costs 150 points
+func SettingsFromEnv() Settings {
+	settings := Settings{PointValue: 0.01, MinimumRedeem: 100, BeveragePoints: 150}
+	if value, err := strconv.ParseFloat(os.Getenv("LOYALTY_POINT_VALUE"), 64); err == nil && value > 0 {
+		settings.PointValue = value
+	}
+	if points, err := strconv.Atoi(os.Getenv("LOYALTY_MIN_REDEEM")); err == nil && points > 0 {
+		settings.MinimumRedeem = points
+	}
+	if points, err := strconv.Atoi(os.Getenv("LOYALTY_BEVERAGE_POINTS")); err == nil && points > 0 {
+		settings.BeveragePoints = points
+	}
+	return settings
+}
+
+//function to check whether a kind is a known earning rule kind
+func ValidRuleKind(kind string) bool {
+	switch kind {
+	case RulePerAmount, RuleItemBonus:
+		return true
+	}
+	return false
+}
+
+//function to work out the points an invoice earns under the active rules
+//items are the lines of the invoice, only top level lines count for item bonuses
+func Earned(rules []models.LoyaltyRule, items []models.InvoiceItem, total float64) int {
+	points := 0.0
+	for _, rule := range rules {
+		if !rule.Active {
+			continue
+		}
+		switch rule.Kind {
+		case RulePerAmount:
+			points += math.Floor(total) * rule.Points
+		case RuleItemBonus:
+			for _, item := range items {
+				if item.ParentItemId == nil && item.ItemKind == rule.ItemKind && item.ItemId == rule.ItemId {
+					points += float64(item.Quantity) * rule.Points
+				}
+			}
+		}
+	}
+	//fractional rule points only count once they add up to a whole point
+	return int(math.Floor(points + 1e-9))
+}
+
+//function to check a redemption against the balance of the customer and the invoice it is made on and
+//work out its points and value, items are the lines of the invoice, redeemed the redemptions already made
+//on it and due what a discount can still take off it, see Redeemable
+func Price(settings Settings, request models.LoyaltyRedemption, balance int, items []models.InvoiceItem, redeemed []models.LoyaltyRedemption, due float64) (models.LoyaltyRedemption, error) {
+	switch request.Reward {
+	case RewardDiscount:
+		if request.Points < settings.MinimumRedeem {
+			return request, LoyaltyError(fmt.Sprintf("at least %d points must be redeemed at once", settings.MinimumRedeem))
+		}
+		request.BeverageId = ""
+		request.Amount = tax.Round(float64(request.Points) * settings.PointValue)
+		if request.Amount > tax.Round(due) {
+			return request, LoyaltyError(fmt.Sprintf("%d points are worth %.2f, more than the %.2f that can still be taken off", request.Points, request.Amount, due))
+		}
+	case RewardBeverage:
+		if request.BeverageId == "" {
+			return request, LoyaltyError("a free beverage needs the beverage_id of a beverage on the invoice")
+		}
+		quantity, price := 0, 0.0
+		for _, item := range items {
+			if item.ParentItemId == nil && item.ItemKind == pricing.KindBeverage && item.ItemId == request.BeverageId {
+				quantity += item.Quantity
+				price = item.UnitPrice
+			}
+		}
+		if quantity == 0 {
+			return request, LoyaltyError("beverage " + request.BeverageId + " is not on the invoice")
+		}
+		for _, r := range redeemed {
+			if r.Reward == RewardBeverage && r.BeverageId == request.BeverageId {
+				quantity--
+			}
+		}
+		if quantity <= 0 {
+			return request, LoyaltyError("every " + request.BeverageId + " on the invoice is already free")
+		}
+		request.Points = settings.BeveragePoints
+		request.Amount = price
+	default:
+		return request, LoyaltyError("reward must be discount or beverage")
+	}
+	if request.Points > balance {
+		return request, LoyaltyError(fmt.Sprintf("the customer has %d points, %d are needed", balance, request.Points))
+	}
+	return request, nil
+}
+
+//function to work out how much of an invoice a discount can still take off, discounts come off
+//the amounts before tax so they are limited by what the promotions and rewards left of the subtotal
+func Redeemable(invoice models.Invoice) float64 {
+	return math.Max(0, math.Min(tax.Round(invoice.SubTotal-invoice.Discount), invoice.BalanceDue))
+}
+
+//function to take the rewards redeemed on an invoice off what the promotions left of it
+//remaining is the amount left in each tax category, a reward never takes a category below zero
+func Apply(redemptions []models.LoyaltyRedemption, remaining map[string]float64) promotions.Result {
+	left := map[string]float64{}
+	var categories []string
+	for category, amount := range remaining {
+		left[category] = amount
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	var result promotions.Result
+	for _, r := range redemptions {
+		shares := map[string]float64{}
+		if r.Reward == RewardBeverage {
+			shares[tax.CategoryBeverage] = r.Amount
+		} else {
+			//a discount is spread over the categories in proportion to what is left of them
+			total := 0.0
+			for _, category := range categories {
+				total += math.Max(0, left[category])
+			}
+			if total <= 0 {
+				continue
+			}
+			for _, category := range categories {
+				shares[category] = math.Min(r.Amount, total) * math.Max(0, left[category]) / total
+			}
+		}
+
+		amount := 0.0
+		for _, category := range categories {
+			share := math.Min(tax.Round(shares[category]), tax.Round(left[category]))
+			if share <= 0 {
+				continue
+			}
+			left[category] -= share
+			amount += share
+			result.Lines = append(result.Lines, tax.Line{Category: category, Amount: -share})
+		}
+		if amount <= 0 {
+			continue
+		}
+		result.Discount += amount
+		result.Discounts = append(result.Discounts, models.InvoiceDiscount{Name: Label(r), Amount: tax.Round(amount)})
+	}
+	result.Discount = tax.Round(result.Discount)
+	return result
+}
+
+//function to name a redemption on the invoice and the receipt
+func Label(r models.LoyaltyRedemption) string {
+	if r.Reward == RewardBeverage {
+		return fmt.Sprintf("Free %s (%d points)", r.BeverageId, r.Points)
+	}
+	return fmt.Sprintf("Loyalty reward (%d points)", r.Points)
+}
+
+//function to work out how many of the points moved by an invoice go back when share of it is credited
+//done is what already went back, a final credit gives back everything that is left
+func Share(points int, done int, share float64, final bool) int {
+	left := points - done
+	if final {
+		return left
+	}
+	n := int(math.Round(float64(points) * share))
+	if n > left {
+		n = left
+	}
+	if n < 0 {
+		n = 0
+	}
+	return n
+}
+
+//function to check a manual adjustment does not leave a customer with a negative balance
+func CheckAdjustment(balance int, points int) error {
+	if points == 0 {
+		return LoyaltyError("points must not be zero")
+	}
+	if balance+points < 0 {
+		return LoyaltyError(fmt.Sprintf("the customer has %d points, %d cannot be taken off", balance, -points))
+	}
+	return nil
+}
+
+//function to work out how much of an invoice a credit note takes back, a void or a refund of
+//the last lines is final and settles every point the invoice moved
+func Credited(invoice models.Invoice, note models.CreditNote, status string) (float64, bool) {
+	final := status == payments.StatusVoid || status == payments.StatusRefunded
+	if invoice.Total <= 0 {
+		return 0, final
+	}
+	return -note.Total / invoice.Total, final
+}
+
+//function to describe a credit note in the ledger
+func CreditReason(note models.CreditNote) string {
+	if note.Reason != "" {
+		return note.CreditNoteNumber + ": " + note.Reason
+	}
+	return "Credit note " + note.CreditNoteNumber
+}
+
+//columns of the loyalty_rules table in the order they are scanned
+const ruleColumns = "rule_id, name, kind, points, item_kind, item_id, active, created_at, updated_at"
+
+//function to load every earning rule
+func LoadRules(db database.DBTX) ([]models.LoyaltyRule, error) {
+	return loadRules(db, "ORDER BY rule_id")
+}
+
+//function to load one earning rule, sql.ErrNoRows is returned when it does not exist
+func FindRule(db database.DBTX, ruleId string) (models.LoyaltyRule, error) {
+	found, err := loadRules(db, "WHERE rule_id = ?", ruleId)
+	if err != nil {
+		return models.LoyaltyRule{}, err
+	}
+	if len(found) == 0 {
+		return models.LoyaltyRule{}, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+func loadRules(db database.DBTX, clause string, args ...interface{}) ([]models.LoyaltyRule, error) {
+	results, err := db.Query("SELECT "+ruleColumns+" FROM loyalty_rules "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	rules := []models.LoyaltyRule{}
+	for results.Next() {
+		var rule models.LoyaltyRule
+		if err := results.Scan(&rule.RuleId, &rule.Name, &rule.Kind, &rule.Points, &rule.ItemKind, &rule.ItemId, &rule.Active, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, results.Err()
+}
+
+//function to load the redemptions made on an invoice in the order they were made
+func LoadRedemptions(db database.DBTX, invoiceId string) ([]models.LoyaltyRedemption, error) {
+	query := "SELECT redemption_id, invoice_id, customer_id, reward, points, amount, beverage_id, created_by, created_at FROM loyalty_redemptions WHERE invoice_id = ? ORDER BY redemption_id"
+	results, err := db.Query(query, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var redemptions []models.LoyaltyRedemption
+	for results.Next() {
+		var r models.LoyaltyRedemption
+		if err := results.Scan(&r.RedemptionId, &r.InvoiceId, &r.CustomerId, &r.Reward, &r.Points, &r.Amount, &r.BeverageId, &r.CreatedBy, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		redemptions = append(redemptions, r)
+	}
+	return redemptions, results.Err()
+}
+
+//function to store a redemption, it returns it with its id
+func SaveRedemption(db database.DBTX, r models.LoyaltyRedemption) (models.LoyaltyRedemption, error) {
+	query := "INSERT INTO loyalty_redemptions (invoice_id, customer_id, reward, points, amount, beverage_id, created_by, created_at) VALUES (?,?,?,?,?,?,?,?)"
+	result, err := db.Exec(query, r.InvoiceId, r.CustomerId, r.Reward, r.Points, r.Amount, r.BeverageId, r.CreatedBy, r.CreatedAt)
+	if err != nil {
+		return r, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return r, err
+	}
+	r.RedemptionId = int(id)
+	return r, nil
+}
+
+//function to return the points balance of a customer
+func Balance(db database.DBTX, customerId int) (int, error) {
+	var balance int
+	err := db.QueryRow("SELECT COALESCE(SUM(points), 0) FROM loyalty_ledger WHERE customer_id = ?", customerId).Scan(&balance)
+	return balance, err
+}
+
+//function to write an entry to the ledger with the balance it leaves, it returns the entry with its id
+//the customer row is locked first so concurrent entries of one customer cannot record the same balance
+func Record(db database.DBTX, entry models.LoyaltyEntry) (models.LoyaltyEntry, error) {
+	if _, err := db.Exec("UPDATE customers SET updated_at = updated_at WHERE customer_id = ?", entry.CustomerId); err != nil {
+		return entry, err
+	}
+	balance, err := Balance(db, entry.CustomerId)
+	if err != nil {
+		return entry, err
+	}
+	entry.Balance = balance + entry.Points
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	query := "INSERT INTO loyalty_ledger (customer_id, invoice_id, redemption_id, kind, points, balance, description, created_by, created_at) VALUES (?,?,?,?,?,?,?,?,?)"
+	result, err := db.Exec(query, entry.CustomerId, nullable(entry.InvoiceId), nullable(entry.RedemptionId), entry.Kind, entry.Points, entry.Balance, entry.Description, entry.CreatedBy, entry.CreatedAt)
+	if err != nil {
+		return entry, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return entry, err
+	}
+	entry.EntryId = int(id)
+	return entry, nil
+}
+
+//function to store a missing id as NULL
+func nullable(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
+
+//function to give the customer of a paid invoice the points it earns
+//points are earned once per invoice, by the customer the invoice is billed to when it is first found paid
+func Award(db database.DBTX, invoiceId string) error {
+	var status, number string
+	var customerId int
+	var total float64
+	query := "SELECT status, COALESCE(customer_id, 0), COALESCE(invoice_number, ''), total FROM invoices WHERE invoice_id = ?"
+	if err := db.QueryRow(query, invoiceId).Scan(&status, &customerId, &number, &total); err != nil {
+		return err
+	}
+	if status != payments.StatusPaid || customerId == 0 {
+		return nil
+	}
+	var earned bool
+	if err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM loyalty_ledger WHERE invoice_id = ? AND kind = ?)", invoiceId, EntryEarn).Scan(&earned); err != nil {
+		return err
+	}
+	if earned {
+		return nil
+	}
+
+	rules, err := LoadRules(db)
+	if err != nil {
+		return err
+	}
+	results, err := db.Query("SELECT item_kind, item_id, quantity FROM invoice_items WHERE invoice_id = ? AND parent_item_id IS NULL", invoiceId)
+	if err != nil {
+		return err
+	}
+	var items []models.InvoiceItem
+	for results.Next() {
+		var item models.InvoiceItem
+		if err := results.Scan(&item.ItemKind, &item.ItemId, &item.Quantity); err != nil {
+			results.Close()
+			return err
+		}
+		items = append(items, item)
+	}
+	results.Close()
+	if err := results.Err(); err != nil {
+		return err
+	}
+
+	points := Earned(rules, items, total)
+	if points <= 0 {
+		return nil
+	}
+	id, _ := strconv.Atoi(invoiceId)
+	_, err = Record(db, models.LoyaltyEntry{CustomerId: customerId, InvoiceId: id, Kind: EntryEarn, Points: points, Description: "Earned on invoice " + Reference(number, invoiceId)})
+	return err
+}
+
+//function to take back the points earned on an invoice and give back the points redeemed on it
+//when share of it is credited, a final credit such as a void settles everything that is left
+func Reverse(db database.DBTX, invoiceId string, share float64, final bool, reason string) error {
+	id, _ := strconv.Atoi(invoiceId)
+
+	var customerId, earned int
+	err := db.QueryRow("SELECT customer_id, points FROM loyalty_ledger WHERE invoice_id = ? AND kind = ?", invoiceId, EntryEarn).Scan(&customerId, &earned)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if err == nil {
+		var reversed int
+		if err := db.QueryRow("SELECT COALESCE(SUM(-points), 0) FROM loyalty_ledger WHERE invoice_id = ? AND kind = ?", invoiceId, EntryReverse).Scan(&reversed); err != nil {
+			return err
+		}
+		if n := Share(earned, reversed, share, final); n > 0 {
+			if _, err := Record(db, models.LoyaltyEntry{CustomerId: customerId, InvoiceId: id, Kind: EntryReverse, Points: -n, Description: reason}); err != nil {
+				return err
+			}
+		}
+	}
+
+	redemptions, err := LoadRedemptions(db, invoiceId)
+	if err != nil {
+		return err
+	}
+	for _, r := range redemptions {
+		var restored int
+		if err := db.QueryRow("SELECT COALESCE(SUM(points), 0) FROM loyalty_ledger WHERE redemption_id = ? AND kind = ?", r.RedemptionId, EntryRestore).Scan(&restored); err != nil {
+			return err
+		}
+		if n := Share(r.Points, restored, share, final); n > 0 {
+			entry := models.LoyaltyEntry{CustomerId: r.CustomerId, InvoiceId: id, RedemptionId: r.RedemptionId, Kind: EntryRestore, Points: n, Description: reason}
+			if _, err := Record(db, entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//function to sum up what an invoice did to the points of its customer
+func Summary(db database.DBTX, invoiceId string, customerId int) (models.LoyaltySummary, error) {
+	var summary models.LoyaltySummary
+	query := `SELECT COALESCE(SUM(CASE WHEN kind IN (?, ?) THEN points ELSE 0 END), 0),
+		COALESCE(SUM(CASE WHEN kind IN (?, ?) THEN -points ELSE 0 END), 0)
+		FROM loyalty_ledger WHERE invoice_id = ?`
+	if err := db.QueryRow(query, EntryEarn, EntryReverse, EntryRedeem, EntryRestore, invoiceId).Scan(&summary.PointsEarned, &summary.PointsRedeemed); err != nil {
+		return summary, err
+	}
+	var err error
+	if summary.Balance, err = Balance(db, customerId); err != nil {
+		return summary, err
+	}
+	summary.Redemptions, err = LoadRedemptions(db, invoiceId)
+	return summary, err
+}
+
+//function to name an invoice in ledger descriptions, invoices billed before numbering go by their id
+func Reference(number string, invoiceId string) string {
+	if number != "" {
+		return number
+	}
+	return "#" + invoiceId
+}
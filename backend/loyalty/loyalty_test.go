@@ -0,0 +1,306 @@
+package loyalty
+
+import (
+	"reflect"
+	"testing"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/tax"
+)
+
+//the default terms, 100 points are worth 1.00 and a free beverage costs 150 points
+var settings = Settings{PointValue: 0.01, MinimumRedeem: 100, BeveragePoints: 150}
+
+//function to build the lines of an invoice of two pizzas with a topping and two beverages
+func sampleItems() []models.InvoiceItem {
+	parent := 1
+	return []models.InvoiceItem{
+		{InvoiceItemId: 1, ItemKind: pricing.KindPizza, ItemId: "P1", Quantity: 2, UnitPrice: 10, LineTotal: 20},
+		{InvoiceItemId: 2, ParentItemId: &parent, ItemKind: pricing.KindTopping, ItemId: "T1", Quantity: 2, UnitPrice: 1, LineTotal: 2},
+		{InvoiceItemId: 3, ItemKind: pricing.KindBeverage, ItemId: "B1", Quantity: 2, UnitPrice: 2.5, LineTotal: 5},
+	}
+}
+
+func TestEarned(t *testing.T) {
+	perAmount := func(points float64) models.LoyaltyRule {
+		return models.LoyaltyRule{Kind: RulePerAmount, Points: points, Active: true}
+	}
+	bonus := func(kind, id string, points float64) models.LoyaltyRule {
+		return models.LoyaltyRule{Kind: RuleItemBonus, ItemKind: kind, ItemId: id, Points: points, Active: true}
+	}
+	tests := []struct {
+		name  string
+		rules []models.LoyaltyRule
+		total float64
+		want  int
+	}{
+		{name: "no rules", total: 27.9, want: 0},
+		{name: "a point per whole unit", rules: []models.LoyaltyRule{perAmount(1)}, total: 27.9, want: 27},
+		{name: "fractional points add up", rules: []models.LoyaltyRule{perAmount(0.5)}, total: 27.9, want: 13},
+		{name: "bonus per unit of an item", rules: []models.LoyaltyRule{bonus(pricing.KindPizza, "P1", 10)}, total: 27.9, want: 20},
+		{name: "toppings do not earn bonuses", rules: []models.LoyaltyRule{bonus(pricing.KindTopping, "T1", 10)}, total: 27.9, want: 0},
+		{name: "bonus needs the same kind", rules: []models.LoyaltyRule{bonus(pricing.KindBeverage, "P1", 10)}, total: 27.9, want: 0},
+		{name: "rules add up", rules: []models.LoyaltyRule{perAmount(1), bonus(pricing.KindBeverage, "B1", 5)}, total: 27.9, want: 37},
+		{name: "inactive rules are ignored", rules: []models.LoyaltyRule{{Kind: RulePerAmount, Points: 1}}, total: 27.9, want: 0},
+		{name: "fractions that add up to a whole point", rules: []models.LoyaltyRule{perAmount(0.1), perAmount(0.2)}, total: 10, want: 3},
+	}
+	for _, tt := range tests {
+		if got := Earned(tt.rules, sampleItems(), tt.total); got != tt.want {
+			t.Errorf("%s: Earned() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPrice(t *testing.T) {
+	discount := func(points int) models.LoyaltyRedemption {
+		return models.LoyaltyRedemption{Reward: RewardDiscount, Points: points}
+	}
+	beverage := func(id string) models.LoyaltyRedemption {
+		return models.LoyaltyRedemption{Reward: RewardBeverage, BeverageId: id}
+	}
+	tests := []struct {
+		name     string
+		request  models.LoyaltyRedemption
+		balance  int
+		redeemed []models.LoyaltyRedemption
+		due      float64
+		want     models.LoyaltyRedemption
+		wantErr  bool
+	}{
+		{
+			name:    "discount",
+			request: discount(250),
+			balance: 500,
+			due:     27,
+			want:    models.LoyaltyRedemption{Reward: RewardDiscount, Points: 250, Amount: 2.5},
+		},
+		{
+			name:    "discount of the whole amount due",
+			request: discount(2700),
+			balance: 3000,
+			due:     27,
+			want:    models.LoyaltyRedemption{Reward: RewardDiscount, Points: 2700, Amount: 27},
+		},
+		{
+			name:    "discount drops a beverage id",
+			request: models.LoyaltyRedemption{Reward: RewardDiscount, Points: 100, BeverageId: "B1"},
+			balance: 100,
+			due:     27,
+			want:    models.LoyaltyRedemption{Reward: RewardDiscount, Points: 100, Amount: 1},
+		},
+		{
+			name:    "free beverage at the price of one",
+			request: beverage("B1"),
+			balance: 150,
+			due:     27,
+			want:    models.LoyaltyRedemption{Reward: RewardBeverage, Points: 150, Amount: 2.5, BeverageId: "B1"},
+		},
+		{
+			name:     "second free beverage of two",
+			request:  beverage("B1"),
+			balance:  500,
+			redeemed: []models.LoyaltyRedemption{{Reward: RewardBeverage, BeverageId: "B1"}},
+			due:      24.5,
+			want:     models.LoyaltyRedemption{Reward: RewardBeverage, Points: 150, Amount: 2.5, BeverageId: "B1"},
+		},
+		{name: "below the minimum", request: discount(99), balance: 500, due: 27, wantErr: true},
+		{name: "more than is due", request: discount(2800), balance: 3000, due: 27, wantErr: true},
+		{name: "more than the balance", request: discount(600), balance: 500, due: 27, wantErr: true},
+		{name: "beverage without id", request: beverage(""), balance: 500, due: 27, wantErr: true},
+		{name: "beverage not on the invoice", request: beverage("B2"), balance: 500, due: 27, wantErr: true},
+		{name: "pizza as a free beverage", request: beverage("P1"), balance: 500, due: 27, wantErr: true},
+		{
+			name:     "every beverage already free",
+			request:  beverage("B1"),
+			balance:  500,
+			redeemed: []models.LoyaltyRedemption{{Reward: RewardBeverage, BeverageId: "B1"}, {Reward: RewardBeverage, BeverageId: "B1"}},
+			due:      22,
+			wantErr:  true,
+		},
+		{name: "beverage above the balance", request: beverage("B1"), balance: 149, due: 27, wantErr: true},
+		{name: "unknown reward", request: models.LoyaltyRedemption{Reward: "pizza", Points: 500}, balance: 500, due: 27, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Price(settings, tt.request, tt.balance, sampleItems(), tt.redeemed, tt.due)
+			if tt.wantErr {
+				if _, ok := err.(LoyaltyError); !ok {
+					t.Errorf("Price() error = %v, want a LoyaltyError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Price() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Price() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedeemable(t *testing.T) {
+	tests := []struct {
+		invoice models.Invoice
+		want    float64
+	}{
+		{invoice: models.Invoice{SubTotal: 27, BalanceDue: 29.7}, want: 27},
+		{invoice: models.Invoice{SubTotal: 27, Discount: 2.7, BalanceDue: 26.73}, want: 24.3},
+		{invoice: models.Invoice{SubTotal: 27, BalanceDue: 10}, want: 10},
+		{invoice: models.Invoice{SubTotal: 27, Discount: 27, BalanceDue: 0}, want: 0},
+	}
+	for _, tt := range tests {
+		if got := Redeemable(tt.invoice); got != tt.want {
+			t.Errorf("Redeemable(%+v) = %v, want %v", tt.invoice, got, tt.want)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		redemptions []models.LoyaltyRedemption
+		remaining   map[string]float64
+		want        []string
+		discount    float64
+		lines       []tax.Line
+	}{
+		{
+			name:        "discount spread over what is left",
+			redemptions: []models.LoyaltyRedemption{{Reward: RewardDiscount, Points: 500, Amount: 5}},
+			remaining:   map[string]float64{tax.CategoryFood: 20, tax.CategoryBeverage: 5},
+			want:        []string{"Loyalty reward (500 points)"},
+			discount:    5,
+			lines:       []tax.Line{{Category: tax.CategoryBeverage, Amount: -1}, {Category: tax.CategoryFood, Amount: -4}},
+		},
+		{
+			name:        "free beverage comes off the beverages",
+			redemptions: []models.LoyaltyRedemption{{Reward: RewardBeverage, Points: 150, Amount: 2.5, BeverageId: "B1"}},
+			remaining:   map[string]float64{tax.CategoryFood: 20, tax.CategoryBeverage: 5},
+			want:        []string{"Free B1 (150 points)"},
+			discount:    2.5,
+			lines:       []tax.Line{{Category: tax.CategoryBeverage, Amount: -2.5}},
+		},
+		{
+			name:        "free beverage already discounted by a promotion",
+			redemptions: []models.LoyaltyRedemption{{Reward: RewardBeverage, Points: 150, Amount: 2.5, BeverageId: "B1"}},
+			remaining:   map[string]float64{tax.CategoryFood: 20, tax.CategoryBeverage: 1},
+			want:        []string{"Free B1 (150 points)"},
+			discount:    1,
+			lines:       []tax.Line{{Category: tax.CategoryBeverage, Amount: -1}},
+		},
+		{
+			name: "rewards never go below zero",
+			redemptions: []models.LoyaltyRedemption{
+				{Reward: RewardBeverage, Points: 150, Amount: 2.5, BeverageId: "B1"},
+				{Reward: RewardDiscount, Points: 3000, Amount: 30},
+			},
+			remaining: map[string]float64{tax.CategoryFood: 20, tax.CategoryBeverage: 5},
+			want:      []string{"Free B1 (150 points)", "Loyalty reward (3000 points)"},
+			discount:  25,
+			lines: []tax.Line{
+				{Category: tax.CategoryBeverage, Amount: -2.5},
+				{Category: tax.CategoryBeverage, Amount: -2.5},
+				{Category: tax.CategoryFood, Amount: -20},
+			},
+		},
+		{
+			name:        "nothing left to take off",
+			redemptions: []models.LoyaltyRedemption{{Reward: RewardDiscount, Points: 100, Amount: 1}},
+			remaining:   map[string]float64{tax.CategoryFood: 0},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remaining := map[string]float64{}
+			for category, amount := range tt.remaining {
+				remaining[category] = amount
+			}
+			result := Apply(tt.redemptions, remaining)
+
+			var got []string
+			for _, d := range result.Discounts {
+				got = append(got, d.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("discounts = %v, want %v", got, tt.want)
+			}
+			if result.Discount != tt.discount {
+				t.Errorf("discount = %v, want %v", result.Discount, tt.discount)
+			}
+			if !reflect.DeepEqual(result.Lines, tt.lines) {
+				t.Errorf("lines = %+v, want %+v", result.Lines, tt.lines)
+			}
+			if !reflect.DeepEqual(remaining, tt.remaining) {
+				t.Errorf("Apply() changed the remaining amounts it was given to %v", remaining)
+			}
+		})
+	}
+}
+
+func TestShare(t *testing.T) {
+	tests := []struct {
+		name   string
+		points int
+		done   int
+		share  float64
+		final  bool
+		want   int
+	}{
+		{name: "part of an invoice credited", points: 100, share: 0.4, want: 40},
+		{name: "share is rounded", points: 25, share: 0.33, want: 8},
+		{name: "final credit settles what is left", points: 100, done: 40, share: 0.2, final: true, want: 60},
+		{name: "void settles every point", points: 100, share: 1, final: true, want: 100},
+		{name: "never more than is left", points: 100, done: 40, share: 0.7, want: 60},
+		{name: "nothing left", points: 100, done: 100, share: 0.5, want: 0},
+		{name: "nothing credited", points: 100, share: 0, want: 0},
+	}
+	for _, tt := range tests {
+		if got := Share(tt.points, tt.done, tt.share, tt.final); got != tt.want {
+			t.Errorf("%s: Share() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCredited(t *testing.T) {
+	invoice := models.Invoice{Total: 29.7}
+	tests := []struct {
+		name      string
+		invoice   models.Invoice
+		note      models.CreditNote
+		status    string
+		wantShare float64
+		wantFinal bool
+	}{
+		{name: "partial refund", invoice: invoice, note: models.CreditNote{Total: -11.88}, status: payments.StatusPaid, wantShare: 0.4},
+		{name: "refund of the last lines", invoice: invoice, note: models.CreditNote{Total: -17.82}, status: payments.StatusRefunded, wantShare: 0.6, wantFinal: true},
+		{name: "void", invoice: invoice, note: models.CreditNote{Total: -29.7}, status: payments.StatusVoid, wantShare: 1, wantFinal: true},
+		{name: "void of a free invoice", invoice: models.Invoice{}, note: models.CreditNote{}, status: payments.StatusVoid, wantShare: 0, wantFinal: true},
+	}
+	for _, tt := range tests {
+		share, final := Credited(tt.invoice, tt.note, tt.status)
+		if tax.Round(share*100) != tt.wantShare*100 || final != tt.wantFinal {
+			t.Errorf("%s: Credited() = %v, %v, want %v, %v", tt.name, share, final, tt.wantShare, tt.wantFinal)
+		}
+	}
+}
+
+func TestCheckAdjustment(t *testing.T) {
+	tests := []struct {
+		balance int
+		points  int
+		wantErr bool
+	}{
+		{balance: 0, points: 50},
+		{balance: 50, points: -50},
+		{balance: 50, points: -51, wantErr: true},
+		{balance: 50, points: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := CheckAdjustment(tt.balance, tt.points); (err != nil) != tt.wantErr {
+			t.Errorf("CheckAdjustment(%d, %d) error = %v, want error %v", tt.balance, tt.points, err, tt.wantErr)
+		}
+	}
+}
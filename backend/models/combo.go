@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+//Combo is a bundle of pizzas and beverages sold at one price, such as two medium pizzas and a drink
+type Combo struct {
+	ComboId     string      `json:"combo_id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Price       float64     `json:"price"`
+	Slots       []ComboSlot `json:"slots"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+//ComboSlot is one part of a combo, filled with Quantity pizzas or beverages
+type ComboSlot struct {
+	ComboSlotId int      `json:"combo_slot_id"`
+	Name        string   `json:"name"`
+	//pizza or beverage
+	ItemKind    string   `json:"item_kind"`
+	//size the pizzas of the slot are served in, empty for the pizza's own size
+	Size        string   `json:"size,omitempty"`
+	Quantity    int      `json:"quantity"`
+	//pizza types or beverages the slot can be filled with, empty for any item of its kind
+	ItemIds     []string `json:"item_ids,omitempty"`
+}
+
+//ComboComponent is an item chosen for a slot of a combo billed on an invoice
+//the billing fills in the names and prices, the client only sends the slot and item ids
+type ComboComponent struct {
+	ComboSlotId int     `json:"combo_slot_id"`
+	SlotName    string  `json:"slot_name,omitempty"`
+	ItemKind    string  `json:"item_kind,omitempty"`
+	ItemId      string  `json:"item_id"`
+	Name        string  `json:"name,omitempty"`
+	Size        string  `json:"size,omitempty"`
+	Quantity    int     `json:"quantity"`
+	//part of the combo price accounted to the component, it decides how the combo is taxed
+	Price       float64 `json:"price"`
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+//CreditNote cancels all or part of an invoice, the invoice itself is kept unchanged
+//its amounts are negative so they can be added to the sales they cancel
+type CreditNote struct {
+	CreditNoteId     int     `json:"credit_note_id"`
+	CreditNoteNumber string  `json:"credit_note_number"`
+	//invoice the credit note was issued against
+	InvoiceId int    `json:"invoice_id"`
+	//void for a cancelled invoice, refund for lines given back after payment
+	Kind   string `json:"kind"`
+	Reason string `json:"reason"`
+	SubTotal float64 `json:"subtotal"`
+	Discount float64 `json:"discount"`
+	//charges of the invoice given back, only by the credit note that clears the invoice
+	Charge   float64 `json:"charge"`
+	Tax      float64 `json:"tax"`
+	Total    float64 `json:"total"`
+	//money handed back to the customer and the tender it was handed back in
+	AmountRefunded float64          `json:"amount_refunded"`
+	Tender         string           `json:"tender,omitempty"`
+	Items          []CreditNoteItem `json:"items"`
+	CreatedAt      time.Time        `json:"created_at"`
+}
+
+//CreditNoteItem is a line of an invoice credited by a credit note, a pizza is credited with its toppings
+type CreditNoteItem struct {
+	CreditNoteItemId int     `json:"credit_note_item_id"`
+	CreditNoteId     int     `json:"credit_note_id"`
+	InvoiceItemId    int     `json:"invoice_item_id"`
+	Name             string  `json:"name"`
+	Quantity         int     `json:"quantity"`
+	//value of the credited units before discounts and tax, negative
+	Amount float64 `json:"amount"`
+}
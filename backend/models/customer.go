@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+//Customer is a regular of the shop, invoices billed to a customer link to them by id
+type Customer struct {
+	CustomerId int               `json:"customer_id"`
+	Name       string            `json:"name"`
+	//phone number the customer is looked up by, unique when given
+	Phone      string            `json:"phone"`
+	Email      string            `json:"email"`
+	Notes      string            `json:"notes"`
+	Addresses  []CustomerAddress `json:"addresses,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+//CustomerAddress is an address a customer has orders delivered to
+type CustomerAddress struct {
+	AddressId    int    `json:"address_id"`
+	CustomerId   int    `json:"customer_id"`
+	//name the customer knows the address by, such as home or work
+	Label        string `json:"label"`
+	Line1        string `json:"line1"`
+	Line2        string `json:"line2,omitempty"`
+	City         string `json:"city"`
+	PostalCode   string `json:"postal_code"`
+	//notes for the driver such as the door code
+	Instructions string `json:"instructions,omitempty"`
+}
+
+//CustomerSpend totals the invoices billed to a customer, net of the credit notes issued against them
+type CustomerSpend struct {
+	InvoiceCount  int     `json:"invoice_count"`
+	TotalSpent    float64 `json:"total_spent"`
+	//date of the last invoice, empty for a customer who never ordered
+	LastOrderDate string  `json:"last_order_date,omitempty"`
+}
+
+//FavouriteItem is a catalog item a customer orders, ranked by the quantity ordered
+type FavouriteItem struct {
+	ItemKind      string `json:"item_kind"`
+	ItemId        string `json:"item_id"`
+	Name          string `json:"name"`
+	Size          string `json:"size,omitempty"`
+	Quantity      int    `json:"quantity"`
+	//number of invoices the item was ordered on
+	Orders        int    `json:"orders"`
+	LastOrderDate string `json:"last_order_date"`
+}
@@ -3,10 +3,34 @@ package models
 import "time"
 type Invoice struct {
 	InvoiceId string `json:"invoice_id"`
+	//number printed on the receipt such as COL1-2026-000123, empty on invoices billed before numbering
+	InvoiceNumber string `json:"invoice_number"`
 	InvoiceDate string `json:"invoice_date"`
+	//sum of the items before discounts, the total is after discounts and tax
 	SubTotal float64 `json:"subtotal"`
+	Discount float64 `json:"discount"`
+	//packaging, service and delivery charges added after the discounts, taxed along with the items
+	Charge float64 `json:"charge"`
 	Tax float64 `json:"tax"`
 	Total float64 `json:"total"`
 	CustomerName string `json:"customer_name"`
+	//customer from the directory the invoice is billed to, 0 for walk-in customers
+	CustomerId int `json:"customer_id,omitempty"`
+	//dine_in, takeaway or delivery, dine-in invoices are billed to a table and deliveries to an address
+	OrderType string `json:"order_type"`
+	TableNumber string `json:"table_number,omitempty"`
+	Delivery *Delivery `json:"delivery,omitempty"`
+	//open until the payments cover the total, then paid, void when cancelled or refunded once every line is refunded
+	Status string `json:"status"`
+	AmountPaid float64 `json:"amount_paid"`
+	BalanceDue float64 `json:"balance_due"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	Taxes []InvoiceTax `json:"taxes,omitempty"`
+	Discounts []InvoiceDiscount `json:"discounts,omitempty"`
+	Charges []InvoiceCharge `json:"charges,omitempty"`
+	CouponCodes []string `json:"coupon_codes,omitempty"`
+	Payments []Payment `json:"payments,omitempty"`
+	CreditNotes []CreditNote `json:"credit_notes,omitempty"`
+	//points earned and redeemed on an invoice billed to a customer
+	Loyalty *LoyaltySummary `json:"loyalty,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,13 @@
+package models
+
+//InvoiceDiscount is a snapshot of a promotion applied to an invoice
+type InvoiceDiscount struct {
+	InvoiceDiscountId int     `json:"invoice_discount_id"`
+	InvoiceId         int     `json:"invoice_id"`
+	PromotionId       int     `json:"promotion_id"`
+	Name              string  `json:"name"`
+	CouponCode        string  `json:"coupon_code,omitempty"`
+	Amount            float64 `json:"amount"`
+	//terms of the promotion as they were applied, nil for loyalty rewards
+	Terms *Promotion `json:"-"`
+}
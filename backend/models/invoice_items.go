@@ -1,10 +1,21 @@
 package models
 
+//InvoiceItem is a billed line, toppings are modifier lines attached to a pizza line
+//the quantity of a modifier line is per unit of its parent line
 type InvoiceItem struct {
     InvoiceItemId int     `json:"invoice_item_id"`
     InvoiceId     int     `json:"invoice_id"`
+    ParentItemId  *int    `json:"parent_item_id,omitempty"`
+    ItemKind      string  `json:"item_kind"`
     ItemId        string  `json:"item_id,omitempty"`
+    Name          string  `json:"name,omitempty"`
+    Size          string  `json:"size,omitempty"`
     Quantity      int     `json:"quantity"`
     UnitPrice     float64 `json:"unit_price"`
-    
-}
\ No newline at end of file
+    LineTotal     float64 `json:"line_total"`
+    Modifiers     []InvoiceItem `json:"modifiers,omitempty"`
+    //set on a pizza line built with the configurator, its toppings are part of the line
+    Configuration *PizzaConfiguration `json:"configuration,omitempty"`
+    //set on a combo line, the items chosen for the slots of the combo
+    Components    []ComboComponent `json:"components,omitempty"`
+}
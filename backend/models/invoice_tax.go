@@ -0,0 +1,14 @@
+package models
+
+//InvoiceTax is a snapshot of the tax rule applied to an invoice
+type InvoiceTax struct {
+	InvoiceTaxId  int     `json:"invoice_tax_id"`
+	InvoiceId     int     `json:"invoice_id"`
+	TaxRateId     int     `json:"tax_rate_id"`
+	Name          string  `json:"name"`
+	Category      string  `json:"category"`
+	Rate          float64 `json:"rate"`
+	Inclusive     bool    `json:"inclusive"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	TaxAmount     float64 `json:"tax_amount"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+//KitchenStation is a place in the kitchen pizzas are made at, such as the oven or the prep line
+type KitchenStation struct {
+	StationId  int       `json:"station_id"`
+	Name       string    `json:"name"`
+	//comma separated pizza type ids made at the station, empty when the station takes every pizza no other station makes
+	PizzaTypes string    `json:"pizza_types"`
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+//KitchenTicket is a pizza the kitchen has to make for an invoice, a pizza line of the invoice
+//or a pizza of one of its combo lines
+type KitchenTicket struct {
+	TicketId      int        `json:"ticket_id"`
+	InvoiceId     int        `json:"invoice_id"`
+	InvoiceNumber string     `json:"invoice_number,omitempty"`
+	InvoiceItemId int        `json:"invoice_item_id"`
+	//position of the pizza in its combo line counted from 1, 0 for a pizza line
+	Component     int        `json:"component,omitempty"`
+	//0 while no station makes the pizza
+	StationId     int        `json:"station_id,omitempty"`
+	ItemId        string     `json:"item_id"`
+	Name          string     `json:"name"`
+	Size          string     `json:"size,omitempty"`
+	Quantity      int        `json:"quantity"`
+	//toppings added to or left off the pizza, as printed on the kitchen ticket
+	Notes         []string   `json:"notes,omitempty"`
+	//how the order is served, read from the invoice
+	OrderType     string     `json:"order_type"`
+	TableNumber   string     `json:"table_number,omitempty"`
+	//queued, preparing, baking, ready, served or cancelled
+	Status        string     `json:"status"`
+	//the ticket is queued when it is created, the other times are set when it reaches their status
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	BakingAt      *time.Time `json:"baking_at,omitempty"`
+	ReadyAt       *time.Time `json:"ready_at,omitempty"`
+	ServedAt      *time.Time `json:"served_at,omitempty"`
+	//seconds the ticket waited before it was started and took from queued to ready
+	WaitSeconds   int        `json:"wait_seconds"`
+	PrepSeconds   int        `json:"prep_seconds"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+//LoyaltyRule decides how many points a paid invoice earns its customer
+type LoyaltyRule struct {
+	RuleId    int       `json:"rule_id"`
+	Name      string    `json:"name"`
+	//per_amount earns Points for every whole unit of the invoice total,
+	//item_bonus earns Points for every unit of the item ItemKind/ItemId billed
+	Kind      string    `json:"kind"`
+	Points    float64   `json:"points"`
+	ItemKind  string    `json:"item_kind,omitempty"`
+	ItemId    string    `json:"item_id,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+//LoyaltyEntry is one movement of the points of a customer, entries are never changed once written
+type LoyaltyEntry struct {
+	EntryId      int       `json:"entry_id"`
+	CustomerId   int       `json:"customer_id"`
+	InvoiceId    int       `json:"invoice_id,omitempty"`
+	RedemptionId int       `json:"redemption_id,omitempty"`
+	//earn, redeem, reverse, restore or adjust
+	Kind         string    `json:"kind"`
+	//points added to the balance, negative when points are taken off
+	Points       int       `json:"points"`
+	//balance of the customer once the entry was written
+	Balance      int       `json:"balance"`
+	Description  string    `json:"description"`
+	//user who made a redemption or an adjustment, empty for points moved by the system
+	CreatedBy    string    `json:"created_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+//LoyaltyRedemption is points spent on an open invoice, taken off it as a discount
+type LoyaltyRedemption struct {
+	RedemptionId int       `json:"redemption_id"`
+	InvoiceId    int       `json:"invoice_id"`
+	CustomerId   int       `json:"customer_id"`
+	//discount or beverage
+	Reward       string    `json:"reward"`
+	Points       int       `json:"points"`
+	//value of the reward, for a free beverage the price of one of them
+	Amount       float64   `json:"amount"`
+	BeverageId   string    `json:"beverage_id,omitempty"`
+	CreatedBy    string    `json:"created_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+//LoyaltySummary is what an invoice did to the points of its customer, it is printed on the receipt
+type LoyaltySummary struct {
+	PointsEarned   int                 `json:"points_earned"`
+	PointsRedeemed int                 `json:"points_redeemed"`
+	//balance of the customer now, not when the invoice was billed
+	Balance        int                 `json:"balance"`
+	Redemptions    []LoyaltyRedemption `json:"redemptions,omitempty"`
+}
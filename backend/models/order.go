@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+//DeliveryZone is an area the shop delivers to, the zone of a delivery decides its fee
+type DeliveryZone struct {
+	ZoneId      int       `json:"zone_id"`
+	Name        string    `json:"name"`
+	Fee         float64   `json:"fee"`
+	//deliveries of at least this much after discounts are free, 0 when every delivery pays the fee
+	FreeOver    float64   `json:"free_over"`
+	//comma separated postal code prefixes found in the zone, used to pick the zone of an address
+	PostalCodes string    `json:"postal_codes"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+//OrderCharge is added to every invoice of an order type, such as packaging on takeaway or service on dine-in
+type OrderCharge struct {
+	ChargeId      int       `json:"charge_id"`
+	Name          string    `json:"name"`
+	//dine_in, takeaway or delivery
+	OrderType     string    `json:"order_type"`
+	//fixed charges Amount once per invoice, per_item for every unit of the items
+	//and percentage charges Amount as a fraction of the items after discounts
+	Kind          string    `json:"kind"`
+	Amount        float64   `json:"amount"`
+	Active        bool      `json:"active"`
+	//first day the charge applies as YYYY-MM-DD, invoices billed before keep their charges
+	EffectiveFrom string    `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+//InvoiceCharge records a charge or delivery fee added to an invoice when it was calculated
+type InvoiceCharge struct {
+	InvoiceChargeId int     `json:"invoice_charge_id"`
+	InvoiceId       int     `json:"invoice_id"`
+	//the order charge or the delivery zone the amount comes from, 0 once it is deleted
+	ChargeId        int     `json:"charge_id,omitempty"`
+	ZoneId          int     `json:"zone_id,omitempty"`
+	Name            string  `json:"name"`
+	Amount          float64 `json:"amount"`
+}
+
+//Delivery is where a delivery invoice goes
+type Delivery struct {
+	ZoneId       int    `json:"zone_id"`
+	//address of the customer the delivery was copied from, 0 when it was typed in
+	AddressId    int    `json:"address_id,omitempty"`
+	Line1        string `json:"line1"`
+	Line2        string `json:"line2"`
+	City         string `json:"city"`
+	PostalCode   string `json:"postal_code"`
+	Instructions string `json:"instructions"`
+}
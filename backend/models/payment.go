@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+//Payment is a tender taken against an invoice, an invoice can be settled with several of them
+type Payment struct {
+	PaymentId int       `json:"payment_id"`
+	InvoiceId int       `json:"invoice_id"`
+	//cash, card or voucher
+	Tender    string    `json:"tender"`
+	//part of the invoice the tender settles, for cash this is what was kept after giving change
+	Amount    float64   `json:"amount"`
+	//cash handed over by the customer and the change given back
+	Tendered  float64   `json:"tendered,omitempty"`
+	Change    float64   `json:"change,omitempty"`
+	//card authorisation or voucher code
+	Reference string    `json:"reference,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,24 @@
+package models
+
+//PizzaConfiguration is a build-your-own pizza, a base pizza type in a size with some of its
+//default toppings left off and extra toppings added on the whole pizza or on one half
+//the configurator fills in the names and prices, the client only sends the ids
+type PizzaConfiguration struct {
+	PizzaTypeId string          `json:"pizza_type_id"`
+	Name        string          `json:"name,omitempty"`
+	Size        string          `json:"size,omitempty"`
+	Removed     []ToppingChoice `json:"removed,omitempty"`
+	Added       []ToppingChoice `json:"added,omitempty"`
+	//price of the base pizza in its size, the extras are charged on top of it
+	BasePrice   float64         `json:"base_price"`
+	UnitPrice   float64         `json:"unit_price"`
+}
+
+//ToppingChoice is a topping removed from or added to a configured pizza
+type ToppingChoice struct {
+	ToppingId string  `json:"topping_id"`
+	Name      string  `json:"name,omitempty"`
+	//whole, left or right, empty means the whole pizza
+	Placement string  `json:"placement,omitempty"`
+	Price     float64 `json:"price"`
+}
@@ -0,0 +1,10 @@
+package models
+
+//PizzaSize is the price of a pizza type in one size
+//toppings on a pizza of this size cost their catalog price times ToppingMultiplier
+type PizzaSize struct {
+	PizzaTypeId       string  `json:"pizza_type_id"`
+	Size              string  `json:"size"`
+	Price             float64 `json:"price"`
+	ToppingMultiplier float64 `json:"topping_multiplier"`
+}
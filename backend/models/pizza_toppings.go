@@ -1,7 +1,16 @@
 package models
 
+//PizzaTopping links a topping to a pizza type, default toppings are included in the
+//price of the pizza while the others are offered as chargeable extras
 type PizzaTopping struct {
 	PizzaToppingId int `json:"pizza_topping_id"`
 	PizzaTypeId string `json:"pizza_type_id"`
 	ToppingId string `json:"topping_id"`
+	IsDefault bool `json:"is_default"`
+}
+
+//LinkedTopping is a topping of the catalog as it is offered on a pizza type
+type LinkedTopping struct {
+	Topping
+	IsDefault bool `json:"is_default"`
 }
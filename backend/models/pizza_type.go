@@ -9,5 +9,7 @@ type PizzaType struct {
 	Description	string `json:"description"`
 	CreatedAt	time.Time `json:"created_at"`
 	UpdatedAt	time.Time `json:"updated_at"`
+	//price matrix of the sizes the pizza is sold in, base_price applies to unsized lines
+	Sizes		[]PizzaSize `json:"sizes,omitempty"`
 
 }
\ No newline at end of file
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+//Promotion is a discount rule, it applies to every invoice it matches
+//unless it has a coupon code, then only to invoices the code was entered on
+type Promotion struct {
+	PromotionId  int       `json:"promotion_id"`
+	Name         string    `json:"name"`
+	//percentage, fixed, buy_x_get_y or combo
+	Kind         string    `json:"kind"`
+	//fraction taken off by a percentage promotion, amount taken off by a fixed or combo promotion
+	Value        float64   `json:"value"`
+	//pizza type the promotion is limited to, empty for every pizza type
+	PizzaTypeId  string    `json:"pizza_type_id,omitempty"`
+	//beverage a combo deal pairs with the pizza, empty for any beverage
+	BeverageId   string    `json:"beverage_id,omitempty"`
+	//buy BuyQuantity pizzas and get FreeQuantity more for free
+	BuyQuantity  int       `json:"buy_quantity,omitempty"`
+	FreeQuantity int       `json:"free_quantity,omitempty"`
+	CouponCode   string    `json:"coupon_code,omitempty"`
+	//number of invoices the coupon can be used on, 0 for no limit
+	UsageLimit   int       `json:"usage_limit"`
+	TimesUsed    int       `json:"times_used"`
+	//first and last day the promotion runs as YYYY-MM-DD, empty for open ended
+	ValidFrom    string    `json:"valid_from,omitempty"`
+	ValidTo      string    `json:"valid_to,omitempty"`
+	//happy hour schedule, weekdays such as "mon,fri" and a HH:MM time window
+	Days         string    `json:"days,omitempty"`
+	StartTime    string    `json:"start_time,omitempty"`
+	EndTime      string    `json:"end_time,omitempty"`
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
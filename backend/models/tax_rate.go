@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type TaxRate struct {
+	TaxRateId     int       `json:"tax_rate_id"`
+	Name          string    `json:"name"`
+	Category      string    `json:"category"`
+	Rate          float64   `json:"rate"`
+	Inclusive     bool      `json:"inclusive"`
+	EffectiveFrom string    `json:"effective_from"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+type User struct {
+	UserId       int       `json:"user_id"`
+	Username     string    `json:"username"`
+	Password     string    `json:"password,omitempty"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
@@ -0,0 +1,62 @@
+package numbering
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"piza_shop_billing/backend/database"
+)
+
+//Format describes a series of document numbers such as COL1-2026-000123,
+//every prefix restarts its sequence at 1 each year
+type Format struct {
+	Prefix string
+	Digits int
+}
+
+//function to read the format of invoice numbers from the environment, INVOICE_NUMBER_PREFIX
+//is usually the branch code and INVOICE_NUMBER_DIGITS the width of the sequence
+func InvoiceFormat() Format {
+	return formatFromEnv("INVOICE_NUMBER_PREFIX", "INV")
+}
+
+//function to read the format of credit note numbers from the environment, by default
+//credit notes are numbered in their own series next to the invoices of the branch
+func CreditNoteFormat() Format {
+	return formatFromEnv("CREDIT_NOTE_NUMBER_PREFIX", InvoiceFormat().Prefix+"-CN")
+}
+
+func formatFromEnv(prefixVar string, defaultPrefix string) Format {
+	format := Format{Prefix: strings.ToUpper(strings.TrimSpace(os.Getenv(prefixVar))), Digits: 6}
+	if format.Prefix == "" {
+		format.Prefix = defaultPrefix
+	}
+	if digits, err := strconv.Atoi(os.Getenv("INVOICE_NUMBER_DIGITS")); err == nil && digits > 0 && digits <= 12 {
+		format.Digits = digits
+	}
+	return format
+}
+
+//function to format the number of a document from its year and its place in the sequence of that year
+func (f Format) Number(year int, sequence int) string {
+	return fmt.Sprintf("%s-%d-%0*d", f.Prefix, year, f.Digits, sequence)
+}
+
+//function to take the next number of a series for a year
+//it must run in the transaction that stores the document, the sequence row stays locked until the
+//transaction ends so concurrent cashiers wait for each other, and a rolled back document gives its
+//number back so the series has no gaps
+func (f Format) Next(db database.DBTX, year int) (string, error) {
+	query := "INSERT INTO number_sequences (prefix, year, last_number) VALUES (?, ?, 1) " +
+		database.Current.OnConflictUpdate("prefix, year", "last_number = last_number + 1")
+	if _, err := db.Exec(query, f.Prefix, year); err != nil {
+		return "", err
+	}
+	var sequence int
+	if err := db.QueryRow("SELECT last_number FROM number_sequences WHERE prefix = ? AND year = ?", f.Prefix, year).Scan(&sequence); err != nil {
+		return "", err
+	}
+	return f.Number(year, sequence), nil
+}
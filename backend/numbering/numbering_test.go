@@ -0,0 +1,130 @@
+package numbering
+
+import (
+	"testing"
+
+	"piza_shop_billing/backend/database/databasetest"
+)
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		format   Format
+		year     int
+		sequence int
+		want     string
+	}{
+		{format: Format{Prefix: "COL1", Digits: 6}, year: 2026, sequence: 123, want: "COL1-2026-000123"},
+		{format: Format{Prefix: "INV-CN", Digits: 6}, year: 2026, sequence: 1, want: "INV-CN-2026-000001"},
+		{format: Format{Prefix: "INV", Digits: 3}, year: 2027, sequence: 42, want: "INV-2027-042"},
+		//a sequence wider than the digits is printed in full
+		{format: Format{Prefix: "INV", Digits: 3}, year: 2027, sequence: 1234, want: "INV-2027-1234"},
+	}
+	for _, tt := range tests {
+		if got := tt.format.Number(tt.year, tt.sequence); got != tt.want {
+			t.Errorf("%+v.Number(%d, %d) = %q, want %q", tt.format, tt.year, tt.sequence, got, tt.want)
+		}
+	}
+}
+
+func TestFormatsFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		prefix      string
+		creditNotes string
+		digits      string
+		invoice     Format
+		creditNote  Format
+	}{
+		{name: "defaults", invoice: Format{Prefix: "INV", Digits: 6}, creditNote: Format{Prefix: "INV-CN", Digits: 6}},
+		{name: "branch prefix", prefix: " col1 ", digits: "4", invoice: Format{Prefix: "COL1", Digits: 4}, creditNote: Format{Prefix: "COL1-CN", Digits: 4}},
+		{name: "own credit note prefix", prefix: "COL1", creditNotes: "cr1", invoice: Format{Prefix: "COL1", Digits: 6}, creditNote: Format{Prefix: "CR1", Digits: 6}},
+		{name: "digits out of range", digits: "13", invoice: Format{Prefix: "INV", Digits: 6}, creditNote: Format{Prefix: "INV-CN", Digits: 6}},
+		{name: "digits not a number", digits: "six", invoice: Format{Prefix: "INV", Digits: 6}, creditNote: Format{Prefix: "INV-CN", Digits: 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("INVOICE_NUMBER_PREFIX", tt.prefix)
+			t.Setenv("CREDIT_NOTE_NUMBER_PREFIX", tt.creditNotes)
+			t.Setenv("INVOICE_NUMBER_DIGITS", tt.digits)
+			if got := InvoiceFormat(); got != tt.invoice {
+				t.Errorf("InvoiceFormat() = %+v, want %+v", got, tt.invoice)
+			}
+			if got := CreditNoteFormat(); got != tt.creditNote {
+				t.Errorf("CreditNoteFormat() = %+v, want %+v", got, tt.creditNote)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	db := databasetest.Open(t)
+	invoices := Format{Prefix: "COL1", Digits: 6}
+	otherBranch := Format{Prefix: "COL2", Digits: 6}
+	creditNotes := Format{Prefix: "COL1-CN", Digits: 6}
+
+	//every series counts on its own and starts again each year
+	steps := []struct {
+		format Format
+		year   int
+		want   string
+	}{
+		{format: invoices, year: 2026, want: "COL1-2026-000001"},
+		{format: invoices, year: 2026, want: "COL1-2026-000002"},
+		{format: otherBranch, year: 2026, want: "COL2-2026-000001"},
+		{format: creditNotes, year: 2026, want: "COL1-CN-2026-000001"},
+		{format: invoices, year: 2026, want: "COL1-2026-000003"},
+		{format: invoices, year: 2027, want: "COL1-2027-000001"},
+		{format: invoices, year: 2026, want: "COL1-2026-000004"},
+	}
+	for i, step := range steps {
+		got, err := step.format.Next(db, step.year)
+		if err != nil {
+			t.Fatalf("step %d: Next() error = %v", i, err)
+		}
+		if got != step.want {
+			t.Errorf("step %d: Next() = %q, want %q", i, got, step.want)
+		}
+	}
+}
+
+func TestNextRolledBackGivesTheNumberBack(t *testing.T) {
+	db := databasetest.Open(t)
+	format := Format{Prefix: "INV", Digits: 6}
+
+	take := func(commit bool) string {
+		t.Helper()
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		number, err := format.Next(tx, 2026)
+		if err != nil {
+			tx.Rollback()
+			t.Fatal(err)
+		}
+		if commit {
+			err = tx.Commit()
+		} else {
+			err = tx.Rollback()
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		return number
+	}
+
+	tests := []struct {
+		commit bool
+		want   string
+	}{
+		{commit: true, want: "INV-2026-000001"},
+		{commit: false, want: "INV-2026-000002"},
+		{commit: true, want: "INV-2026-000002"},
+		{commit: true, want: "INV-2026-000003"},
+	}
+	for i, tt := range tests {
+		if got := take(tt.commit); got != tt.want {
+			t.Errorf("document %d took %q, want %q", i, got, tt.want)
+		}
+	}
+}
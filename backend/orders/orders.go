@@ -0,0 +1,338 @@
+package orders
+
+import (
+	"database/sql"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/tax"
+)
+
+//order types an invoice can be billed as
+const (
+	TypeDineIn   = "dine_in"
+	TypeTakeaway = "takeaway"
+	TypeDelivery = "delivery"
+)
+
+//order type of invoices that do not say, counter sales are taken away
+const DefaultType = TypeTakeaway
+
+//kinds of order charge
+const (
+	ChargeFixed      = "fixed"
+	ChargePerItem    = "per_item"
+	ChargePercentage = "percentage"
+)
+
+//OrderError is returned when the order details of an invoice are invalid, as opposed to a database failure
+type OrderError string
+
+func (e OrderError) Error() string { return string(e) }
+
+//Order is what the charges of an invoice depend on, its type with the charges
+//configured for it and the zone a delivery goes to
+type Order struct {
+	Type    string
+	Charges []models.OrderCharge
+	Zone    *models.DeliveryZone
+}
+
+//Result holds the charges added to an invoice and the taxable lines they add
+type Result struct {
+	Charge  float64
+	Charges []models.InvoiceCharge
+	Lines   []tax.Line
+}
+
+//function to check whether a name is a known order type
+func ValidType(orderType string) bool {
+	switch orderType {
+	case TypeDineIn, TypeTakeaway, TypeDelivery:
+		return true
+	}
+	return false
+}
+
+//function to check whether a kind is a known order charge kind
+func ValidChargeKind(kind string) bool {
+	switch kind {
+	case ChargeFixed, ChargePerItem, ChargePercentage:
+		return true
+	}
+	return false
+}
+
+//function to name an order type on receipts
+func Label(orderType string) string {
+	switch orderType {
+	case TypeDineIn:
+		return "Dine-in"
+	case TypeDelivery:
+		return "Delivery"
+	}
+	return "Takeaway"
+}
+
+//function to work out the charges of an invoice at the moment it was billed
+//items are the lines of the invoice and remaining what the discounts left of each tax category,
+//percentage charges are taken of the remaining amounts and taxed with them, other charges are taxed at the rate for all
+func Apply(order Order, items []models.InvoiceItem, remaining map[string]float64, at time.Time) Result {
+	day := at.Format(tax.DateFormat)
+	var categories []string
+	net := 0.0
+	for category, amount := range remaining {
+		categories = append(categories, category)
+		net += amount
+	}
+	sort.Strings(categories)
+
+	var result Result
+	add := func(charge models.InvoiceCharge, lines []tax.Line) {
+		amount := 0.0
+		for _, line := range lines {
+			if line.Amount <= 0 {
+				continue
+			}
+			amount += line.Amount
+			result.Lines = append(result.Lines, line)
+		}
+		if amount <= 0 {
+			return
+		}
+		charge.Amount = tax.Round(amount)
+		result.Charge += charge.Amount
+		result.Charges = append(result.Charges, charge)
+	}
+
+	for _, c := range order.Charges {
+		if !c.Active || c.OrderType != order.Type || c.EffectiveFrom > day {
+			continue
+		}
+		charge := models.InvoiceCharge{ChargeId: c.ChargeId, Name: c.Name}
+		switch c.Kind {
+		case ChargeFixed:
+			add(charge, []tax.Line{{Category: tax.CategoryAll, Amount: c.Amount}})
+		case ChargePerItem:
+			units := 0
+			for _, item := range items {
+				if item.ParentItemId == nil {
+					units += item.Quantity
+				}
+			}
+			add(charge, []tax.Line{{Category: tax.CategoryAll, Amount: tax.Round(c.Amount * float64(units))}})
+		case ChargePercentage:
+			var lines []tax.Line
+			for _, category := range categories {
+				lines = append(lines, tax.Line{Category: category, Amount: tax.Round(remaining[category] * c.Amount)})
+			}
+			add(charge, lines)
+		}
+	}
+
+	//a delivery pays the fee of its zone unless the order is large enough to go free
+	if zone := order.Zone; order.Type == TypeDelivery && zone != nil {
+		if zone.FreeOver <= 0 || tax.Round(net) < zone.FreeOver {
+			add(models.InvoiceCharge{ZoneId: zone.ZoneId, Name: "Delivery (" + zone.Name + ")"}, []tax.Line{{Category: tax.CategoryAll, Amount: zone.Fee}})
+		}
+	}
+	result.Charge = tax.Round(result.Charge)
+	return result
+}
+
+//function to find the active zone a postal code belongs to, the zone with the longest matching prefix wins
+func ZoneFor(zones []models.DeliveryZone, postalCode string) (models.DeliveryZone, bool) {
+	code := normalizePostalCode(postalCode)
+	var best models.DeliveryZone
+	longest := 0
+	for _, zone := range zones {
+		if !zone.Active || code == "" {
+			continue
+		}
+		for _, prefix := range strings.Split(zone.PostalCodes, ",") {
+			prefix = normalizePostalCode(prefix)
+			if prefix != "" && strings.HasPrefix(code, prefix) && len(prefix) > longest {
+				best, longest = zone, len(prefix)
+			}
+		}
+	}
+	return best, longest > 0
+}
+
+//function to compare postal codes without case or spaces
+func normalizePostalCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
+
+//columns of the delivery_zones table in the order they are scanned
+const zoneColumns = "zone_id, name, fee, free_over, postal_codes, active, created_at, updated_at"
+
+//function to load every order charge
+func LoadCharges(db database.DBTX) ([]models.OrderCharge, error) {
+	return loadCharges(db, "ORDER BY charge_id")
+}
+
+//function to load one order charge, sql.ErrNoRows is returned when it does not exist
+func FindCharge(db database.DBTX, chargeId string) (models.OrderCharge, error) {
+	found, err := loadCharges(db, "WHERE charge_id = ?", chargeId)
+	if err != nil {
+		return models.OrderCharge{}, err
+	}
+	if len(found) == 0 {
+		return models.OrderCharge{}, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+func loadCharges(db database.DBTX, clause string, args ...interface{}) ([]models.OrderCharge, error) {
+	query := "SELECT charge_id, name, order_type, kind, amount, active, " + database.Current.FormatDate("effective_from") + ", created_at, updated_at FROM order_charges "
+	results, err := db.Query(query+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	charges := []models.OrderCharge{}
+	for results.Next() {
+		var c models.OrderCharge
+		if err := results.Scan(&c.ChargeId, &c.Name, &c.OrderType, &c.Kind, &c.Amount, &c.Active, &c.EffectiveFrom, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		charges = append(charges, c)
+	}
+	return charges, results.Err()
+}
+
+//function to load every delivery zone
+func LoadZones(db database.DBTX) ([]models.DeliveryZone, error) {
+	return loadZones(db, "ORDER BY zone_id")
+}
+
+//function to load one delivery zone, sql.ErrNoRows is returned when it does not exist
+func FindZone(db database.DBTX, zoneId string) (models.DeliveryZone, error) {
+	found, err := loadZones(db, "WHERE zone_id = ?", zoneId)
+	if err != nil {
+		return models.DeliveryZone{}, err
+	}
+	if len(found) == 0 {
+		return models.DeliveryZone{}, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+func loadZones(db database.DBTX, clause string, args ...interface{}) ([]models.DeliveryZone, error) {
+	results, err := db.Query("SELECT "+zoneColumns+" FROM delivery_zones "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	zones := []models.DeliveryZone{}
+	for results.Next() {
+		var z models.DeliveryZone
+		if err := results.Scan(&z.ZoneId, &z.Name, &z.Fee, &z.FreeOver, &z.PostalCodes, &z.Active, &z.CreatedAt, &z.UpdatedAt); err != nil {
+			return nil, err
+		}
+		zones = append(zones, z)
+	}
+	return zones, results.Err()
+}
+
+//function to load what the charges of an invoice depend on
+func LoadOrder(db database.DBTX, invoiceId string) (Order, error) {
+	var order Order
+	var zoneId int
+	query := "SELECT i.order_type, COALESCE(d.zone_id, 0) FROM invoices i LEFT JOIN invoice_deliveries d ON d.invoice_id = i.invoice_id WHERE i.invoice_id = ?"
+	if err := db.QueryRow(query, invoiceId).Scan(&order.Type, &zoneId); err != nil {
+		return order, err
+	}
+	var err error
+	if order.Charges, err = LoadCharges(db); err != nil {
+		return order, err
+	}
+	if zoneId != 0 {
+		zone, err := FindZone(db, strconv.Itoa(zoneId))
+		if err != nil && err != sql.ErrNoRows {
+			return order, err
+		}
+		//a zone deleted since keeps its fee off the invoice
+		if err == nil {
+			order.Zone = &zone
+		}
+	}
+	return order, nil
+}
+
+//function to replace the delivery address of an invoice, nil removes it
+func SaveDelivery(db database.DBTX, invoiceId string, delivery *models.Delivery) error {
+	if _, err := db.Exec("DELETE FROM invoice_deliveries WHERE invoice_id = ?", invoiceId); err != nil {
+		return err
+	}
+	if delivery == nil {
+		return nil
+	}
+	query := "INSERT INTO invoice_deliveries (invoice_id, zone_id, address_id, line1, line2, city, postal_code, instructions) VALUES (?,?,?,?,?,?,?,?)"
+	_, err := db.Exec(query, invoiceId, nullable(delivery.ZoneId), nullable(delivery.AddressId), delivery.Line1, delivery.Line2, delivery.City, delivery.PostalCode, delivery.Instructions)
+	return err
+}
+
+//function to load the delivery address of an invoice, nil when it is not delivered
+func LoadDelivery(db database.DBTX, invoiceId string) (*models.Delivery, error) {
+	var d models.Delivery
+	query := "SELECT COALESCE(zone_id, 0), COALESCE(address_id, 0), line1, line2, city, postal_code, instructions FROM invoice_deliveries WHERE invoice_id = ?"
+	err := db.QueryRow(query, invoiceId).Scan(&d.ZoneId, &d.AddressId, &d.Line1, &d.Line2, &d.City, &d.PostalCode, &d.Instructions)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+//function to replace the charge snapshot stored against an invoice
+func SaveInvoiceCharges(db database.DBTX, invoiceId string, charges []models.InvoiceCharge) error {
+	if _, err := db.Exec("DELETE FROM invoice_charges WHERE invoice_id = ?", invoiceId); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO invoice_charges (invoice_id, charge_id, zone_id, name, amount) VALUES (?, ?, ?, ?, ?)"
+	for _, c := range charges {
+		if _, err := db.Exec(query, invoiceId, nullable(c.ChargeId), nullable(c.ZoneId), c.Name, c.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//function to load the charge snapshot stored against an invoice
+func LoadInvoiceCharges(db database.DBTX, invoiceId string) ([]models.InvoiceCharge, error) {
+	query := "SELECT invoice_charge_id, invoice_id, COALESCE(charge_id, 0), COALESCE(zone_id, 0), name, amount FROM invoice_charges WHERE invoice_id = ? ORDER BY invoice_charge_id"
+	results, err := db.Query(query, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var charges []models.InvoiceCharge
+	for results.Next() {
+		var c models.InvoiceCharge
+		if err := results.Scan(&c.InvoiceChargeId, &c.InvoiceId, &c.ChargeId, &c.ZoneId, &c.Name, &c.Amount); err != nil {
+			return nil, err
+		}
+		charges = append(charges, c)
+	}
+	return charges, results.Err()
+}
+
+//function to store a missing id as NULL
+func nullable(id int) interface{} {
+	if id == 0 {
+		return nil
+	}
+	return id
+}
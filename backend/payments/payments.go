@@ -0,0 +1,211 @@
+package payments
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+)
+
+//tenders a payment can be taken in
+const (
+	TenderCash    = "cash"
+	TenderCard    = "card"
+	TenderVoucher = "voucher"
+)
+
+//states of an invoice, an open invoice becomes paid once its payments cover the total
+//and a paid invoice becomes refunded once every line has been refunded
+const (
+	StatusOpen     = "open"
+	StatusPaid     = "paid"
+	StatusVoid     = "void"
+	StatusRefunded = "refunded"
+)
+
+//PaymentError is returned when a tender cannot be taken, as opposed to a database failure
+type PaymentError string
+
+func (e PaymentError) Error() string { return string(e) }
+
+//error returned when an invoice that is no longer open is changed
+var ErrLocked = errors.New("invoice is paid, void or refunded and can no longer be changed")
+
+//function to check whether a tender is one the shop takes
+func ValidTender(tender string) bool {
+	switch tender {
+	case TenderCash, TenderCard, TenderVoucher:
+		return true
+	}
+	return false
+}
+
+//function to round an amount to cents
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+//function to take the tenders of a payment against the balance due on an invoice
+//cards and vouchers are taken first and can never exceed what is due, cash is taken last
+//and the part of it above the balance is given back as change
+func Take(balance float64, tenders []models.Payment) ([]models.Payment, float64, error) {
+	if len(tenders) == 0 {
+		return nil, 0, PaymentError("a payment needs at least one tender")
+	}
+	if balance <= 0 {
+		return nil, 0, PaymentError("the invoice has nothing left to pay")
+	}
+
+	var ordered []models.Payment
+	for _, tender := range tenders {
+		if tender.Tender != TenderCash {
+			ordered = append(ordered, tender)
+		}
+	}
+	for _, tender := range tenders {
+		if tender.Tender == TenderCash {
+			ordered = append(ordered, tender)
+		}
+	}
+
+	remaining := round(balance)
+	change := 0.0
+	taken := make([]models.Payment, 0, len(ordered))
+	for _, tender := range ordered {
+		if !ValidTender(tender.Tender) {
+			return nil, 0, PaymentError("tender must be one of cash, card or voucher")
+		}
+		amount := round(tender.Amount)
+		if amount <= 0 {
+			return nil, 0, PaymentError("the amount of a tender must be greater than zero")
+		}
+		if remaining <= 0 {
+			return nil, 0, PaymentError("the invoice is settled before the " + tender.Tender + " tender")
+		}
+
+		switch tender.Tender {
+		case TenderCash:
+			tender.Tendered = amount
+			tender.Amount = math.Min(amount, remaining)
+			tender.Change = round(amount - tender.Amount)
+			change += tender.Change
+		default:
+			if tender.Tender == TenderVoucher && tender.Reference == "" {
+				return nil, 0, PaymentError("a voucher payment needs the voucher code as reference")
+			}
+			if amount > remaining {
+				return nil, 0, PaymentError(fmt.Sprintf("a %s payment cannot be more than the %.2f still due", tender.Tender, remaining))
+			}
+			tender.Amount = amount
+			tender.Tendered = 0
+			tender.Change = 0
+		}
+		remaining = round(remaining - tender.Amount)
+		taken = append(taken, tender)
+	}
+	return taken, round(change), nil
+}
+
+//function to work out the status of an invoice from its subtotal, its total and what has been paid on it
+//void and refunded invoices keep their status, an invoice whose items are all discounted away is paid
+//without a tender, other invoices stay open until a payment covers the total
+func StatusFor(current string, subTotal float64, total float64, paid float64) string {
+	if current == StatusVoid || current == StatusRefunded {
+		return current
+	}
+	if round(total) <= 0 && round(subTotal) > 0 {
+		return StatusPaid
+	}
+	if paid > 0 && round(paid) >= round(total) {
+		return StatusPaid
+	}
+	return StatusOpen
+}
+
+//function to check that what has been paid does not exceed a new invoice total
+func CheckTotal(total float64, paid float64) error {
+	if round(paid) > round(total) {
+		return PaymentError(fmt.Sprintf("the invoice total cannot fall below the %.2f already paid", paid))
+	}
+	return nil
+}
+
+//function to check that an invoice can still be changed, it returns sql.ErrNoRows when the invoice does not exist
+func CheckOpen(db database.DBTX, invoiceId string) error {
+	var status string
+	if err := db.QueryRow("SELECT status FROM invoices WHERE invoice_id = ?", invoiceId).Scan(&status); err != nil {
+		return err
+	}
+	if status != StatusOpen {
+		return ErrLocked
+	}
+	return nil
+}
+
+//function to store the amount paid and status of an invoice after its total changed
+//it fails when the new total is below what has already been paid
+func Settle(db database.DBTX, invoiceId string, total float64) error {
+	var status string
+	var subTotal, paid float64
+	query := "SELECT status, subtotal, (SELECT COALESCE(SUM(amount), 0) FROM payments WHERE invoice_id = ?) FROM invoices WHERE invoice_id = ?"
+	if err := db.QueryRow(query, invoiceId, invoiceId).Scan(&status, &subTotal, &paid); err != nil {
+		return err
+	}
+	if status == StatusOpen || status == StatusPaid {
+		if err := CheckTotal(total, paid); err != nil {
+			return err
+		}
+	}
+	_, err := db.Exec("UPDATE invoices SET status=?, amount_paid=? WHERE invoice_id=?", StatusFor(status, subTotal, total, paid), round(paid), invoiceId)
+	return err
+}
+
+//function to record tenders against an invoice, it returns them with their ids
+func Save(db database.DBTX, invoiceId int, tenders []models.Payment) ([]models.Payment, error) {
+	query := "INSERT INTO payments (invoice_id, tender, amount, tendered, change_given, reference, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)"
+	saved := make([]models.Payment, 0, len(tenders))
+	for _, payment := range tenders {
+		payment.InvoiceId = invoiceId
+		result, err := db.Exec(query, payment.InvoiceId, payment.Tender, payment.Amount, payment.Tendered, payment.Change, payment.Reference, payment.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		payment.PaymentId = int(id)
+		saved = append(saved, payment)
+	}
+	return saved, nil
+}
+
+//function to load the payments taken against an invoice in the order they were taken
+func Load(db database.DBTX, invoiceId string) ([]models.Payment, error) {
+	query := "SELECT payment_id, invoice_id, tender, amount, tendered, change_given, reference, created_at FROM payments WHERE invoice_id = ? ORDER BY payment_id"
+	results, err := db.Query(query, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var payments []models.Payment
+	for results.Next() {
+		var payment models.Payment
+		if err := results.Scan(&payment.PaymentId, &payment.InvoiceId, &payment.Tender, &payment.Amount, &payment.Tendered, &payment.Change, &payment.Reference, &payment.CreatedAt); err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, results.Err()
+}
+
+//function to work out what is still due on an invoice, nothing is due on a void or refunded invoice
+func Balance(status string, total float64, paid float64) float64 {
+	if status == StatusVoid || status == StatusRefunded {
+		return 0
+	}
+	return math.Max(0, round(total-paid))
+}
@@ -0,0 +1,172 @@
+package payments
+
+import (
+	"reflect"
+	"testing"
+
+	"piza_shop_billing/backend/models"
+)
+
+func TestTake(t *testing.T) {
+	cash := func(amount float64) models.Payment { return models.Payment{Tender: TenderCash, Amount: amount} }
+	card := func(amount float64) models.Payment {
+		return models.Payment{Tender: TenderCard, Amount: amount, Reference: "AUTH1"}
+	}
+	voucher := func(amount float64, code string) models.Payment {
+		return models.Payment{Tender: TenderVoucher, Amount: amount, Reference: code}
+	}
+
+	tests := []struct {
+		name    string
+		balance float64
+		tenders []models.Payment
+		want    []models.Payment
+		change  float64
+		wantErr bool
+	}{
+		{
+			name:    "exact cash",
+			balance: 20,
+			tenders: []models.Payment{cash(20)},
+			want:    []models.Payment{{Tender: TenderCash, Amount: 20, Tendered: 20}},
+		},
+		{
+			name:    "cash with change due",
+			balance: 17.25,
+			tenders: []models.Payment{cash(20)},
+			want:    []models.Payment{{Tender: TenderCash, Amount: 17.25, Tendered: 20, Change: 2.75}},
+			change:  2.75,
+		},
+		{
+			name:    "partial cash leaves the rest due",
+			balance: 30,
+			tenders: []models.Payment{cash(10)},
+			want:    []models.Payment{{Tender: TenderCash, Amount: 10, Tendered: 10}},
+		},
+		{
+			name:    "split tenders take cash last",
+			balance: 50,
+			tenders: []models.Payment{cash(20), card(25), voucher(5, "GIFT5")},
+			want: []models.Payment{
+				{Tender: TenderCard, Amount: 25, Reference: "AUTH1"},
+				{Tender: TenderVoucher, Amount: 5, Reference: "GIFT5"},
+				{Tender: TenderCash, Amount: 20, Tendered: 20},
+			},
+		},
+		{
+			name:    "split tenders with change on the cash",
+			balance: 33.3,
+			tenders: []models.Payment{card(20), cash(15)},
+			want: []models.Payment{
+				{Tender: TenderCard, Amount: 20, Reference: "AUTH1"},
+				{Tender: TenderCash, Amount: 13.3, Tendered: 15, Change: 1.7},
+			},
+			change: 1.7,
+		},
+		{
+			name:    "amounts are rounded to cents",
+			balance: 9.999,
+			tenders: []models.Payment{card(10.004)},
+			want:    []models.Payment{{Tender: TenderCard, Amount: 10, Reference: "AUTH1"}},
+		},
+		{name: "card overpay", balance: 20, tenders: []models.Payment{card(25)}, wantErr: true},
+		{name: "voucher overpay", balance: 20, tenders: []models.Payment{voucher(25, "GIFT25")}, wantErr: true},
+		{name: "card and voucher overpay together", balance: 20, tenders: []models.Payment{card(15), voucher(10, "GIFT10")}, wantErr: true},
+		{name: "cash after the invoice is settled", balance: 20, tenders: []models.Payment{card(20), cash(5)}, wantErr: true},
+		{name: "voucher without code", balance: 20, tenders: []models.Payment{voucher(5, "")}, wantErr: true},
+		{name: "unknown tender", balance: 20, tenders: []models.Payment{{Tender: "cheque", Amount: 20}}, wantErr: true},
+		{name: "zero amount", balance: 20, tenders: []models.Payment{cash(0)}, wantErr: true},
+		{name: "negative amount", balance: 20, tenders: []models.Payment{card(-5)}, wantErr: true},
+		{name: "no tenders", balance: 20, wantErr: true},
+		{name: "nothing due", balance: 0, tenders: []models.Payment{cash(5)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, change, err := Take(tt.balance, tt.tenders)
+			if tt.wantErr {
+				if _, ok := err.(PaymentError); !ok {
+					t.Fatalf("Take() error = %v, want a PaymentError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Take() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Take() = %+v, want %+v", got, tt.want)
+			}
+			if change != tt.change {
+				t.Errorf("change = %v, want %v", change, tt.change)
+			}
+		})
+	}
+}
+
+func TestStatusFor(t *testing.T) {
+	tests := []struct {
+		current  string
+		subTotal float64
+		total    float64
+		paid     float64
+		want     string
+	}{
+		{current: StatusOpen, subTotal: 20, total: 20, paid: 0, want: StatusOpen},
+		{current: StatusOpen, subTotal: 20, total: 20, paid: 10, want: StatusOpen},
+		{current: StatusOpen, subTotal: 20, total: 20, paid: 20, want: StatusPaid},
+		{current: StatusOpen, subTotal: 20, total: 20, paid: 19.999, want: StatusPaid},
+		//a paid invoice whose total went up is open again
+		{current: StatusPaid, subTotal: 25, total: 25, paid: 20, want: StatusOpen},
+		//an invoice without items stays open until something is billed on it
+		{current: StatusOpen, subTotal: 0, total: 0, paid: 0, want: StatusOpen},
+		//an invoice discounted to nothing by a coupon or a reward has nothing left to pay
+		{current: StatusOpen, subTotal: 20, total: 0, paid: 0, want: StatusPaid},
+		{current: StatusOpen, subTotal: 20, total: 0.004, paid: 0, want: StatusPaid},
+		{current: StatusOpen, subTotal: 20, total: 0.01, paid: 0, want: StatusOpen},
+		{current: StatusVoid, subTotal: 20, total: 20, paid: 20, want: StatusVoid},
+		{current: StatusRefunded, subTotal: 20, total: 20, paid: 20, want: StatusRefunded},
+	}
+	for _, tt := range tests {
+		if got := StatusFor(tt.current, tt.subTotal, tt.total, tt.paid); got != tt.want {
+			t.Errorf("StatusFor(%q, %v, %v, %v) = %q, want %q", tt.current, tt.subTotal, tt.total, tt.paid, got, tt.want)
+		}
+	}
+}
+
+func TestBalance(t *testing.T) {
+	tests := []struct {
+		status string
+		total  float64
+		paid   float64
+		want   float64
+	}{
+		{status: StatusOpen, total: 33.3, paid: 20, want: 13.3},
+		{status: StatusOpen, total: 20, paid: 0, want: 20},
+		{status: StatusPaid, total: 20, paid: 20, want: 0},
+		{status: StatusOpen, total: 20, paid: 25, want: 0},
+		{status: StatusVoid, total: 20, paid: 0, want: 0},
+		{status: StatusRefunded, total: 20, paid: 20, want: 0},
+	}
+	for _, tt := range tests {
+		if got := Balance(tt.status, tt.total, tt.paid); got != tt.want {
+			t.Errorf("Balance(%q, %v, %v) = %v, want %v", tt.status, tt.total, tt.paid, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTotal(t *testing.T) {
+	tests := []struct {
+		total   float64
+		paid    float64
+		wantErr bool
+	}{
+		{total: 20, paid: 0},
+		{total: 20, paid: 20},
+		{total: 20, paid: 20.001},
+		{total: 15, paid: 20, wantErr: true},
+	}
+	for _, tt := range tests {
+		if err := CheckTotal(tt.total, tt.paid); (err != nil) != tt.wantErr {
+			t.Errorf("CheckTotal(%v, %v) error = %v, want error %v", tt.total, tt.paid, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package pricing
+
+import (
+	"database/sql"
+	"errors"
+	"math"
+
+	"piza_shop_billing/backend/database"
+)
+
+//item kinds known to the catalog
+const (
+	KindPizza    = "pizza"
+	KindTopping  = "topping"
+	KindBeverage = "beverage"
+	KindCombo    = "combo"
+)
+
+//sizes a pizza type can be sold in
+const (
+	SizeSmall  = "small"
+	SizeMedium = "medium"
+	SizeLarge  = "large"
+	SizeCustom = "custom"
+)
+
+//error returned when an item id does not exist in any catalog table
+var ErrUnknownItem = errors.New("unknown item")
+
+//error returned when an item id is found in more than one catalog table and no kind tells them apart
+var ErrAmbiguousItem = errors.New("ambiguous item")
+
+//error returned when a pizza type is not sold in the requested size
+var ErrUnknownSize = errors.New("unknown size")
+
+//Item holds the catalog details of a billable item
+type Item struct {
+	ItemId    string  `json:"item_id"`
+	Kind      string  `json:"kind"`
+	Name      string  `json:"name"`
+	UnitPrice float64 `json:"unit_price"`
+}
+
+//catalog tables in the order they are searched for an item id
+var catalogQueries = []struct {
+	kind  string
+	query string
+}{
+	{KindPizza, "SELECT name, base_price FROM pizza_types WHERE pizza_type_id = ?"},
+	{KindTopping, "SELECT name, price FROM toppings WHERE topping_id = ?"},
+	{KindBeverage, "SELECT name, price FROM beverages WHERE beverage_id = ?"},
+	{KindCombo, "SELECT name, price FROM combos WHERE combo_id = ?"},
+}
+
+//function to resolve an item id to its current catalog price
+func Resolve(db database.DBTX, itemId string) (Item, error) {
+	return ResolveKind(db, "", itemId)
+}
+
+//function to resolve an item id of a given kind to its current catalog price
+//an empty kind searches every catalog table and fails with ErrAmbiguousItem when the id is in more than one
+func ResolveKind(db database.DBTX, kind string, itemId string) (Item, error) {
+	item := Item{ItemId: itemId}
+	if itemId == "" {
+		return item, ErrUnknownItem
+	}
+
+	var found []Item
+	for _, catalog := range catalogQueries {
+		if kind != "" && kind != catalog.kind {
+			continue
+		}
+		match := Item{ItemId: itemId, Kind: catalog.kind}
+		err := db.QueryRow(catalog.query, itemId).Scan(&match.Name, &match.UnitPrice)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return item, err
+		}
+		found = append(found, match)
+	}
+
+	switch len(found) {
+	case 0:
+		return item, ErrUnknownItem
+	case 1:
+		return found[0], nil
+	}
+	return item, ErrAmbiguousItem
+}
+
+//function to check whether a kind is a known catalog item kind
+func ValidKind(kind string) bool {
+	for _, catalog := range catalogQueries {
+		if catalog.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+//function to check whether a size is one of the known pizza sizes
+func ValidSize(size string) bool {
+	switch size {
+	case SizeSmall, SizeMedium, SizeLarge, SizeCustom:
+		return true
+	}
+	return false
+}
+
+//function to resolve the price and topping multiplier of a pizza type in one size
+func ResolveSize(db database.DBTX, pizzaTypeId string, size string) (float64, float64, error) {
+	var price, toppingMultiplier float64
+	query := "SELECT price, topping_multiplier FROM pizza_sizes WHERE pizza_type_id = ? AND size = ?"
+	err := db.QueryRow(query, pizzaTypeId, size).Scan(&price, &toppingMultiplier)
+	if err == sql.ErrNoRows {
+		return 0, 0, ErrUnknownSize
+	}
+	return price, toppingMultiplier, err
+}
+
+//function to price a topping for a pizza size, rounded to cents
+func ToppingPrice(price float64, toppingMultiplier float64) float64 {
+	return math.Round(price*toppingMultiplier*100) / 100
+}
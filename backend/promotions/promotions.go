@@ -0,0 +1,452 @@
+package promotions
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/tax"
+)
+
+//kinds of promotion
+const (
+	KindPercentage = "percentage"
+	KindFixed      = "fixed"
+	KindBuyXGetY   = "buy_x_get_y"
+	KindCombo      = "combo"
+)
+
+//layouts of the happy hour time window and of the moment a promotion is checked at
+const (
+	TimeFormat = "15:04"
+	AtFormat   = "2006-01-02 15:04"
+)
+
+//weekday names accepted in the days of a happy hour schedule
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+//Result holds the discounts taken off an invoice
+//Lines are the negative amounts per tax category, so tax is charged on the discounted amounts
+type Result struct {
+	Discount  float64
+	Discounts []models.InvoiceDiscount
+	Lines     []tax.Line
+}
+
+//function to check whether a kind is a known promotion kind
+func ValidKind(kind string) bool {
+	switch kind {
+	case KindPercentage, KindFixed, KindBuyXGetY, KindCombo:
+		return true
+	}
+	return false
+}
+
+//function to check whether the days of a happy hour schedule are known weekday names
+func ValidDays(days string) bool {
+	for _, day := range splitDays(days) {
+		if _, ok := weekdays[day]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+//function to split a comma separated list of weekdays
+func splitDays(days string) []string {
+	var names []string
+	for _, day := range strings.Split(days, ",") {
+		if day = strings.ToLower(strings.TrimSpace(day)); day != "" {
+			names = append(names, day)
+		}
+	}
+	return names
+}
+
+//function to tell whether a promotion runs at the given moment
+//a time window ending before it starts runs past midnight
+func InEffect(promotion models.Promotion, at time.Time) bool {
+	if !promotion.Active {
+		return false
+	}
+	day := at.Format(tax.DateFormat)
+	if promotion.ValidFrom != "" && day < promotion.ValidFrom {
+		return false
+	}
+	if promotion.ValidTo != "" && day > promotion.ValidTo {
+		return false
+	}
+
+	if days := splitDays(promotion.Days); len(days) > 0 {
+		matched := false
+		for _, name := range days {
+			if weekdays[name] == at.Weekday() {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	clock := at.Format(TimeFormat)
+	start, end := promotion.StartTime, promotion.EndTime
+	switch {
+	case start == "" && end == "":
+		return true
+	case start == "":
+		return clock < end
+	case end == "":
+		return clock >= start
+	case start <= end:
+		return clock >= start && clock < end
+	default:
+		return clock >= start || clock < end
+	}
+}
+
+//line is a top level invoice line with its modifiers folded in
+type line struct {
+	kind     string
+	itemId   string
+	category string
+	quantity int
+	amount   float64
+}
+
+//function to calculate the discounts of the promotions in effect on an invoice
+//promotions with a coupon code only apply when the code is one of coupons,
+//the discounts never take a category below zero
+func Apply(promotions []models.Promotion, items []models.InvoiceItem, coupons []string, at time.Time) Result {
+	var lines []line
+	remaining := map[string]float64{}
+	for _, item := range items {
+		//a combo is discounted in the categories of the items it is made of,
+		//its pizzas and drinks do not count towards pizza or combo deals
+		if len(item.Components) > 0 {
+			for _, part := range tax.ItemLines(item) {
+				lines = append(lines, line{kind: item.ItemKind, itemId: item.ItemId, category: part.Category, quantity: item.Quantity, amount: part.Amount})
+				remaining[part.Category] += part.Amount
+			}
+			continue
+		}
+		l := line{kind: item.ItemKind, itemId: item.ItemId, category: tax.CategoryFor(item.ItemKind), quantity: item.Quantity, amount: item.LineTotal}
+		for _, modifier := range item.Modifiers {
+			l.amount += modifier.LineTotal
+		}
+		lines = append(lines, l)
+		remaining[l.category] += l.amount
+	}
+
+	entered := map[string]bool{}
+	for _, code := range coupons {
+		entered[NormalizeCode(code)] = true
+	}
+
+	//apply the promotions in the order they were created so the result is stable
+	sorted := append([]models.Promotion(nil), promotions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].PromotionId < sorted[j].PromotionId })
+
+	var result Result
+	for _, promotion := range sorted {
+		if promotion.CouponCode != "" && !entered[NormalizeCode(promotion.CouponCode)] {
+			continue
+		}
+		if !InEffect(promotion, at) {
+			continue
+		}
+
+		amount := 0.0
+		for category, share := range discountShares(promotion, lines) {
+			share = math.Min(tax.Round(share), tax.Round(remaining[category]))
+			if share <= 0 {
+				continue
+			}
+			remaining[category] -= share
+			amount += share
+			result.Lines = append(result.Lines, tax.Line{Category: category, Amount: -share})
+		}
+		if amount <= 0 {
+			continue
+		}
+		result.Discount += amount
+		terms := promotion
+		result.Discounts = append(result.Discounts, models.InvoiceDiscount{
+			PromotionId: promotion.PromotionId,
+			Name:        promotion.Name,
+			CouponCode:  promotion.CouponCode,
+			Amount:      tax.Round(amount),
+			Terms:       &terms,
+		})
+	}
+	result.Discount = tax.Round(result.Discount)
+	return result
+}
+
+//function to work out what a promotion takes off each tax category of the invoice lines
+func discountShares(promotion models.Promotion, lines []line) map[string]float64 {
+	shares := map[string]float64{}
+
+	//percentage and fixed promotions cover every line, or only the lines of one pizza type
+	var eligible []line
+	eligibleAmount := 0.0
+	for _, l := range lines {
+		if promotion.PizzaTypeId != "" && (l.kind != pricing.KindPizza || l.itemId != promotion.PizzaTypeId) {
+			continue
+		}
+		eligible = append(eligible, l)
+		eligibleAmount += l.amount
+	}
+
+	switch promotion.Kind {
+	case KindPercentage:
+		for _, l := range eligible {
+			shares[l.category] += l.amount * promotion.Value
+		}
+	case KindFixed:
+		if eligibleAmount <= 0 {
+			break
+		}
+		//the amount is spread over the categories in proportion to what they cost
+		for _, l := range eligible {
+			shares[l.category] += math.Min(promotion.Value, eligibleAmount) * l.amount / eligibleAmount
+		}
+	case KindBuyXGetY:
+		group := promotion.BuyQuantity + promotion.FreeQuantity
+		if promotion.FreeQuantity <= 0 || group <= 0 {
+			break
+		}
+		//the cheapest units of the group are the free ones
+		var units []float64
+		for _, l := range eligible {
+			if l.kind != pricing.KindPizza || l.quantity <= 0 {
+				continue
+			}
+			for i := 0; i < l.quantity; i++ {
+				units = append(units, l.amount/float64(l.quantity))
+			}
+		}
+		sort.Float64s(units)
+		free := len(units) / group * promotion.FreeQuantity
+		for _, unit := range units[:free] {
+			shares[tax.CategoryFood] += unit
+		}
+	case KindCombo:
+		pizzas, beverages := 0, 0
+		pizzaAmount, beverageAmount := 0.0, 0.0
+		for _, l := range lines {
+			switch {
+			case l.kind == pricing.KindPizza && (promotion.PizzaTypeId == "" || l.itemId == promotion.PizzaTypeId):
+				pizzas += l.quantity
+				pizzaAmount += l.amount
+			case l.kind == pricing.KindBeverage && (promotion.BeverageId == "" || l.itemId == promotion.BeverageId):
+				beverages += l.quantity
+				beverageAmount += l.amount
+			}
+		}
+		pairs := pizzas
+		if beverages < pairs {
+			pairs = beverages
+		}
+		if pairs == 0 || pizzaAmount+beverageAmount <= 0 {
+			break
+		}
+		amount := promotion.Value * float64(pairs)
+		shares[tax.CategoryFood] += amount * pizzaAmount / (pizzaAmount + beverageAmount)
+		shares[tax.CategoryBeverage] += amount * beverageAmount / (pizzaAmount + beverageAmount)
+	}
+	return shares
+}
+
+//columns of the promotions table in the order they are scanned
+const columns = "promotion_id, name, kind, value, pizza_type_id, beverage_id, buy_quantity, free_quantity, COALESCE(coupon_code, ''), usage_limit, times_used, valid_from, valid_to, days, start_time, end_time, active"
+
+//function to load every promotion
+func Load(db database.DBTX) ([]models.Promotion, error) {
+	return load(db, "ORDER BY promotion_id")
+}
+
+//function to load one promotion, sql.ErrNoRows is returned when it does not exist
+func Find(db database.DBTX, promotionId string) (models.Promotion, error) {
+	found, err := load(db, "WHERE promotion_id = ?", promotionId)
+	if err != nil {
+		return models.Promotion{}, err
+	}
+	if len(found) == 0 {
+		return models.Promotion{}, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+//function to load the promotions selected by a WHERE or ORDER BY clause
+func load(db database.DBTX, clause string, args ...interface{}) ([]models.Promotion, error) {
+	results, err := db.Query("SELECT "+columns+" FROM promotions "+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var promotions []models.Promotion
+	for results.Next() {
+		var p models.Promotion
+		if err := results.Scan(&p.PromotionId, &p.Name, &p.Kind, &p.Value, &p.PizzaTypeId, &p.BeverageId, &p.BuyQuantity, &p.FreeQuantity, &p.CouponCode, &p.UsageLimit, &p.TimesUsed, &p.ValidFrom, &p.ValidTo, &p.Days, &p.StartTime, &p.EndTime, &p.Active); err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+	return promotions, results.Err()
+}
+
+//function to load the coupon codes entered on an invoice
+func LoadCoupons(db database.DBTX, invoiceId string) ([]string, error) {
+	results, err := db.Query("SELECT coupon_code FROM invoice_coupons WHERE invoice_id = ? ORDER BY coupon_code", invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var codes []string
+	for results.Next() {
+		var code string
+		if err := results.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, results.Err()
+}
+
+//function to replace the discount snapshot stored against an invoice along with the terms of its promotions
+//a promotion that was deleted since it was applied is stored without its id, like the discounts it left behind
+func SaveInvoiceDiscounts(db database.DBTX, invoiceId string, discounts []models.InvoiceDiscount) error {
+	if _, err := db.Exec("DELETE FROM invoice_discounts WHERE invoice_id = ?", invoiceId); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO invoice_discounts (invoice_id, promotion_id, name, coupon_code, amount, terms) VALUES (?, (SELECT promotion_id FROM promotions WHERE promotion_id = ?), ?, ?, ?, ?)"
+	for _, d := range discounts {
+		//loyalty rewards are stored alongside the promotions without one
+		var terms interface{}
+		if d.Terms != nil {
+			encoded, err := json.Marshal(d.Terms)
+			if err != nil {
+				return err
+			}
+			terms = string(encoded)
+		}
+		if _, err := db.Exec(query, invoiceId, d.PromotionId, d.Name, d.CouponCode, d.Amount, terms); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//function to load the terms of the promotions applied to an invoice as they were when they were applied
+func LoadApplied(db database.DBTX, invoiceId string) ([]models.Promotion, error) {
+	results, err := db.Query("SELECT terms FROM invoice_discounts WHERE invoice_id = ? AND terms IS NOT NULL ORDER BY invoice_discount_id", invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var applied []models.Promotion
+	for results.Next() {
+		var terms string
+		if err := results.Scan(&terms); err != nil {
+			return nil, err
+		}
+		var promotion models.Promotion
+		if err := json.Unmarshal([]byte(terms), &promotion); err != nil {
+			return nil, err
+		}
+		applied = append(applied, promotion)
+	}
+	return applied, results.Err()
+}
+
+//function to put the terms a promotion was applied to an invoice with in place of the promotion as it is now
+//so editing or deleting a promotion does not change the invoices it was already applied to
+//the coupon of a deleted promotion is gone from the invoice, it counts as entered since it was checked when it was
+func Freeze(current []models.Promotion, applied []models.Promotion, coupons []string) ([]models.Promotion, []string) {
+	frozen := map[int]models.Promotion{}
+	for _, promotion := range applied {
+		frozen[promotion.PromotionId] = promotion
+	}
+
+	var promos []models.Promotion
+	for _, promotion := range current {
+		if terms, ok := frozen[promotion.PromotionId]; ok {
+			promotion = terms
+			delete(frozen, promotion.PromotionId)
+		}
+		promos = append(promos, promotion)
+	}
+	for _, promotion := range applied {
+		if _, ok := frozen[promotion.PromotionId]; !ok {
+			continue
+		}
+		promos = append(promos, promotion)
+		if promotion.CouponCode != "" {
+			coupons = append(coupons, promotion.CouponCode)
+		}
+	}
+	return promos, coupons
+}
+
+//function to load the discount snapshot stored against an invoice
+func LoadInvoiceDiscounts(db database.DBTX, invoiceId string) ([]models.InvoiceDiscount, error) {
+	query := "SELECT invoice_discount_id, invoice_id, COALESCE(promotion_id, 0), name, coupon_code, amount FROM invoice_discounts WHERE invoice_id = ? ORDER BY invoice_discount_id"
+	results, err := db.Query(query, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var discounts []models.InvoiceDiscount
+	for results.Next() {
+		var d models.InvoiceDiscount
+		if err := results.Scan(&d.InvoiceDiscountId, &d.InvoiceId, &d.PromotionId, &d.Name, &d.CouponCode, &d.Amount); err != nil {
+			return nil, err
+		}
+		discounts = append(discounts, d)
+	}
+	return discounts, results.Err()
+}
+
+//CouponError is returned when a coupon code cannot be entered on an invoice
+type CouponError string
+
+func (e CouponError) Error() string { return string(e) }
+
+//error returned when every use of a coupon has been taken
+var ErrCouponUsedUp = CouponError("coupon has reached its usage limit")
+
+//function to normalise a coupon code, codes are matched without regard to case
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+//function to check that a coupon can be entered on an invoice billed at the given moment
+func CheckCoupon(promotion models.Promotion, at time.Time) error {
+	if !InEffect(promotion, at) {
+		return CouponError("coupon " + promotion.CouponCode + " is not valid at this time")
+	}
+	if promotion.UsageLimit > 0 && promotion.TimesUsed >= promotion.UsageLimit {
+		return ErrCouponUsedUp
+	}
+	return nil
+}
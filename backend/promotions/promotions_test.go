@@ -0,0 +1,284 @@
+package promotions
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/tax"
+)
+
+//a friday evening
+var friday = time.Date(2026, 3, 13, 18, 0, 0, 0, time.UTC)
+
+//function to build an invoice of two pizzas with a topping, another pizza and two beverages
+//32.00 of food and 6.00 of beverages
+func sampleItems() []models.InvoiceItem {
+	return []models.InvoiceItem{
+		{ItemKind: pricing.KindPizza, ItemId: "P1", Quantity: 2, LineTotal: 20, Modifiers: []models.InvoiceItem{
+			{ItemKind: pricing.KindTopping, ItemId: "T1", Quantity: 2, LineTotal: 4},
+		}},
+		{ItemKind: pricing.KindPizza, ItemId: "P2", Quantity: 1, LineTotal: 8},
+		{ItemKind: pricing.KindBeverage, ItemId: "B1", Quantity: 2, LineTotal: 6},
+	}
+}
+
+func TestApply(t *testing.T) {
+	promotion := func(id int, name, kind string, value float64) models.Promotion {
+		return models.Promotion{PromotionId: id, Name: name, Kind: kind, Value: value, Active: true}
+	}
+	with := func(p models.Promotion, change func(*models.Promotion)) models.Promotion {
+		change(&p)
+		return p
+	}
+	tenPercent := promotion(1, "Ten percent", KindPercentage, 0.10)
+
+	tests := []struct {
+		name       string
+		promotions []models.Promotion
+		coupons    []string
+		//amount of every applied discount by name, in the order they were applied
+		want     []string
+		discount float64
+		//discounted amount by tax category
+		lines map[string]float64
+	}{
+		{
+			name:       "percentage of every line",
+			promotions: []models.Promotion{tenPercent},
+			want:       []string{"Ten percent 3.80"},
+			discount:   3.8,
+			lines:      map[string]float64{tax.CategoryFood: -3.2, tax.CategoryBeverage: -0.6},
+		},
+		{
+			name:       "fixed amount spread over the categories",
+			promotions: []models.Promotion{promotion(1, "Five off", KindFixed, 5)},
+			want:       []string{"Five off 5.00"},
+			discount:   5,
+			lines:      map[string]float64{tax.CategoryFood: -4.21, tax.CategoryBeverage: -0.79},
+		},
+		{
+			name:       "fixed amount above the invoice",
+			promotions: []models.Promotion{promotion(1, "Fifty off", KindFixed, 50)},
+			want:       []string{"Fifty off 38.00"},
+			discount:   38,
+			lines:      map[string]float64{tax.CategoryFood: -32, tax.CategoryBeverage: -6},
+		},
+		{
+			name:       "percentage of one pizza type",
+			promotions: []models.Promotion{with(promotion(1, "Half price P2", KindPercentage, 0.5), func(p *models.Promotion) { p.PizzaTypeId = "P2" })},
+			want:       []string{"Half price P2 4.00"},
+			discount:   4,
+			lines:      map[string]float64{tax.CategoryFood: -4},
+		},
+		{
+			name: "buy two get the cheapest free",
+			promotions: []models.Promotion{with(promotion(1, "Three for two", KindBuyXGetY, 0), func(p *models.Promotion) {
+				p.BuyQuantity, p.FreeQuantity = 2, 1
+			})},
+			want:     []string{"Three for two 8.00"},
+			discount: 8,
+			lines:    map[string]float64{tax.CategoryFood: -8},
+		},
+		{
+			name: "buy x get y without enough pizzas",
+			promotions: []models.Promotion{with(promotion(1, "Four for three", KindBuyXGetY, 0), func(p *models.Promotion) {
+				p.BuyQuantity, p.FreeQuantity = 3, 1
+			})},
+		},
+		{
+			name:       "combo for every pizza and beverage pair",
+			promotions: []models.Promotion{promotion(1, "Meal deal", KindCombo, 2)},
+			want:       []string{"Meal deal 4.00"},
+			discount:   4,
+			lines:      map[string]float64{tax.CategoryFood: -3.37, tax.CategoryBeverage: -0.63},
+		},
+		{
+			name:       "coupon not entered",
+			promotions: []models.Promotion{with(promotion(1, "Coupon", KindFixed, 5), func(p *models.Promotion) { p.CouponCode = "SAVE5" })},
+		},
+		{
+			name:       "coupon entered in another case",
+			promotions: []models.Promotion{with(promotion(1, "Coupon", KindFixed, 5), func(p *models.Promotion) { p.CouponCode = "SAVE5" })},
+			coupons:    []string{" save5 "},
+			want:       []string{"Coupon 5.00"},
+			discount:   5,
+			lines:      map[string]float64{tax.CategoryFood: -4.21, tax.CategoryBeverage: -0.79},
+		},
+		{
+			name:       "inactive promotion",
+			promotions: []models.Promotion{with(tenPercent, func(p *models.Promotion) { p.Active = false })},
+		},
+		{
+			name:       "happy hour past midnight",
+			promotions: []models.Promotion{with(tenPercent, func(p *models.Promotion) { p.StartTime, p.EndTime = "17:00", "02:00" })},
+			want:       []string{"Ten percent 3.80"},
+			discount:   3.8,
+			lines:      map[string]float64{tax.CategoryFood: -3.2, tax.CategoryBeverage: -0.6},
+		},
+		{
+			name:       "lunch hour in the evening",
+			promotions: []models.Promotion{with(tenPercent, func(p *models.Promotion) { p.StartTime, p.EndTime = "11:00", "14:00" })},
+		},
+		{
+			name:       "weekend promotion on a friday",
+			promotions: []models.Promotion{with(tenPercent, func(p *models.Promotion) { p.Days = "sat,sun" })},
+		},
+		{
+			name: "stacked promotions never go below zero",
+			promotions: []models.Promotion{
+				promotion(1, "Half price", KindPercentage, 0.5),
+				promotion(2, "Thirty off", KindFixed, 30),
+			},
+			want:     []string{"Half price 19.00", "Thirty off 19.00"},
+			discount: 38,
+			lines:    map[string]float64{tax.CategoryFood: -32, tax.CategoryBeverage: -6},
+		},
+		{
+			name: "stacked promotions apply in the order they were created",
+			promotions: []models.Promotion{
+				promotion(2, "Ten off", KindFixed, 10),
+				with(promotion(1, "Half price P2", KindPercentage, 0.5), func(p *models.Promotion) { p.PizzaTypeId = "P2" }),
+			},
+			want:     []string{"Half price P2 4.00", "Ten off 10.00"},
+			discount: 14,
+			lines:    map[string]float64{tax.CategoryFood: -12.42, tax.CategoryBeverage: -1.58},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Apply(tt.promotions, sampleItems(), tt.coupons, friday)
+
+			var got []string
+			for _, d := range result.Discounts {
+				got = append(got, d.Name+" "+formatAmount(d.Amount))
+				if d.Terms == nil || d.Terms.PromotionId != d.PromotionId {
+					t.Errorf("discount %q does not carry the terms it was applied with", d.Name)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("discounts = %v, want %v", got, tt.want)
+			}
+			if result.Discount != tt.discount {
+				t.Errorf("discount = %v, want %v", result.Discount, tt.discount)
+			}
+			lines := map[string]float64{}
+			for _, l := range result.Lines {
+				lines[l.Category] = tax.Round(lines[l.Category] + l.Amount)
+			}
+			if len(lines) == 0 {
+				lines = nil
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("lines = %v, want %v", lines, tt.lines)
+			}
+		})
+	}
+}
+
+func TestInEffect(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion models.Promotion
+		want      bool
+	}{
+		{name: "always", promotion: models.Promotion{Active: true}, want: true},
+		{name: "inactive", promotion: models.Promotion{}, want: false},
+		{name: "starts tomorrow", promotion: models.Promotion{Active: true, ValidFrom: "2026-03-14"}, want: false},
+		{name: "ended yesterday", promotion: models.Promotion{Active: true, ValidTo: "2026-03-12"}, want: false},
+		{name: "last day", promotion: models.Promotion{Active: true, ValidFrom: "2026-03-01", ValidTo: "2026-03-13"}, want: true},
+		{name: "on fridays", promotion: models.Promotion{Active: true, Days: "Mon, Fri"}, want: true},
+		{name: "on weekends", promotion: models.Promotion{Active: true, Days: "sat,sun"}, want: false},
+		{name: "window", promotion: models.Promotion{Active: true, StartTime: "17:00", EndTime: "19:00"}, want: true},
+		{name: "window ends at the hour", promotion: models.Promotion{Active: true, StartTime: "16:00", EndTime: "18:00"}, want: false},
+		{name: "window starts at the hour", promotion: models.Promotion{Active: true, StartTime: "18:00"}, want: true},
+		{name: "window past midnight", promotion: models.Promotion{Active: true, StartTime: "22:00", EndTime: "02:00"}, want: false},
+		{name: "until the evening", promotion: models.Promotion{Active: true, EndTime: "17:00"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := InEffect(tt.promotion, friday); got != tt.want {
+			t.Errorf("%s: InEffect() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckCoupon(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion models.Promotion
+		want      error
+		invalid   bool
+	}{
+		{name: "no limit", promotion: models.Promotion{Active: true, TimesUsed: 100}},
+		{name: "uses left", promotion: models.Promotion{Active: true, UsageLimit: 3, TimesUsed: 2}},
+		{name: "used up", promotion: models.Promotion{Active: true, UsageLimit: 3, TimesUsed: 3}, want: ErrCouponUsedUp},
+		{name: "expired", promotion: models.Promotion{Active: true, CouponCode: "OLD", ValidTo: "2026-01-31"}, invalid: true},
+	}
+	for _, tt := range tests {
+		err := CheckCoupon(tt.promotion, friday)
+		if tt.invalid {
+			if _, ok := err.(CouponError); !ok || err == ErrCouponUsedUp {
+				t.Errorf("%s: CheckCoupon() error = %v, want the coupon to be refused as not valid", tt.name, err)
+			}
+			continue
+		}
+		if err != tt.want {
+			t.Errorf("%s: CheckCoupon() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	now := models.Promotion{PromotionId: 1, Name: "Ten percent", Kind: KindPercentage, Value: 0.10, Active: true}
+	was := models.Promotion{PromotionId: 1, Name: "Twenty percent", Kind: KindPercentage, Value: 0.20, Active: true}
+	other := models.Promotion{PromotionId: 2, Name: "Meal deal", Kind: KindCombo, Value: 2, Active: true}
+	deleted := models.Promotion{PromotionId: 3, Name: "Five off", Kind: KindFixed, Value: 5, CouponCode: "SAVE5", Active: true}
+	deletedNoCoupon := models.Promotion{PromotionId: 4, Name: "Happy hour", Kind: KindPercentage, Value: 0.15, Active: true}
+
+	tests := []struct {
+		name        string
+		current     []models.Promotion
+		applied     []models.Promotion
+		coupons     []string
+		want        []models.Promotion
+		wantCoupons []string
+	}{
+		{
+			name:    "nothing applied yet",
+			current: []models.Promotion{now, other},
+			want:    []models.Promotion{now, other},
+		},
+		{
+			name:    "edited promotion keeps its applied terms",
+			current: []models.Promotion{now, other},
+			applied: []models.Promotion{was},
+			want:    []models.Promotion{was, other},
+		},
+		{
+			name:        "deleted promotion is kept with its coupon",
+			current:     []models.Promotion{other},
+			applied:     []models.Promotion{deleted, deletedNoCoupon},
+			coupons:     []string{"OTHER"},
+			want:        []models.Promotion{other, deleted, deletedNoCoupon},
+			wantCoupons: []string{"OTHER", "SAVE5"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, coupons := Freeze(tt.current, tt.applied, tt.coupons)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("promotions = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(coupons, tt.wantCoupons) {
+				t.Errorf("coupons = %v, want %v", coupons, tt.wantCoupons)
+			}
+		})
+	}
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
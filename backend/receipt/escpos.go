@@ -0,0 +1,158 @@
+package receipt
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"piza_shop_billing/backend/escpos"
+	"piza_shop_billing/backend/orders"
+	"piza_shop_billing/backend/pricing"
+)
+
+//size of the dots of the invoice QR code
+const qrModuleSize = 6
+
+//function to render a customer receipt as an ESC/POS byte stream
+//the invoice id is printed as a QR code and the drawer is kicked when kickDrawer is set
+func RenderESCPOS(w io.Writer, r Receipt, width int, kickDrawer bool) error {
+	e := escpos.NewEncoder()
+	rule := strings.Repeat("-", width)
+
+	//shop header
+	e.Align(escpos.AlignCenter).Bold(true).DoubleSize(true).Line(truncate(r.Shop.Name, width/2))
+	e.DoubleSize(false).Bold(false)
+	if r.Shop.Address != "" {
+		e.Line(truncate(r.Shop.Address, width))
+	}
+	if r.Shop.Phone != "" {
+		e.Line(truncate("Tel: "+r.Shop.Phone, width))
+	}
+
+	//invoice details
+	e.Align(escpos.AlignLeft).Line(rule)
+	e.Line(fit("Invoice", r.Number(), width))
+	e.Line(fit("Date", r.Invoice.InvoiceDate, width))
+	if r.Invoice.CustomerName != "" {
+		e.Line(fit("Customer", r.Invoice.CustomerName, width))
+	}
+	for _, line := range r.OrderLines() {
+		e.Line(fit(line.Label, line.Value, width))
+	}
+	e.Line(rule)
+
+	//items with their toppings
+	for _, line := range r.Lines() {
+		label := line.Label
+		if line.Indent {
+			label = "  " + label
+		}
+		e.Line(fit(label, Money(line.Amount), width))
+	}
+	e.Line(rule)
+
+	//totals
+	e.Line(fit("Subtotal", Money(r.Invoice.SubTotal), width))
+	for _, line := range r.DiscountLines() {
+		e.Line(fit(line.Label, Money(line.Amount), width))
+	}
+	for _, line := range r.ChargeLines() {
+		e.Line(fit(line.Label, Money(line.Amount), width))
+	}
+	for _, line := range r.TaxLines() {
+		e.Line(fit(line.Label, Money(line.Amount), width))
+	}
+	e.Bold(true).Line(fit("TOTAL", Money(r.Invoice.Total), width)).Bold(false)
+	for _, line := range r.PaymentLines() {
+		e.Line(fit(line.Label, Money(line.Amount), width))
+	}
+	for _, line := range r.CreditLines() {
+		e.Line(fit(line.Label, Money(line.Amount), width))
+	}
+	e.Line(rule)
+	if loyaltyLines := r.LoyaltyLines(); len(loyaltyLines) > 0 {
+		for _, line := range loyaltyLines {
+			e.Line(fit(line.Label, line.Value, width))
+		}
+		e.Line(rule)
+	}
+
+	//footer with the invoice number as a QR code for look ups at the counter
+	e.Align(escpos.AlignCenter).QRCode(r.Number(), qrModuleSize)
+	e.Line("Thank you for your order!")
+	e.Feed(3).Cut()
+	if kickDrawer {
+		e.KickDrawer()
+	}
+
+	_, err := e.WriteTo(w)
+	return err
+}
+
+//function to render a kitchen ticket as an ESC/POS byte stream
+//only pizza lines and their toppings, and the pizzas of combos, are printed without prices
+func RenderKitchenTicket(w io.Writer, r Receipt, width int) error {
+	e := escpos.NewEncoder()
+	rule := strings.Repeat("=", width)
+
+	e.Align(escpos.AlignCenter).Bold(true).DoubleSize(true).Line("KITCHEN")
+	e.Line(truncate(r.Number(), width/2)).DoubleSize(false).Bold(false)
+	e.Line(r.Invoice.InvoiceDate)
+	if r.Invoice.CustomerName != "" {
+		e.Line(truncate(r.Invoice.CustomerName, width))
+	}
+	//the kitchen needs to know where the order goes once it is made
+	if r.Invoice.OrderType != "" {
+		label := strings.ToUpper(orders.Label(r.Invoice.OrderType))
+		if r.Invoice.TableNumber != "" {
+			label += " - TABLE " + r.Invoice.TableNumber
+		}
+		e.Bold(true).Line(truncate(label, width)).Bold(false)
+	}
+	e.Align(escpos.AlignLeft).Line(rule)
+
+	for _, item := range r.Items {
+		//the pizzas of a combo are made like any other pizza
+		if item.ItemKind == pricing.KindCombo {
+			for _, component := range item.Components {
+				if component.ItemKind != pricing.KindPizza {
+					continue
+				}
+				e.Bold(true).DoubleSize(true).Line(truncate(ComponentLabel(item, component), width/2))
+				e.DoubleSize(false).Bold(false)
+				e.Line(truncate("   "+item.Name, width))
+				e.Line(rule)
+			}
+			continue
+		}
+		if item.ItemKind != pricing.KindPizza {
+			continue
+		}
+		e.Bold(true).DoubleSize(true).Line(truncate(lineLabel(item.Quantity, ItemName(item)), width/2))
+		e.DoubleSize(false).Bold(false)
+		for _, modifier := range item.Modifiers {
+			e.Line(truncate("   + "+lineLabel(modifier.Quantity, modifier.Name), width))
+		}
+		if config := item.Configuration; config != nil {
+			for _, choice := range config.Removed {
+				e.Line(truncate("   - NO "+ChoiceName(choice), width))
+			}
+			for _, choice := range config.Added {
+				e.Line(truncate("   + "+ChoiceName(choice), width))
+			}
+		}
+		e.Line(rule)
+	}
+
+	e.Feed(3).Cut()
+	_, err := e.WriteTo(w)
+	return err
+}
+
+//function to label a line with its quantity, a single unit is printed without it
+func lineLabel(quantity int, name string) string {
+	if quantity == 1 {
+		return name
+	}
+	return fmt.Sprintf("%d x %s", quantity, name)
+}
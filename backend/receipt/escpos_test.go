@@ -0,0 +1,134 @@
+package receipt
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/orders"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/tax"
+)
+
+//run go test ./receipt -update to rewrite the golden files after an intended change to the output
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+//function to build a paid dine-in receipt with a pizza and its toppings, a combo and a beverage
+func sampleReceipt() Receipt {
+	return Receipt{
+		Shop: Shop{Name: "Pizza Shop", Address: "1 Main Street", Phone: "555-0100"},
+		Invoice: models.Invoice{
+			InvoiceId:     "42",
+			InvoiceNumber: "COL1-2026-000042",
+			InvoiceDate:   "2026-03-14 12:30",
+			CustomerName:  "Ana",
+			OrderType:     orders.TypeDineIn,
+			TableNumber:   "7",
+			SubTotal:      38.5,
+			Discount:      3.85,
+			Tax:           2.6,
+			Total:         37.25,
+			Status:        payments.StatusPaid,
+			AmountPaid:    37.25,
+			Discounts:     []models.InvoiceDiscount{{Name: "Lunch 10%", CouponCode: "LUNCH", Amount: 3.85}},
+			Taxes:         []models.InvoiceTax{{Name: "VAT", Category: tax.CategoryAll, Rate: 0.075, TaxableAmount: 34.65, TaxAmount: 2.6}},
+			Payments: []models.Payment{
+				{Tender: payments.TenderCard, Amount: 20, Reference: "A1B2"},
+				{Tender: payments.TenderCash, Amount: 17.25, Tendered: 20, Change: 2.75},
+			},
+		},
+		Items: []models.InvoiceItem{
+			{
+				ItemKind: pricing.KindPizza, Name: "Margherita", Size: "large", Quantity: 2, UnitPrice: 11, LineTotal: 22,
+				Modifiers: []models.InvoiceItem{{ItemKind: pricing.KindTopping, Name: "Olives", Quantity: 2, UnitPrice: 1, LineTotal: 2}},
+			},
+			{
+				ItemKind: pricing.KindCombo, Name: "Lunch combo", Quantity: 1, UnitPrice: 12, LineTotal: 12,
+				Components: []models.ComboComponent{
+					{ItemKind: pricing.KindPizza, Name: "Pepperoni", Size: "small", Quantity: 1},
+					{ItemKind: pricing.KindBeverage, Name: "Cola", Quantity: 1},
+				},
+			},
+			{ItemKind: pricing.KindBeverage, Name: "Lemonade", Quantity: 1, UnitPrice: 2.5, LineTotal: 2.5},
+		},
+	}
+}
+
+//function to compare rendered bytes with a golden file, or rewrite the file when -update is set
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s\n got: %q\nwant: %q", path, got, want)
+	}
+}
+
+func TestRenderGolden(t *testing.T) {
+	tests := []struct {
+		golden string
+		render func(*bytes.Buffer, Receipt) error
+	}{
+		{golden: "receipt_80mm.bin", render: func(b *bytes.Buffer, r Receipt) error { return RenderESCPOS(b, r, Width80mm, true) }},
+		{golden: "receipt_58mm.bin", render: func(b *bytes.Buffer, r Receipt) error { return RenderESCPOS(b, r, Width58mm, false) }},
+		{golden: "kitchen_80mm.bin", render: func(b *bytes.Buffer, r Receipt) error { return RenderKitchenTicket(b, r, Width80mm) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := tt.render(&out, sampleReceipt()); err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, tt.golden, out.Bytes())
+		})
+	}
+}
+
+func TestRenderControlBytes(t *testing.T) {
+	//reset and select the PC437 code table
+	start := []byte{0x1B, '@', 0x1B, 't', 0}
+	//feed three lines and partially cut
+	cut := []byte{0x1B, 'd', 3, 0x1D, 'V', 66, 0}
+	kick := []byte{0x1B, 'p', 0, 25, 250}
+
+	tests := []struct {
+		name   string
+		render func(*bytes.Buffer, Receipt) error
+		end    []byte
+	}{
+		{name: "receipt with drawer kick", render: func(b *bytes.Buffer, r Receipt) error { return RenderESCPOS(b, r, Width80mm, true) }, end: append(append([]byte{}, cut...), kick...)},
+		{name: "receipt without drawer kick", render: func(b *bytes.Buffer, r Receipt) error { return RenderESCPOS(b, r, Width80mm, false) }, end: cut},
+		{name: "kitchen ticket", render: func(b *bytes.Buffer, r Receipt) error { return RenderKitchenTicket(b, r, Width80mm) }, end: cut},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := tt.render(&out, sampleReceipt()); err != nil {
+				t.Fatal(err)
+			}
+			got := out.Bytes()
+			if !bytes.HasPrefix(got, start) {
+				t.Errorf("output starts with %q, want %q", got[:len(start)], start)
+			}
+			if !bytes.HasSuffix(got, tt.end) {
+				t.Errorf("output ends with %q, want %q", got[len(got)-len(tt.end):], tt.end)
+			}
+			//the paper is cut once, after everything else is printed
+			if n := bytes.Count(got, []byte{0x1D, 'V'}); n != 1 {
+				t.Errorf("output has %d cut commands, want 1", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package receipt
+
+import (
+	"html/template"
+	"io"
+)
+
+var htmlTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{"money": Money}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: Arial, sans-serif; max-width: 420px; margin: 24px auto; color: #222; }
+header { text-align: center; border-bottom: 1px dashed #999; padding-bottom: 8px; }
+h1 { font-size: 20px; margin: 0 0 4px; }
+table { width: 100%; border-collapse: collapse; margin-top: 8px; }
+td { padding: 2px 0; }
+td.amount { text-align: right; }
+tr.modifier td.label { padding-left: 16px; color: #555; }
+tr.total td { font-weight: bold; border-top: 1px dashed #999; }
+table.loyalty { border-top: 1px dashed #999; }
+footer { text-align: center; margin-top: 16px; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Shop.Name}}</h1>
+{{if .Shop.Address}}<div>{{.Shop.Address}}</div>{{end}}
+{{if .Shop.Phone}}<div>Tel: {{.Shop.Phone}}</div>{{end}}
+</header>
+<table>
+<tr><td>Invoice</td><td class="amount">{{.Number}}</td></tr>
+<tr><td>Date</td><td class="amount">{{.Invoice.InvoiceDate}}</td></tr>
+{{if .Invoice.CustomerName}}<tr><td>Customer</td><td class="amount">{{.Invoice.CustomerName}}</td></tr>{{end}}
+{{range .OrderLines}}<tr><td>{{.Label}}</td><td class="amount">{{.Value}}</td></tr>
+{{end}}</table>
+<table>
+{{range .Lines}}<tr{{if .Indent}} class="modifier"{{end}}><td class="label">{{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}<tr class="total"><td>Subtotal</td><td class="amount">{{money .Invoice.SubTotal}}</td></tr>
+{{range .DiscountLines}}<tr><td>{{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}{{range .ChargeLines}}<tr><td>{{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}{{range .TaxLines}}<tr><td>{{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}<tr class="total"><td>Total</td><td class="amount">{{money .Invoice.Total}}</td></tr>
+{{range .PaymentLines}}<tr><td>{{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}{{range .CreditLines}}<tr><td>{{.Label}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}</table>
+{{with .LoyaltyLines}}<table class="loyalty">
+{{range .}}<tr><td>{{.Label}}</td><td class="amount">{{.Value}}</td></tr>
+{{end}}</table>
+{{end}}<footer>Thank you for your order!</footer>
+</body>
+</html>
+`))
+
+//function to render a receipt as a standalone html page
+func RenderHTML(w io.Writer, r Receipt) error {
+	return htmlTemplate.Execute(w, r)
+}
@@ -0,0 +1,122 @@
+package receipt
+
+import (
+	"io"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+//layout of the pdf receipt in millimetres, sized for an 80mm roll
+const (
+	pdfWidth      = 80.0
+	pdfMargin     = 4.0
+	pdfLineHeight = 5.0
+)
+
+//function to render a receipt as a single page pdf as long as the receipt itself
+func RenderPDF(w io.Writer, r Receipt) error {
+	lines := r.Lines()
+	discountLines := r.DiscountLines()
+	chargeLines := r.ChargeLines()
+	orderLines := r.OrderLines()
+	taxLines := r.TaxLines()
+	paymentLines := r.PaymentLines()
+	creditLines := r.CreditLines()
+	loyaltyLines := r.LoyaltyLines()
+
+	//header, invoice details, items, totals, loyalty points and footer plus some room for the rules
+	rows := 3 + 3 + len(orderLines) + len(lines) + 2 + len(discountLines) + len(chargeLines) + len(taxLines) + 1 + len(paymentLines) + len(creditLines) + len(loyaltyLines)
+	height := float64(rows)*pdfLineHeight + 4*pdfMargin + 12
+
+	pdf := gofpdf.NewCustom(&gofpdf.InitType{
+		UnitStr: "mm",
+		Size:    gofpdf.SizeType{Wd: pdfWidth, Ht: height},
+	})
+	pdf.SetMargins(pdfMargin, pdfMargin, pdfMargin)
+	pdf.SetAutoPageBreak(false, pdfMargin)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	content := pdfWidth - 2*pdfMargin
+
+	//shop header
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(content, pdfLineHeight+1, tr(r.Shop.Name), "", 1, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	if r.Shop.Address != "" {
+		pdf.CellFormat(content, pdfLineHeight, tr(r.Shop.Address), "", 1, "C", false, 0, "")
+	}
+	if r.Shop.Phone != "" {
+		pdf.CellFormat(content, pdfLineHeight, tr("Tel: "+r.Shop.Phone), "", 1, "C", false, 0, "")
+	}
+	rule(pdf, content)
+
+	row := func(label string, amount string, indent bool) {
+		x := pdfMargin
+		width := content * 0.7
+		if indent {
+			x += 3
+			width -= 3
+		}
+		pdf.SetX(x)
+		pdf.CellFormat(width, pdfLineHeight, tr(label), "", 0, "L", false, 0, "")
+		pdf.CellFormat(content*0.3, pdfLineHeight, tr(amount), "", 1, "R", false, 0, "")
+	}
+
+	//invoice details
+	row("Invoice", r.Number(), false)
+	row("Date", r.Invoice.InvoiceDate, false)
+	if r.Invoice.CustomerName != "" {
+		row("Customer", r.Invoice.CustomerName, false)
+	}
+	for _, line := range orderLines {
+		row(line.Label, line.Value, false)
+	}
+	rule(pdf, content)
+
+	//items with their toppings
+	for _, line := range lines {
+		row(line.Label, Money(line.Amount), line.Indent)
+	}
+	rule(pdf, content)
+
+	//totals
+	row("Subtotal", Money(r.Invoice.SubTotal), false)
+	for _, line := range discountLines {
+		row(line.Label, Money(line.Amount), false)
+	}
+	for _, line := range chargeLines {
+		row(line.Label, Money(line.Amount), false)
+	}
+	for _, line := range taxLines {
+		row(line.Label, Money(line.Amount), false)
+	}
+	pdf.SetFont("Helvetica", "B", 9)
+	row("TOTAL", Money(r.Invoice.Total), false)
+	pdf.SetFont("Helvetica", "", 8)
+	for _, line := range paymentLines {
+		row(line.Label, Money(line.Amount), false)
+	}
+	for _, line := range creditLines {
+		row(line.Label, Money(line.Amount), false)
+	}
+	rule(pdf, content)
+	if len(loyaltyLines) > 0 {
+		for _, line := range loyaltyLines {
+			row(line.Label, line.Value, false)
+		}
+		rule(pdf, content)
+	}
+
+	pdf.CellFormat(content, pdfLineHeight, "Thank you for your order!", "", 1, "C", false, 0, "")
+
+	return pdf.Output(w)
+}
+
+//function to draw a dashed separator across the receipt
+func rule(pdf *gofpdf.Fpdf, width float64) {
+	y := pdf.GetY() + 1
+	pdf.SetDashPattern([]float64{1, 1}, 0)
+	pdf.Line(pdfMargin, y, pdfMargin+width, y)
+	pdf.SetDashPattern([]float64{}, 0)
+	pdf.SetY(y + 1)
+}
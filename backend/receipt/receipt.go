@@ -0,0 +1,263 @@
+package receipt
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/orders"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/tax"
+)
+
+//character widths of the supported thermal paper rolls
+const (
+	Width80mm = 48
+	Width58mm = 32
+)
+
+//Shop is the header printed at the top of every receipt
+type Shop struct {
+	Name    string
+	Address string
+	Phone   string
+}
+
+//Receipt holds everything needed to render a printable invoice
+type Receipt struct {
+	Shop    Shop
+	Invoice models.Invoice
+	Items   []models.InvoiceItem
+}
+
+//Line is one priced row of a receipt, modifier rows are indented under their pizza
+type Line struct {
+	Label  string
+	Amount float64
+	Indent bool
+}
+
+//function to read the shop header from the environment
+func ShopFromEnv() Shop {
+	shop := Shop{
+		Name:    os.Getenv("SHOP_NAME"),
+		Address: os.Getenv("SHOP_ADDRESS"),
+		Phone:   os.Getenv("SHOP_PHONE"),
+	}
+	if shop.Name == "" {
+		shop.Name = "Pizza Shop"
+	}
+	return shop
+}
+
+//function to build a receipt for an invoice and its items
+func New(invoice models.Invoice, items []models.InvoiceItem) Receipt {
+	return Receipt{Shop: ShopFromEnv(), Invoice: invoice, Items: items}
+}
+
+//function to return the number printed on a receipt, invoices billed before numbering show their id
+func (r Receipt) Number() string {
+	if r.Invoice.InvoiceNumber != "" {
+		return r.Invoice.InvoiceNumber
+	}
+	return "#" + r.Invoice.InvoiceId
+}
+
+//function to flatten the items of a receipt into printable rows
+func (r Receipt) Lines() []Line {
+	var lines []Line
+	for _, item := range r.Items {
+		if item.Configuration != nil {
+			lines = append(lines, configuredLines(item)...)
+			continue
+		}
+		lines = append(lines, Line{
+			Label:  fmt.Sprintf("%d x %s", item.Quantity, ItemName(item)),
+			Amount: item.LineTotal,
+		})
+		for _, component := range item.Components {
+			lines = append(lines, Line{Label: ComponentLabel(item, component), Indent: true})
+		}
+		for _, modifier := range item.Modifiers {
+			label := "+ " + modifier.Name
+			if modifier.Quantity > 1 {
+				label = fmt.Sprintf("+ %d x %s", modifier.Quantity, modifier.Name)
+			}
+			lines = append(lines, Line{Label: label, Amount: modifier.LineTotal, Indent: true})
+		}
+	}
+	return lines
+}
+
+//function to print a configured pizza as its base pizza followed by the toppings it was built with
+//the rows add up to the line total
+func configuredLines(item models.InvoiceItem) []Line {
+	config := item.Configuration
+	quantity := float64(item.Quantity)
+	lines := []Line{{
+		Label:  fmt.Sprintf("%d x %s", item.Quantity, ItemName(item)),
+		Amount: config.BasePrice * quantity,
+	}}
+	for _, choice := range config.Removed {
+		lines = append(lines, Line{Label: "- no " + ChoiceName(choice), Indent: true})
+	}
+	for _, choice := range config.Added {
+		lines = append(lines, Line{Label: "+ " + ChoiceName(choice), Amount: choice.Price * quantity, Indent: true})
+	}
+	return lines
+}
+
+//function to name a topping of a configured pizza, toppings on one half say which
+func ChoiceName(choice models.ToppingChoice) string {
+	if choice.Placement == "" || choice.Placement == billing.PlacementWhole {
+		return choice.Name
+	}
+	return choice.Name + " (" + choice.Placement + " half)"
+}
+
+//function to describe a component of a combo line, counted for every combo on the line
+//components are covered by the combo price so their rows carry no amount
+func ComponentLabel(item models.InvoiceItem, component models.ComboComponent) string {
+	name := component.Name
+	if component.Size != "" {
+		name += " (" + component.Size + ")"
+	}
+	if quantity := item.Quantity * component.Quantity; quantity > 1 {
+		return fmt.Sprintf("%d x %s", quantity, name)
+	}
+	return name
+}
+
+//function to name a line for printing, sized pizzas carry their size
+func ItemName(item models.InvoiceItem) string {
+	if item.Size == "" {
+		return item.Name
+	}
+	return item.Name + " (" + item.Size + ")"
+}
+
+//function to describe the discount rows of a receipt, printed as negative amounts
+func (r Receipt) DiscountLines() []Line {
+	var lines []Line
+	for _, d := range r.Invoice.Discounts {
+		label := d.Name
+		if d.CouponCode != "" {
+			label += " (" + d.CouponCode + ")"
+		}
+		lines = append(lines, Line{Label: label, Amount: -d.Amount})
+	}
+	return lines
+}
+
+//function to describe the rows added to a receipt by its order type, such as a service charge or the delivery fee
+func (r Receipt) ChargeLines() []Line {
+	var lines []Line
+	for _, c := range r.Invoice.Charges {
+		lines = append(lines, Line{Label: c.Name, Amount: c.Amount})
+	}
+	return lines
+}
+
+//function to describe the tenders a receipt was paid with, the change given back on cash
+//and what is still due on a partly paid invoice
+func (r Receipt) PaymentLines() []Line {
+	var lines []Line
+	for _, p := range r.Invoice.Payments {
+		label := strings.ToUpper(p.Tender[:1]) + p.Tender[1:]
+		if p.Reference != "" {
+			label += " (" + p.Reference + ")"
+		}
+		if p.Tender != payments.TenderCash {
+			lines = append(lines, Line{Label: label, Amount: p.Amount})
+			continue
+		}
+		lines = append(lines, Line{Label: label, Amount: p.Tendered})
+		if p.Change > 0 {
+			lines = append(lines, Line{Label: "Change", Amount: p.Change})
+		}
+	}
+	if len(lines) > 0 && r.Invoice.BalanceDue > 0 {
+		lines = append(lines, Line{Label: "Balance due", Amount: r.Invoice.BalanceDue})
+	}
+	return lines
+}
+
+//function to describe the credit notes issued against the invoice of a receipt, printed as negative amounts
+func (r Receipt) CreditLines() []Line {
+	var lines []Line
+	for _, note := range r.Invoice.CreditNotes {
+		lines = append(lines, Line{Label: "Credit note " + note.CreditNoteNumber + " (" + note.Kind + ")", Amount: note.Total})
+	}
+	return lines
+}
+
+//Detail is an unpriced row of a receipt such as the loyalty points of the customer
+type Detail struct {
+	Label string
+	Value string
+}
+
+//function to describe how the order of a receipt is served, its type with the table or the delivery address
+func (r Receipt) OrderLines() []Detail {
+	if r.Invoice.OrderType == "" {
+		return nil
+	}
+	details := []Detail{{Label: "Order", Value: orders.Label(r.Invoice.OrderType)}}
+	if r.Invoice.TableNumber != "" {
+		details = append(details, Detail{Label: "Table", Value: r.Invoice.TableNumber})
+	}
+	if d := r.Invoice.Delivery; d != nil {
+		details = append(details, Detail{Label: "Deliver to", Value: d.Line1})
+		if d.Line2 != "" {
+			details = append(details, Detail{Value: d.Line2})
+		}
+		if place := strings.TrimSpace(d.PostalCode + " " + d.City); place != "" {
+			details = append(details, Detail{Value: place})
+		}
+		if d.Instructions != "" {
+			details = append(details, Detail{Label: "Notes", Value: d.Instructions})
+		}
+	}
+	return details
+}
+
+//function to describe what the invoice did to the loyalty points of its customer, empty for walk-in customers
+func (r Receipt) LoyaltyLines() []Detail {
+	summary := r.Invoice.Loyalty
+	if summary == nil {
+		return nil
+	}
+	return []Detail{
+		{Label: "Points earned", Value: fmt.Sprint(summary.PointsEarned)},
+		{Label: "Points redeemed", Value: fmt.Sprint(summary.PointsRedeemed)},
+		{Label: "Points balance", Value: fmt.Sprint(summary.Balance)},
+	}
+}
+
+//function to describe the tax rows of a receipt
+func (r Receipt) TaxLines() []Line {
+	var lines []Line
+	for _, t := range r.Invoice.Taxes {
+		label := fmt.Sprintf("%s %s%%", t.Name, formatRate(t.Rate))
+		if t.Category != tax.CategoryAll {
+			label += " (" + t.Category + ")"
+		}
+		if t.Inclusive {
+			label += " incl."
+		}
+		lines = append(lines, Line{Label: label, Amount: t.TaxAmount})
+	}
+	return lines
+}
+
+//function to format a currency amount for printing
+func Money(amount float64) string {
+	return fmt.Sprintf("%.2f", amount)
+}
+
+//function to print a rate such as 0.125 as 12.5
+func formatRate(rate float64) string {
+	return fmt.Sprintf("%g", tax.Round(rate*10000)/100)
+}
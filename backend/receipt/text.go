@@ -0,0 +1,105 @@
+package receipt
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+//function to render a fixed width receipt for thermal printers
+//width is the number of characters per line, see Width80mm and Width58mm
+func RenderText(w io.Writer, r Receipt, width int) error {
+	var b strings.Builder
+	rule := strings.Repeat("-", width) + "\n"
+
+	b.WriteString(center(strings.ToUpper(r.Shop.Name), width))
+	if r.Shop.Address != "" {
+		b.WriteString(center(r.Shop.Address, width))
+	}
+	if r.Shop.Phone != "" {
+		b.WriteString(center("Tel: "+r.Shop.Phone, width))
+	}
+	b.WriteString(rule)
+
+	b.WriteString(leftRight("Invoice", r.Number(), width))
+	b.WriteString(leftRight("Date", r.Invoice.InvoiceDate, width))
+	if r.Invoice.CustomerName != "" {
+		b.WriteString(leftRight("Customer", r.Invoice.CustomerName, width))
+	}
+	for _, line := range r.OrderLines() {
+		b.WriteString(leftRight(line.Label, line.Value, width))
+	}
+	b.WriteString(rule)
+
+	for _, line := range r.Lines() {
+		label := line.Label
+		if line.Indent {
+			label = "  " + label
+		}
+		b.WriteString(leftRight(label, Money(line.Amount), width))
+	}
+	b.WriteString(rule)
+
+	b.WriteString(leftRight("Subtotal", Money(r.Invoice.SubTotal), width))
+	for _, line := range r.DiscountLines() {
+		b.WriteString(leftRight(line.Label, Money(line.Amount), width))
+	}
+	for _, line := range r.ChargeLines() {
+		b.WriteString(leftRight(line.Label, Money(line.Amount), width))
+	}
+	for _, line := range r.TaxLines() {
+		b.WriteString(leftRight(line.Label, Money(line.Amount), width))
+	}
+	b.WriteString(leftRight("TOTAL", Money(r.Invoice.Total), width))
+	for _, line := range r.PaymentLines() {
+		b.WriteString(leftRight(line.Label, Money(line.Amount), width))
+	}
+	for _, line := range r.CreditLines() {
+		b.WriteString(leftRight(line.Label, Money(line.Amount), width))
+	}
+	b.WriteString(rule)
+	if loyaltyLines := r.LoyaltyLines(); len(loyaltyLines) > 0 {
+		for _, line := range loyaltyLines {
+			b.WriteString(leftRight(line.Label, line.Value, width))
+		}
+		b.WriteString(rule)
+	}
+	b.WriteString(center("Thank you for your order!", width))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+//function to center a text on a line of the given width
+func center(text string, width int) string {
+	text = truncate(text, width)
+	padding := (width - utf8.RuneCountInString(text)) / 2
+	return strings.Repeat(" ", padding) + text + "\n"
+}
+
+//function to print a label on the left and a value on the right of the same line
+func leftRight(left string, right string, width int) string {
+	return fit(left, right, width) + "\n"
+}
+
+//function to place left and right at both ends of a line of the given width
+//the left text is shortened when both do not fit
+func fit(left string, right string, width int) string {
+	right = truncate(right, width)
+	room := width - utf8.RuneCountInString(right) - 1
+	if room < 0 {
+		room = 0
+	}
+	left = truncate(left, room)
+	gap := width - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	return left + strings.Repeat(" ", gap) + right
+}
+
+//function to cut a text down to at most width characters
+func truncate(text string, width int) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:width])
+}
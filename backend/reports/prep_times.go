@@ -0,0 +1,102 @@
+package reports
+
+import (
+	"database/sql"
+	"sort"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/tax"
+)
+
+//PrepTime is how long the kitchen took over the tickets of a station or of a pizza in the report period
+type PrepTime struct {
+	StationId int    `json:"station_id,omitempty"`
+	Station   string `json:"station,omitempty"`
+	ItemId    string `json:"item_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Size      string `json:"size,omitempty"`
+	Tickets   int    `json:"tickets"`
+	Pizzas    int    `json:"pizzas"`
+	//average seconds a ticket waited before it was started and took from queued to ready
+	AverageWait float64 `json:"average_wait_seconds"`
+	AveragePrep float64 `json:"average_prep_seconds"`
+	LongestPrep int     `json:"longest_prep_seconds"`
+
+	wait int
+	prep int
+}
+
+//PrepTimeReport summarises the prep times of the tickets made ready in a period, overall, per station and per pizza
+type PrepTimeReport struct {
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	Overall  PrepTime   `json:"overall"`
+	Stations []PrepTime `json:"stations"`
+	Items    []PrepTime `json:"items"`
+}
+
+//function to build the prep time report for the tickets made ready from..to inclusive, in local time
+//tickets without a station are reported under station 0, cancelled tickets are left out
+func PrepTimes(db *sql.DB, from string, to string) (PrepTimeReport, error) {
+	report := PrepTimeReport{From: from, To: to, Stations: []PrepTime{}, Items: []PrepTime{}}
+
+	query := `SELECT COALESCE(t.station_id, 0), COALESCE(s.name, ''), t.item_id, t.name, t.size, t.quantity, t.wait_seconds, t.prep_seconds
+		FROM kitchen_tickets t
+		LEFT JOIN kitchen_stations s ON s.station_id = t.station_id
+		WHERE t.ready_at IS NOT NULL AND t.status <> 'cancelled' AND ` + database.Current.FormatDate("t.ready_at") + ` BETWEEN ? AND ?`
+	results, err := db.Query(query, from, to)
+	if err != nil {
+		return report, err
+	}
+	defer results.Close()
+
+	stations := map[int]*PrepTime{}
+	items := map[string]*PrepTime{}
+	for results.Next() {
+		var stationId, quantity, wait, prep int
+		var station, itemId, name, size string
+		if err := results.Scan(&stationId, &station, &itemId, &name, &size, &quantity, &wait, &prep); err != nil {
+			return report, err
+		}
+
+		if _, ok := stations[stationId]; !ok {
+			stations[stationId] = &PrepTime{StationId: stationId, Station: station}
+		}
+		key := itemId + "|" + size
+		if _, ok := items[key]; !ok {
+			items[key] = &PrepTime{ItemId: itemId, Name: name, Size: size}
+		}
+		for _, entry := range []*PrepTime{&report.Overall, stations[stationId], items[key]} {
+			entry.Tickets++
+			entry.Pizzas += quantity
+			entry.wait += wait
+			entry.prep += prep
+			if prep > entry.LongestPrep {
+				entry.LongestPrep = prep
+			}
+		}
+	}
+	if err := results.Err(); err != nil {
+		return report, err
+	}
+
+	report.Overall = averaged(report.Overall)
+	for _, entry := range stations {
+		report.Stations = append(report.Stations, averaged(*entry))
+	}
+	for _, entry := range items {
+		report.Items = append(report.Items, averaged(*entry))
+	}
+	sort.Slice(report.Stations, func(i, j int) bool { return report.Stations[i].StationId < report.Stations[j].StationId })
+	sort.Slice(report.Items, func(i, j int) bool { return report.Items[i].AveragePrep > report.Items[j].AveragePrep })
+	return report, nil
+}
+
+//function to turn the summed times of an entry into averages per ticket
+func averaged(entry PrepTime) PrepTime {
+	if entry.Tickets > 0 {
+		entry.AverageWait = tax.Round(float64(entry.wait) / float64(entry.Tickets))
+		entry.AveragePrep = tax.Round(float64(entry.prep) / float64(entry.Tickets))
+	}
+	return entry
+}
@@ -0,0 +1,183 @@
+package reports
+
+import (
+	"database/sql"
+	"sort"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/tax"
+)
+
+//ItemSales is the quantity and revenue of one catalog item over the report period
+//each size of a pizza is reported separately
+type ItemSales struct {
+	ItemKind string  `json:"item_kind"`
+	ItemId   string  `json:"item_id"`
+	Name     string  `json:"name"`
+	Size     string  `json:"size,omitempty"`
+	Quantity int     `json:"quantity"`
+	Revenue  float64 `json:"revenue"`
+	//revenue of the toppings added to this pizza, on top of its own revenue
+	ModifierRevenue float64 `json:"modifier_revenue,omitempty"`
+}
+
+//SalesReport summarises the invoices of a period, the totals are net of the credit notes issued in the period
+type SalesReport struct {
+	From            string      `json:"from"`
+	To              string      `json:"to"`
+	InvoiceCount    int         `json:"invoice_count"`
+	CreditNoteCount int         `json:"credit_note_count"`
+	//total of the credit notes, negative
+	Credited float64     `json:"credited"`
+	SubTotal float64     `json:"subtotal"`
+	Tax      float64     `json:"tax"`
+	Total    float64     `json:"total"`
+	Items    []ItemSales `json:"items"`
+}
+
+//function to build the sales report for the invoices dated from..to inclusive
+//toppings are counted once per unit of the pizza they were added to, and the credit notes issued
+//in the period count as negative amounts and quantities on the local day they were issued
+func Sales(db *sql.DB, from string, to string) (SalesReport, error) {
+	report := SalesReport{From: from, To: to, Items: []ItemSales{}}
+
+	query := "SELECT COUNT(*), COALESCE(SUM(subtotal),0), COALESCE(SUM(tax),0), COALESCE(SUM(total),0) FROM invoices WHERE DATE(invoice_date) BETWEEN ? AND ?"
+	if err := db.QueryRow(query, from, to).Scan(&report.InvoiceCount, &report.SubTotal, &report.Tax, &report.Total); err != nil {
+		return report, err
+	}
+
+	var creditSubTotal, creditTax float64
+	query = "SELECT COUNT(*), COALESCE(SUM(subtotal),0), COALESCE(SUM(tax),0), COALESCE(SUM(total),0) FROM credit_notes WHERE " + database.Current.FormatDate("created_at") + " BETWEEN ? AND ?"
+	if err := db.QueryRow(query, from, to).Scan(&report.CreditNoteCount, &creditSubTotal, &creditTax, &report.Credited); err != nil {
+		return report, err
+	}
+	report.Credited = tax.Round(report.Credited)
+	report.SubTotal = tax.Round(report.SubTotal + creditSubTotal)
+	report.Tax = tax.Round(report.Tax + creditTax)
+	report.Total = tax.Round(report.Total + report.Credited)
+
+	query = `SELECT ii.invoice_item_id, ii.invoice_id, ii.parent_item_id, ii.item_kind, ii.item_id, ii.item_name, ii.size, ii.quantity, ii.unit_price, ii.configuration, ii.components
+		FROM invoice_items ii
+		INNER JOIN invoices i ON i.invoice_id = ii.invoice_id
+		WHERE DATE(i.invoice_date) BETWEEN ? AND ?
+		ORDER BY ii.invoice_id, ii.invoice_item_id`
+	results, err := db.Query(query, from, to)
+	if err != nil {
+		return report, err
+	}
+	defer results.Close()
+
+	items, err := billing.ScanItems(results)
+	if err != nil {
+		return report, err
+	}
+
+	//build the line hierarchy per invoice so modifiers are multiplied by their own pizza
+	byInvoice := map[int][]models.InvoiceItem{}
+	for _, item := range items {
+		byInvoice[item.InvoiceId] = append(byInvoice[item.InvoiceId], item)
+	}
+
+	sales := map[string]*ItemSales{}
+	add := func(item models.InvoiceItem, quantity int) *ItemSales {
+		key := item.ItemKind + "|" + item.ItemId + "|" + item.Size
+		entry, ok := sales[key]
+		if !ok {
+			entry = &ItemSales{ItemKind: item.ItemKind, ItemId: item.ItemId, Name: item.Name, Size: item.Size}
+			sales[key] = entry
+		}
+		entry.Quantity += quantity
+		entry.Revenue += item.LineTotal
+		return entry
+	}
+
+	for _, invoiceItems := range byInvoice {
+		for _, line := range billing.BuildTree(invoiceItems) {
+			entry := add(line, line.Quantity)
+			for _, modifier := range line.Modifiers {
+				add(modifier, line.Quantity*modifier.Quantity)
+				entry.ModifierRevenue += modifier.LineTotal
+			}
+		}
+	}
+
+	if err := addCredits(db, from, to, add); err != nil {
+		return report, err
+	}
+
+	for _, entry := range sales {
+		entry.Revenue = tax.Round(entry.Revenue)
+		entry.ModifierRevenue = tax.Round(entry.ModifierRevenue)
+		report.Items = append(report.Items, *entry)
+	}
+	sort.Slice(report.Items, func(i, j int) bool {
+		if report.Items[i].ItemKind != report.Items[j].ItemKind {
+			return report.Items[i].ItemKind < report.Items[j].ItemKind
+		}
+		return report.Items[i].Revenue > report.Items[j].Revenue
+	})
+	return report, nil
+}
+
+//function to take the lines credited in the period off the item sales, a credited pizza takes its toppings with it
+func addCredits(db *sql.DB, from string, to string, add func(item models.InvoiceItem, quantity int) *ItemSales) error {
+	query := `SELECT cn.invoice_id, cni.invoice_item_id, cni.quantity
+		FROM credit_note_items cni
+		INNER JOIN credit_notes cn ON cn.credit_note_id = cni.credit_note_id
+		WHERE ` + database.Current.FormatDate("cn.created_at") + ` BETWEEN ? AND ?
+		ORDER BY cni.credit_note_item_id`
+	results, err := db.Query(query, from, to)
+	if err != nil {
+		return err
+	}
+	type credit struct {
+		invoiceId     string
+		invoiceItemId int
+		quantity      int
+	}
+	var credits []credit
+	for results.Next() {
+		var c credit
+		if err := results.Scan(&c.invoiceId, &c.invoiceItemId, &c.quantity); err != nil {
+			results.Close()
+			return err
+		}
+		credits = append(credits, c)
+	}
+	results.Close()
+	if err := results.Err(); err != nil {
+		return err
+	}
+
+	//the credited lines are read from the invoices they were billed on, which may be outside the period
+	lines := map[string]map[int]models.InvoiceItem{}
+	for _, c := range credits {
+		if _, ok := lines[c.invoiceId]; !ok {
+			items, err := billing.LoadItems(db, c.invoiceId)
+			if err != nil {
+				return err
+			}
+			lines[c.invoiceId] = map[int]models.InvoiceItem{}
+			for _, item := range items {
+				lines[c.invoiceId][item.InvoiceItemId] = item
+			}
+		}
+		line, ok := lines[c.invoiceId][c.invoiceItemId]
+		if !ok || line.Quantity == 0 {
+			continue
+		}
+
+		share := float64(c.quantity) / float64(line.Quantity)
+		credited := line
+		credited.LineTotal = -line.LineTotal * share
+		entry := add(credited, -c.quantity)
+		for _, modifier := range line.Modifiers {
+			modifier.LineTotal = -modifier.LineTotal * share
+			add(modifier, -c.quantity*modifier.Quantity)
+			entry.ModifierRevenue += modifier.LineTotal
+		}
+	}
+	return nil
+}
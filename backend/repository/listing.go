@@ -0,0 +1,89 @@
+package repository
+
+import "piza_shop_billing/backend/listing"
+
+//fields the invoice list can be filtered and sorted on, newest invoices come first
+var InvoiceListing = listing.Schema{
+	Fields: map[string]listing.Field{
+		"invoice_id":     {Column: "invoice_id", Type: listing.TypeNumber},
+		"invoice_number": {Column: "COALESCE(invoice_number, '')", Type: listing.TypeString, Search: true},
+		"invoice_date":   {Column: "invoice_date", Type: listing.TypeDate},
+		"customer_name":  {Column: "customer_name", Type: listing.TypeString, Search: true},
+		"customer_id":    {Column: "COALESCE(customer_id, 0)", Type: listing.TypeNumber},
+		"order_type":     {Column: "order_type", Type: listing.TypeString},
+		"table_number":   {Column: "table_number", Type: listing.TypeString},
+		"status":         {Column: "status", Type: listing.TypeString},
+		"subtotal":       {Column: "subtotal", Type: listing.TypeNumber},
+		"discount":       {Column: "discount", Type: listing.TypeNumber},
+		"charge":         {Column: "charge", Type: listing.TypeNumber},
+		"tax":            {Column: "tax", Type: listing.TypeNumber},
+		"total":          {Column: "total", Type: listing.TypeNumber},
+		"amount_paid":    {Column: "amount_paid", Type: listing.TypeNumber},
+	},
+	Key:  "invoice_id",
+	Sort: "-invoice_id",
+}
+
+//fields the pizza type list can be filtered and sorted on
+var PizzaListing = listing.Schema{
+	Fields: map[string]listing.Field{
+		"pizza_type_id": {Column: "pizza_type_id", Type: listing.TypeString},
+		"name":          {Column: "name", Type: listing.TypeString, Search: true},
+		"size":          {Column: "size", Type: listing.TypeString},
+		"base_price":    {Column: "base_price", Type: listing.TypeNumber},
+		"description":   {Column: "description", Type: listing.TypeString, Search: true},
+	},
+	Key:  "pizza_type_id",
+	Sort: "pizza_type_id",
+}
+
+//fields the topping list can be filtered and sorted on
+var ToppingListing = listing.Schema{
+	Fields: map[string]listing.Field{
+		"topping_id": {Column: "topping_id", Type: listing.TypeString},
+		"name":       {Column: "name", Type: listing.TypeString, Search: true},
+		"price":      {Column: "price", Type: listing.TypeNumber},
+	},
+	Key:  "topping_id",
+	Sort: "topping_id",
+}
+
+//fields the beverage list can be filtered and sorted on
+var BeverageListing = listing.Schema{
+	Fields: map[string]listing.Field{
+		"beverage_id": {Column: "beverage_id", Type: listing.TypeString},
+		"name":        {Column: "name", Type: listing.TypeString, Search: true},
+		"price":       {Column: "price", Type: listing.TypeNumber},
+	},
+	Key:  "beverage_id",
+	Sort: "beverage_id",
+}
+
+//fields the customer directory can be filtered and sorted on, q finds customers by name, phone or email
+var CustomerListing = listing.Schema{
+	Fields: map[string]listing.Field{
+		"customer_id": {Column: "customer_id", Type: listing.TypeNumber},
+		"name":        {Column: "name", Type: listing.TypeString, Search: true},
+		"phone":       {Column: "COALESCE(phone, '')", Type: listing.TypeString, Search: true},
+		"email":       {Column: "email", Type: listing.TypeString, Search: true},
+		"created_at":  {Column: "created_at", Type: listing.TypeDate},
+	},
+	Key:  "customer_id",
+	Sort: "name",
+}
+
+//fields the loyalty ledger can be filtered and sorted on, newest entries come first
+var LoyaltyLedgerListing = listing.Schema{
+	Fields: map[string]listing.Field{
+		"entry_id":    {Column: "entry_id", Type: listing.TypeNumber},
+		"customer_id": {Column: "customer_id", Type: listing.TypeNumber},
+		"invoice_id":  {Column: "COALESCE(invoice_id, 0)", Type: listing.TypeNumber},
+		"kind":        {Column: "kind", Type: listing.TypeString},
+		"points":      {Column: "points", Type: listing.TypeNumber},
+		"description": {Column: "description", Type: listing.TypeString, Search: true},
+		"created_by":  {Column: "created_by", Type: listing.TypeString},
+		"created_at":  {Column: "created_at", Type: listing.TypeDate},
+	},
+	Key:  "entry_id",
+	Sort: "-entry_id",
+}
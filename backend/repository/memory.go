@@ -0,0 +1,15 @@
+package repository
+
+import "piza_shop_billing/backend/database"
+
+//function to return repositories that keep everything in memory, for handler tests and demos
+//they are the SQL repositories over a private in-memory SQLite database, so invoices are billed, paid,
+//credited and reported by the same code as on MySQL, close drops everything that was stored
+//the SQLite dialect is selected for the whole process, so they cannot run next to MySQL repositories
+func NewMemory() (repos Repositories, close func() error, err error) {
+	db, err := database.OpenMemory()
+	if err != nil {
+		return repos, nil, err
+	}
+	return NewSQL(db), db.Close, nil
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"strconv"
+
+	"piza_shop_billing/backend/events"
+	"piza_shop_billing/backend/models"
+)
+
+//function to wrap repositories so the changes to invoices, their items and payments and the kitchen tickets
+//are published to broker once they are stored, reads go straight to the wrapped repositories
+func Publishing(repos Repositories, broker *events.Broker) Repositories {
+	repos.Invoices = &publishingInvoiceRepository{InvoiceRepository: repos.Invoices, kitchen: repos.Kitchen, broker: broker}
+	repos.Loyalty = &publishingLoyaltyRepository{LoyaltyRepository: repos.Loyalty, broker: broker}
+	repos.Kitchen = &publishingKitchenRepository{KitchenRepository: repos.Kitchen, broker: broker}
+	return repos
+}
+
+type publishingInvoiceRepository struct {
+	InvoiceRepository
+	kitchen KitchenRepository
+	broker  *events.Broker
+}
+
+func (r *publishingInvoiceRepository) Create(invoice models.Invoice, items []models.InvoiceItem) (models.Invoice, []models.InvoiceItem, error) {
+	invoice, items, err := r.InvoiceRepository.Create(invoice, items)
+	if err != nil {
+		return invoice, items, err
+	}
+	r.broker.Publish(events.TopicInvoices, "invoice.created", invoice)
+	for _, item := range items {
+		r.broker.Publish(events.TopicItems, "item.added", item)
+	}
+	if len(invoice.Payments) > 0 {
+		r.broker.Publish(events.TopicPayments, "payment.received", paymentEvent{InvoiceId: invoice.InvoiceId, Payments: invoice.Payments, Invoice: invoice})
+	}
+	r.ticketsChanged(invoice.InvoiceId)
+	return invoice, items, nil
+}
+
+func (r *publishingInvoiceRepository) UpdateCustomer(invoiceId string, customerId int, customerName string) (models.Invoice, error) {
+	invoice, err := r.InvoiceRepository.UpdateCustomer(invoiceId, customerId, customerName)
+	if err == nil {
+		r.broker.Publish(events.TopicInvoices, "invoice.updated", invoice)
+	}
+	return invoice, err
+}
+
+func (r *publishingInvoiceRepository) UpdateOrder(invoiceId string, orderType string, tableNumber string, delivery *models.Delivery) (models.Invoice, error) {
+	invoice, err := r.InvoiceRepository.UpdateOrder(invoiceId, orderType, tableNumber, delivery)
+	if err == nil {
+		r.broker.Publish(events.TopicInvoices, "invoice.updated", invoice)
+		//the kitchen screens show where each order goes
+		r.ticketsChanged(invoiceId)
+	}
+	return invoice, err
+}
+
+func (r *publishingInvoiceRepository) ApplyCoupon(invoiceId string, code string) (models.Invoice, error) {
+	invoice, err := r.InvoiceRepository.ApplyCoupon(invoiceId, code)
+	if err == nil {
+		r.broker.Publish(events.TopicInvoices, "invoice.updated", invoice)
+	}
+	return invoice, err
+}
+
+func (r *publishingInvoiceRepository) RemoveCoupon(invoiceId string, code string) (models.Invoice, error) {
+	invoice, err := r.InvoiceRepository.RemoveCoupon(invoiceId, code)
+	if err == nil {
+		r.broker.Publish(events.TopicInvoices, "invoice.updated", invoice)
+	}
+	return invoice, err
+}
+
+func (r *publishingInvoiceRepository) Pay(invoiceId string, tenders []models.Payment) (models.Invoice, []models.Payment, float64, error) {
+	invoice, taken, change, err := r.InvoiceRepository.Pay(invoiceId, tenders)
+	if err != nil {
+		return invoice, taken, change, err
+	}
+	r.broker.Publish(events.TopicPayments, "payment.received", paymentEvent{InvoiceId: invoiceId, Payments: taken, Change: change, Invoice: invoice})
+	r.broker.Publish(events.TopicInvoices, "invoice.updated", invoice)
+	return invoice, taken, change, nil
+}
+
+func (r *publishingInvoiceRepository) Void(invoiceId string, request models.CreditNote) (models.Invoice, models.CreditNote, error) {
+	invoice, note, err := r.InvoiceRepository.Void(invoiceId, request)
+	if err != nil {
+		return invoice, note, err
+	}
+	r.broker.Publish(events.TopicInvoices, "invoice.voided", creditEvent{Invoice: invoice, CreditNote: note})
+	r.ticketsChanged(invoiceId)
+	return invoice, note, nil
+}
+
+func (r *publishingInvoiceRepository) Refund(invoiceId string, request models.CreditNote) (models.Invoice, models.CreditNote, error) {
+	invoice, note, err := r.InvoiceRepository.Refund(invoiceId, request)
+	if err == nil {
+		r.broker.Publish(events.TopicInvoices, "invoice.refunded", creditEvent{Invoice: invoice, CreditNote: note})
+	}
+	return invoice, note, err
+}
+
+func (r *publishingInvoiceRepository) AddItem(invoiceId string, item models.InvoiceItem) (models.InvoiceItem, error) {
+	item, err := r.InvoiceRepository.AddItem(invoiceId, item)
+	if err == nil {
+		r.broker.Publish(events.TopicItems, "item.added", item)
+		r.itemsChanged(invoiceId)
+	}
+	return item, err
+}
+
+func (r *publishingInvoiceRepository) UpdateItem(invoiceItemId string, update models.InvoiceItem) (models.InvoiceItem, error) {
+	item, err := r.InvoiceRepository.UpdateItem(invoiceItemId, update)
+	if err == nil {
+		r.broker.Publish(events.TopicItems, "item.updated", item)
+		r.itemsChanged(strconv.Itoa(item.InvoiceId))
+	}
+	return item, err
+}
+
+func (r *publishingInvoiceRepository) DeleteItem(invoiceItemId string) (string, error) {
+	invoiceId, err := r.InvoiceRepository.DeleteItem(invoiceItemId)
+	if err == nil {
+		id, _ := strconv.Atoi(invoiceItemId)
+		r.broker.Publish(events.TopicItems, "item.deleted", deletedItemEvent{InvoiceId: invoiceId, InvoiceItemId: id})
+		r.itemsChanged(invoiceId)
+	}
+	return invoiceId, err
+}
+
+//method to publish the new totals and kitchen tickets of an invoice whose items changed
+func (r *publishingInvoiceRepository) itemsChanged(invoiceId string) {
+	if invoice, err := r.InvoiceRepository.Get(invoiceId); err == nil {
+		r.broker.Publish(events.TopicInvoices, "invoice.updated", invoice)
+	}
+	r.ticketsChanged(invoiceId)
+}
+
+//method to publish the kitchen tickets of an invoice after they followed a change to it
+func (r *publishingInvoiceRepository) ticketsChanged(invoiceId string) {
+	tickets, err := r.kitchen.InvoiceTickets(invoiceId)
+	if err != nil || len(tickets) == 0 {
+		return
+	}
+	r.broker.Publish(events.TopicKitchen, "tickets.updated", ticketsEvent{InvoiceId: invoiceId, Tickets: tickets})
+}
+
+type publishingLoyaltyRepository struct {
+	LoyaltyRepository
+	broker *events.Broker
+}
+
+func (r *publishingLoyaltyRepository) Redeem(invoiceId string, request models.LoyaltyRedemption) (models.Invoice, models.LoyaltyRedemption, error) {
+	invoice, redemption, err := r.LoyaltyRepository.Redeem(invoiceId, request)
+	if err == nil {
+		r.broker.Publish(events.TopicInvoices, "invoice.updated", invoice)
+	}
+	return invoice, redemption, err
+}
+
+func (r *publishingLoyaltyRepository) CancelRedemption(invoiceId string, redemptionId string) (models.Invoice, error) {
+	invoice, err := r.LoyaltyRepository.CancelRedemption(invoiceId, redemptionId)
+	if err == nil {
+		r.broker.Publish(events.TopicInvoices, "invoice.updated", invoice)
+	}
+	return invoice, err
+}
+
+type publishingKitchenRepository struct {
+	KitchenRepository
+	broker *events.Broker
+}
+
+func (r *publishingKitchenRepository) UpdateStatus(ticketId string, status string) (models.KitchenTicket, error) {
+	ticket, err := r.KitchenRepository.UpdateStatus(ticketId, status)
+	if err == nil {
+		r.broker.Publish(events.TopicKitchen, "ticket.updated", ticket)
+	}
+	return ticket, err
+}
+
+//data of a payment event, the invoice carries its new status and balance
+type paymentEvent struct {
+	InvoiceId string           `json:"invoice_id"`
+	Payments  []models.Payment `json:"payments"`
+	Change    float64          `json:"change"`
+	Invoice   models.Invoice   `json:"invoice"`
+}
+
+//data of a void or refund event
+type creditEvent struct {
+	Invoice    models.Invoice    `json:"invoice"`
+	CreditNote models.CreditNote `json:"credit_note"`
+}
+
+//data of an item deleted event, the item itself is gone
+type deletedItemEvent struct {
+	InvoiceId     string `json:"invoice_id"`
+	InvoiceItemId int    `json:"invoice_item_id"`
+}
+
+//data of a kitchen event for the tickets of one invoice
+type ticketsEvent struct {
+	InvoiceId string                 `json:"invoice_id"`
+	Tickets   []models.KitchenTicket `json:"tickets"`
+}
@@ -0,0 +1,271 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/listing"
+	"piza_shop_billing/backend/loyalty"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/reports"
+)
+
+//error returned when the requested record does not exist
+var ErrNotFound = errors.New("not found")
+
+//error returned when a record with the same id already exists
+var ErrDuplicate = errors.New("already exists")
+
+//PizzaRepository stores pizza types with their size matrix and the toppings linked to them
+type PizzaRepository interface {
+	//List returns one page of the pizza types matching a query of PizzaListing, List and Get return them with their sizes
+	List(q listing.Query) ([]models.PizzaType, listing.Page, error)
+	Get(pizzaTypeId string) (models.PizzaType, error)
+	//Create and Update store the sizes of the pizza type along with it, replacing the previous matrix
+	Create(pizzaType models.PizzaType) error
+	Update(pizzaType models.PizzaType) error
+	//Delete also removes the sizes and topping links of the pizza type
+	Delete(pizzaTypeId string) error
+	SetSizes(pizzaTypeId string, sizes []models.PizzaSize) error
+	//LinkTopping returns ErrDuplicate when the topping is already linked to the pizza type
+	LinkTopping(pizzaTypeId string, toppingId string, isDefault bool) error
+	//UnlinkTopping returns ErrNotFound when the topping is not linked to the pizza type
+	UnlinkTopping(pizzaTypeId string, toppingId string) error
+	//SetToppings replaces every topping link of the pizza type
+	SetToppings(pizzaTypeId string, links []models.PizzaTopping) error
+	Toppings(pizzaTypeId string) ([]models.LinkedTopping, error)
+}
+
+//ToppingRepository stores the toppings catalog
+type ToppingRepository interface {
+	//List returns one page of the toppings matching a query of ToppingListing
+	List(q listing.Query) ([]models.Topping, listing.Page, error)
+	Get(toppingId string) (models.Topping, error)
+	Create(topping models.Topping) error
+	Update(topping models.Topping) error
+	//Delete also removes the topping from every pizza type it is linked to
+	Delete(toppingId string) error
+}
+
+//BeverageRepository stores the beverages catalog
+type BeverageRepository interface {
+	//List returns one page of the beverages matching a query of BeverageListing
+	List(q listing.Query) ([]models.Beverage, listing.Page, error)
+	Get(beverageId string) (models.Beverage, error)
+	Create(beverage models.Beverage) error
+	Update(beverage models.Beverage) error
+	Delete(beverageId string) error
+}
+
+//ComboRepository stores the meal combos sold as one catalog item
+type ComboRepository interface {
+	//List and Get return the combos with their slots
+	List() ([]models.Combo, error)
+	Get(comboId string) (models.Combo, error)
+	//Create and Update replace the slots of the combo and return it with the slot ids,
+	//Create returns ErrDuplicate when the combo id is taken
+	Create(combo models.Combo) (models.Combo, error)
+	Update(combo models.Combo) (models.Combo, error)
+	//Delete keeps the components already billed on invoices
+	Delete(comboId string) error
+}
+
+//InvoiceRepository stores invoices and their items, every change to the items
+//recalculates the totals of the invoice in the same unit of work
+//invalid items are reported with billing.ItemError and changes to invoices that are no longer open with payments.ErrLocked
+type InvoiceRepository interface {
+	//List returns one page of the invoice headers matching a query of InvoiceListing, dated by day
+	List(q listing.Query) ([]models.Invoice, listing.Page, error)
+	//Get returns the invoice with its tax snapshot, GetByNumber finds it by the number printed on the receipt
+	Get(invoiceId string) (models.Invoice, error)
+	GetByNumber(invoiceNumber string) (models.Invoice, error)
+	//Create stores the invoice and its items atomically and returns them with their ids, totals and the
+	//next invoice number, payments on the invoice are taken in the same unit of work
+	Create(invoice models.Invoice, items []models.InvoiceItem) (models.Invoice, []models.InvoiceItem, error)
+	//UpdateCustomer sets who an open invoice is billed to without touching its totals, customerId is 0 for a walk-in customer,
+	//it is refused with loyalty.LoyaltyError while points of the current customer are redeemed on the invoice
+	UpdateCustomer(invoiceId string, customerId int, customerName string) (models.Invoice, error)
+	//UpdateOrder changes the order type of an open invoice with its table or delivery address and recalculates its charges
+	UpdateOrder(invoiceId string, orderType string, tableNumber string, delivery *models.Delivery) (models.Invoice, error)
+
+	//ApplyCoupon enters a coupon code on an invoice and counts it against the usage limit
+	//of its promotion, invalid codes are reported with promotions.CouponError
+	ApplyCoupon(invoiceId string, code string) (models.Invoice, error)
+	RemoveCoupon(invoiceId string, code string) (models.Invoice, error)
+
+	//Pay takes tenders against an open invoice and returns it with the payments taken and the change due,
+	//the invoice becomes paid once its payments cover the total, invalid tenders are reported with payments.PaymentError
+	Pay(invoiceId string, tenders []models.Payment) (models.Invoice, []models.Payment, float64, error)
+	Payments(invoiceId string) ([]models.Payment, error)
+
+	//invoices are never deleted, Void cancels an open or paid invoice and Refund gives back lines of a paid one,
+	//both keep the invoice and return it with the credit note issued against it,
+	//refusals are reported with creditnotes.CreditError
+	Void(invoiceId string, request models.CreditNote) (models.Invoice, models.CreditNote, error)
+	Refund(invoiceId string, request models.CreditNote) (models.Invoice, models.CreditNote, error)
+	CreditNotes(invoiceId string) ([]models.CreditNote, error)
+	CreditNote(creditNoteId string) (models.CreditNote, error)
+
+	//Items returns the lines of an invoice with toppings nested under their pizza
+	Items(invoiceId string) ([]models.InvoiceItem, error)
+	AddItem(invoiceId string, item models.InvoiceItem) (models.InvoiceItem, error)
+	UpdateItem(invoiceItemId string, item models.InvoiceItem) (models.InvoiceItem, error)
+	//DeleteItem returns the id of the invoice the item was taken off
+	DeleteItem(invoiceItemId string) (string, error)
+}
+
+//PromotionRepository stores the promotions applied to invoices
+type PromotionRepository interface {
+	List() ([]models.Promotion, error)
+	Get(promotionId string) (models.Promotion, error)
+	//Create returns the promotion with its id, ErrDuplicate when the coupon code is taken
+	Create(promotion models.Promotion) (models.Promotion, error)
+	Update(promotion models.Promotion) error
+	//Delete keeps the discounts already recorded on invoices, they are recalculated with the terms they were applied with
+	Delete(promotionId string) error
+}
+
+//CustomerRepository stores the customer directory with the addresses of each customer
+type CustomerRepository interface {
+	//List returns one page of the customers matching a query of CustomerListing, without their addresses
+	List(q listing.Query) ([]models.Customer, listing.Page, error)
+	Get(customerId string) (models.Customer, error)
+	//Create and Update replace the addresses of the customer and return it with its ids,
+	//both return ErrDuplicate when another customer has the same phone number
+	Create(customer models.Customer) (models.Customer, error)
+	Update(customer models.Customer) (models.Customer, error)
+	//Delete keeps the invoices billed to the customer under the name they were billed to
+	Delete(customerId string) error
+	//Spend totals the invoices billed to the customer, void invoices are left out
+	Spend(customerId string) (models.CustomerSpend, error)
+	//Favourites ranks the items the customer ordered by quantity, toppings are counted with their pizza
+	Favourites(customerId string, limit int) ([]models.FavouriteItem, error)
+}
+
+//OrderRepository stores the delivery zones and the charges added to invoices of each order type
+type OrderRepository interface {
+	Zones() ([]models.DeliveryZone, error)
+	Zone(zoneId string) (models.DeliveryZone, error)
+	//CreateZone returns the zone with its id
+	CreateZone(zone models.DeliveryZone) (models.DeliveryZone, error)
+	UpdateZone(zone models.DeliveryZone) (models.DeliveryZone, error)
+	//DeleteZone keeps the deliveries and fees already recorded on invoices
+	DeleteZone(zoneId string) error
+
+	Charges() ([]models.OrderCharge, error)
+	Charge(chargeId string) (models.OrderCharge, error)
+	//CreateCharge returns the charge with its id
+	CreateCharge(charge models.OrderCharge) (models.OrderCharge, error)
+	UpdateCharge(charge models.OrderCharge) (models.OrderCharge, error)
+	//DeleteCharge keeps the charges already recorded on invoices
+	DeleteCharge(chargeId string) error
+}
+
+//TaxRateRepository stores the tax rates invoices are taxed with
+type TaxRateRepository interface {
+	List() ([]models.TaxRate, error)
+	Get(taxRateId string) (models.TaxRate, error)
+	//Create returns the tax rate with its id
+	Create(rate models.TaxRate) (models.TaxRate, error)
+	Update(rate models.TaxRate) error
+	//Delete returns ErrNotFound when the tax rate does not exist
+	Delete(taxRateId string) error
+}
+
+//UserRepository stores the user accounts, it is also the directory the role of every token is checked against
+type UserRepository interface {
+	List() ([]models.User, error)
+	//Get and FindByUsername return the user with its password hash
+	Get(userId string) (models.User, error)
+	FindByUsername(username string) (models.User, error)
+	//Create hashes the password of the user and returns it with its id, ErrDuplicate when the username is taken
+	Create(user models.User) (models.User, error)
+	//Update stores the role and password hash of the user
+	Update(user models.User) error
+	Delete(userId string) error
+	//LastAdmin tells whether the user is the only admin left, user management would be locked without them
+	LastAdmin(userId string) (bool, error)
+	//Role returns the current role of a user, auth.ErrUnknownUser when the user no longer exists
+	Role(userId int) (string, error)
+}
+
+//ReportRepository builds the reports over the stored invoices, credit notes and kitchen tickets
+//periods run from..to inclusive, both written YYYY-MM-DD
+type ReportRepository interface {
+	Sales(from string, to string) (reports.SalesReport, error)
+	PrepTimes(from string, to string) (reports.PrepTimeReport, error)
+}
+
+//KitchenRepository stores the kitchen stations and the tickets made from the pizza lines of invoices
+//tickets follow the items of their invoice, statuses that cannot be reached are reported with kitchen.KitchenError
+type KitchenRepository interface {
+	Stations() ([]models.KitchenStation, error)
+	Station(stationId string) (models.KitchenStation, error)
+	//CreateStation returns the station with its id
+	CreateStation(station models.KitchenStation) (models.KitchenStation, error)
+	//UpdateStation routes the tickets queued from now on, tickets already queued stay where they are
+	UpdateStation(station models.KitchenStation) (models.KitchenStation, error)
+	//DeleteStation leaves its tickets without a station
+	DeleteStation(stationId string) error
+
+	//Queue returns the tickets not served yet oldest first, only those of one station when stationId is given
+	Queue(stationId string) ([]models.KitchenTicket, error)
+	Ticket(ticketId string) (models.KitchenTicket, error)
+	//InvoiceTickets returns every ticket of an invoice, cancelled tickets included
+	InvoiceTickets(invoiceId string) ([]models.KitchenTicket, error)
+	//UpdateStatus moves a ticket to a status, an empty status bumps it to the next one
+	UpdateStatus(ticketId string, status string) (models.KitchenTicket, error)
+}
+
+//LoyaltyRepository stores the earning rules of the loyalty program and the points ledger of every customer
+//redemptions that cannot be made are reported with loyalty.LoyaltyError
+type LoyaltyRepository interface {
+	Rules() ([]models.LoyaltyRule, error)
+	Rule(ruleId string) (models.LoyaltyRule, error)
+	//CreateRule and UpdateRule return the rule with its id and timestamps
+	CreateRule(rule models.LoyaltyRule) (models.LoyaltyRule, error)
+	UpdateRule(rule models.LoyaltyRule) (models.LoyaltyRule, error)
+	//DeleteRule keeps the points already earned under the rule
+	DeleteRule(ruleId string) error
+
+	//Settings returns the redemption terms of the program
+	Settings() loyalty.Settings
+	//Balance returns ErrNotFound when the customer does not exist
+	Balance(customerId string) (int, error)
+	//Ledger returns one page of the entries matching a query of LoyaltyLedgerListing
+	Ledger(q listing.Query) ([]models.LoyaltyEntry, listing.Page, error)
+	//Adjust writes a manual correction of the points of a customer and returns it with the balance it left
+	Adjust(customerId string, entry models.LoyaltyEntry) (models.LoyaltyEntry, error)
+
+	//Redeem spends points of the customer an open invoice is billed to on a reward taken off the invoice,
+	//it returns the invoice with its new totals and the redemption
+	Redeem(invoiceId string, request models.LoyaltyRedemption) (models.Invoice, models.LoyaltyRedemption, error)
+	//CancelRedemption gives the points of a redemption back while the invoice is still open
+	CancelRedemption(invoiceId string, redemptionId string) (models.Invoice, error)
+}
+
+//Repositories groups the repositories of one storage backend
+type Repositories struct {
+	Pizzas     PizzaRepository
+	Toppings   ToppingRepository
+	Beverages  BeverageRepository
+	Invoices   InvoiceRepository
+	Promotions PromotionRepository
+	Combos     ComboRepository
+	Customers  CustomerRepository
+	Loyalty    LoyaltyRepository
+	Orders     OrderRepository
+	Kitchen    KitchenRepository
+	TaxRates   TaxRateRepository
+	Users      UserRepository
+	Reports    ReportRepository
+}
+
+//function to tell which line of a new invoice was rejected
+func lineError(index int, err error) error {
+	if itemErr, ok := err.(billing.ItemError); ok {
+		return billing.ItemError(fmt.Sprintf("item %d: %s", index+1, itemErr))
+	}
+	return err
+}
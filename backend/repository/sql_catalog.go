@@ -0,0 +1,390 @@
+package repository
+
+import (
+	"database/sql"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/listing"
+	"piza_shop_billing/backend/loyalty"
+	"piza_shop_billing/backend/models"
+)
+
+//function to create the repositories backed by a database/sql connection pool, MySQL or SQLite
+func NewSQL(db *sql.DB) Repositories {
+	return Repositories{
+		Pizzas:     &sqlPizzaRepository{db: db},
+		Toppings:   &sqlToppingRepository{db: db},
+		Beverages:  &sqlBeverageRepository{db: db},
+		Invoices:   &sqlInvoiceRepository{db: db},
+		Promotions: &sqlPromotionRepository{db: db},
+		Combos:     &sqlComboRepository{db: db},
+		Customers:  &sqlCustomerRepository{db: db},
+		Loyalty:    &sqlLoyaltyRepository{db: db, settings: loyalty.SettingsFromEnv()},
+		Orders:     &sqlOrderRepository{db: db},
+		Kitchen:    &sqlKitchenRepository{db: db},
+		TaxRates:   &sqlTaxRateRepository{db: db},
+		Users:      &sqlUserRepository{db: db},
+		Reports:    &sqlReportRepository{db: db},
+	}
+}
+
+type sqlPizzaRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlPizzaRepository) List(q listing.Query) ([]models.PizzaType, listing.Page, error) {
+	total, err := q.Count(r.db, "pizza_types", PizzaListing)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+
+	clauses, args := q.Clauses(PizzaListing)
+	results, err := r.db.Query("SELECT pizza_type_id,name,size,base_price,description FROM pizza_types"+clauses, args...)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+	defer results.Close()
+
+	pizzaTypes := []models.PizzaType{}
+	for results.Next() {
+		var pizzaType models.PizzaType
+		if err := results.Scan(&pizzaType.PizzaTypeId, &pizzaType.Name, &pizzaType.Size, &pizzaType.BasePrice, &pizzaType.Description); err != nil {
+			return nil, listing.Page{}, err
+		}
+		pizzaTypes = append(pizzaTypes, pizzaType)
+	}
+	if err := results.Err(); err != nil {
+		return nil, listing.Page{}, err
+	}
+
+	sizes, err := r.sizes("")
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+	for i := range pizzaTypes {
+		pizzaTypes[i].Sizes = sizes[pizzaTypes[i].PizzaTypeId]
+	}
+	return listing.Paginate(q, pizzaTypes, total)
+}
+
+func (r *sqlPizzaRepository) Get(pizzaTypeId string) (models.PizzaType, error) {
+	var pizzaType models.PizzaType
+	query := "SELECT pizza_type_id, name, size, base_price, description FROM pizza_types WHERE pizza_type_id = ?"
+	err := r.db.QueryRow(query, pizzaTypeId).Scan(&pizzaType.PizzaTypeId, &pizzaType.Name, &pizzaType.Size, &pizzaType.BasePrice, &pizzaType.Description)
+	if err == sql.ErrNoRows {
+		return pizzaType, ErrNotFound
+	}
+	if err != nil {
+		return pizzaType, err
+	}
+
+	sizes, err := r.sizes(pizzaTypeId)
+	pizzaType.Sizes = sizes[pizzaTypeId]
+	return pizzaType, err
+}
+
+//function to load the size matrix of one pizza type, or of every pizza type when the id is empty
+func (r *sqlPizzaRepository) sizes(pizzaTypeId string) (map[string][]models.PizzaSize, error) {
+	query := "SELECT pizza_type_id, size, price, topping_multiplier FROM pizza_sizes"
+	var args []interface{}
+	if pizzaTypeId != "" {
+		query += " WHERE pizza_type_id = ?"
+		args = append(args, pizzaTypeId)
+	}
+	query += " ORDER BY pizza_type_id, price"
+
+	results, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	sizes := map[string][]models.PizzaSize{}
+	for results.Next() {
+		var size models.PizzaSize
+		if err := results.Scan(&size.PizzaTypeId, &size.Size, &size.Price, &size.ToppingMultiplier); err != nil {
+			return nil, err
+		}
+		sizes[size.PizzaTypeId] = append(sizes[size.PizzaTypeId], size)
+	}
+	return sizes, results.Err()
+}
+
+func (r *sqlPizzaRepository) Create(pizzaType models.PizzaType) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO pizza_types(pizza_type_id,name,size,base_price,description,created_at,updated_at) VALUES(?,?,?,?,?,?,?)"
+	if _, err := tx.Exec(query, pizzaType.PizzaTypeId, pizzaType.Name, pizzaType.Size, pizzaType.BasePrice, pizzaType.Description, pizzaType.CreatedAt, pizzaType.UpdatedAt); err != nil {
+		return err
+	}
+	if err := saveSizes(tx, pizzaType.PizzaTypeId, pizzaType.Sizes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlPizzaRepository) Update(pizzaType models.PizzaType) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE pizza_types SET name=?, size=?, base_price=?, description=?, updated_at=? WHERE pizza_type_id=?"
+	if _, err := tx.Exec(query, pizzaType.Name, pizzaType.Size, pizzaType.BasePrice, pizzaType.Description, pizzaType.UpdatedAt, pizzaType.PizzaTypeId); err != nil {
+		return err
+	}
+	if err := saveSizes(tx, pizzaType.PizzaTypeId, pizzaType.Sizes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlPizzaRepository) SetSizes(pizzaTypeId string, sizes []models.PizzaSize) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM pizza_types WHERE pizza_type_id = ?)", pizzaTypeId).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	if err := saveSizes(tx, pizzaTypeId, sizes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//function to replace the size matrix of a pizza type
+func saveSizes(tx *sql.Tx, pizzaTypeId string, sizes []models.PizzaSize) error {
+	if _, err := tx.Exec("DELETE FROM pizza_sizes WHERE pizza_type_id = ?", pizzaTypeId); err != nil {
+		return err
+	}
+	query := "INSERT INTO pizza_sizes (pizza_type_id, size, price, topping_multiplier) VALUES (?, ?, ?, ?)"
+	for _, size := range sizes {
+		if _, err := tx.Exec(query, pizzaTypeId, size.Size, size.Price, size.ToppingMultiplier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *sqlPizzaRepository) Delete(pizzaTypeId string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	//delete the sizes and topping links first so no orphaned rows are left behind
+	if _, err := tx.Exec("DELETE FROM pizza_sizes WHERE pizza_type_id = ?", pizzaTypeId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM pizza_toppings WHERE pizza_type_id = ?", pizzaTypeId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM pizza_types WHERE pizza_type_id = ?", pizzaTypeId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlPizzaRepository) LinkTopping(pizzaTypeId string, toppingId string, isDefault bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var linked bool
+	query := "SELECT EXISTS(SELECT 1 FROM pizza_toppings WHERE pizza_type_id = ? AND topping_id = ?)"
+	if err := tx.QueryRow(query, pizzaTypeId, toppingId).Scan(&linked); err != nil {
+		return err
+	}
+	if linked {
+		return ErrDuplicate
+	}
+	if _, err := tx.Exec("INSERT INTO pizza_toppings (pizza_type_id, topping_id, is_default) VALUES (?, ?, ?)", pizzaTypeId, toppingId, isDefault); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlPizzaRepository) UnlinkTopping(pizzaTypeId string, toppingId string) error {
+	result, err := r.db.Exec("DELETE FROM pizza_toppings WHERE pizza_type_id = ? AND topping_id = ?", pizzaTypeId, toppingId)
+	if err != nil {
+		return err
+	}
+	removed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if removed == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqlPizzaRepository) SetToppings(pizzaTypeId string, links []models.PizzaTopping) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM pizza_types WHERE pizza_type_id = ?)", pizzaTypeId).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrNotFound
+	}
+	if _, err := tx.Exec("DELETE FROM pizza_toppings WHERE pizza_type_id = ?", pizzaTypeId); err != nil {
+		return err
+	}
+	for _, link := range links {
+		if _, err := tx.Exec("INSERT INTO pizza_toppings (pizza_type_id, topping_id, is_default) VALUES (?, ?, ?)", pizzaTypeId, link.ToppingId, link.IsDefault); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *sqlPizzaRepository) Toppings(pizzaTypeId string) ([]models.LinkedTopping, error) {
+	return billing.LinkedToppings(r.db, pizzaTypeId)
+}
+
+type sqlToppingRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlToppingRepository) List(q listing.Query) ([]models.Topping, listing.Page, error) {
+	total, err := q.Count(r.db, "toppings", ToppingListing)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+
+	clauses, args := q.Clauses(ToppingListing)
+	results, err := r.db.Query("SELECT topping_id,name,price FROM toppings"+clauses, args...)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+	defer results.Close()
+
+	toppings := []models.Topping{}
+	for results.Next() {
+		var topping models.Topping
+		if err := results.Scan(&topping.ToppingId, &topping.Name, &topping.Price); err != nil {
+			return nil, listing.Page{}, err
+		}
+		toppings = append(toppings, topping)
+	}
+	if err := results.Err(); err != nil {
+		return nil, listing.Page{}, err
+	}
+	return listing.Paginate(q, toppings, total)
+}
+
+func (r *sqlToppingRepository) Get(toppingId string) (models.Topping, error) {
+	var topping models.Topping
+	err := r.db.QueryRow("SELECT topping_id,name,price FROM toppings WHERE topping_id = ?", toppingId).Scan(&topping.ToppingId, &topping.Name, &topping.Price)
+	if err == sql.ErrNoRows {
+		return topping, ErrNotFound
+	}
+	return topping, err
+}
+
+func (r *sqlToppingRepository) Create(topping models.Topping) error {
+	query := "INSERT INTO toppings(topping_id,name,price,created_at,updated_at) VALUES(?,?,?,?,?)"
+	_, err := r.db.Exec(query, topping.ToppingId, topping.Name, topping.Price, topping.CreatedAt, topping.UpdatedAt)
+	return err
+}
+
+func (r *sqlToppingRepository) Update(topping models.Topping) error {
+	query := "UPDATE toppings SET name=?,price=?,updated_at=? WHERE topping_id=?"
+	_, err := r.db.Exec(query, topping.Name, topping.Price, topping.UpdatedAt, topping.ToppingId)
+	return err
+}
+
+func (r *sqlToppingRepository) Delete(toppingId string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	//delete the topping from associated tables first
+	if _, err := tx.Exec("DELETE FROM pizza_toppings WHERE topping_id = ?", toppingId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM toppings WHERE topping_id = ?", toppingId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+type sqlBeverageRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlBeverageRepository) List(q listing.Query) ([]models.Beverage, listing.Page, error) {
+	total, err := q.Count(r.db, "beverages", BeverageListing)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+
+	clauses, args := q.Clauses(BeverageListing)
+	results, err := r.db.Query("SELECT beverage_id,name,price FROM beverages"+clauses, args...)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+	defer results.Close()
+
+	beverages := []models.Beverage{}
+	for results.Next() {
+		var beverage models.Beverage
+		if err := results.Scan(&beverage.BeverageId, &beverage.Name, &beverage.Price); err != nil {
+			return nil, listing.Page{}, err
+		}
+		beverages = append(beverages, beverage)
+	}
+	if err := results.Err(); err != nil {
+		return nil, listing.Page{}, err
+	}
+	return listing.Paginate(q, beverages, total)
+}
+
+func (r *sqlBeverageRepository) Get(beverageId string) (models.Beverage, error) {
+	var beverage models.Beverage
+	err := r.db.QueryRow("SELECT beverage_id, name,price FROM beverages WHERE beverage_id = ?", beverageId).Scan(&beverage.BeverageId, &beverage.Name, &beverage.Price)
+	if err == sql.ErrNoRows {
+		return beverage, ErrNotFound
+	}
+	return beverage, err
+}
+
+func (r *sqlBeverageRepository) Create(beverage models.Beverage) error {
+	query := "INSERT INTO beverages(beverage_id,name,price,created_at,updated_at) VALUES(?,?,?,?,?)"
+	_, err := r.db.Exec(query, beverage.BeverageId, beverage.Name, beverage.Price, beverage.CreatedAt, beverage.UpdatedAt)
+	return err
+}
+
+func (r *sqlBeverageRepository) Update(beverage models.Beverage) error {
+	query := "UPDATE beverages SET name=?, price=?,updated_at=? WHERE beverage_id=?"
+	_, err := r.db.Exec(query, beverage.Name, beverage.Price, beverage.UpdatedAt, beverage.BeverageId)
+	return err
+}
+
+func (r *sqlBeverageRepository) Delete(beverageId string) error {
+	_, err := r.db.Exec("DELETE FROM beverages WHERE beverage_id = ?", beverageId)
+	return err
+}
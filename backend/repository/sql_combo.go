@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"database/sql"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/models"
+)
+
+type sqlComboRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlComboRepository) List() ([]models.Combo, error) {
+	return billing.LoadCombos(r.db)
+}
+
+func (r *sqlComboRepository) Get(comboId string) (models.Combo, error) {
+	combo, err := billing.LoadCombo(r.db, comboId)
+	if err == sql.ErrNoRows {
+		return combo, ErrNotFound
+	}
+	return combo, err
+}
+
+func (r *sqlComboRepository) Create(combo models.Combo) (models.Combo, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return combo, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM combos WHERE combo_id = ?)", combo.ComboId).Scan(&exists); err != nil {
+		return combo, err
+	}
+	if exists {
+		return combo, ErrDuplicate
+	}
+
+	query := "INSERT INTO combos (combo_id, name, description, price, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)"
+	if _, err := tx.Exec(query, combo.ComboId, combo.Name, combo.Description, combo.Price, combo.CreatedAt, combo.UpdatedAt); err != nil {
+		return combo, err
+	}
+	if combo.Slots, err = saveSlots(tx, combo.ComboId, combo.Slots); err != nil {
+		return combo, err
+	}
+	return combo, tx.Commit()
+}
+
+func (r *sqlComboRepository) Update(combo models.Combo) (models.Combo, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return combo, err
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE combos SET name=?, description=?, price=?, updated_at=? WHERE combo_id=?"
+	result, err := tx.Exec(query, combo.Name, combo.Description, combo.Price, combo.UpdatedAt, combo.ComboId)
+	if err != nil {
+		return combo, err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		var exists bool
+		if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM combos WHERE combo_id = ?)", combo.ComboId).Scan(&exists); err != nil {
+			return combo, err
+		}
+		if !exists {
+			return combo, ErrNotFound
+		}
+	}
+	if combo.Slots, err = saveSlots(tx, combo.ComboId, combo.Slots); err != nil {
+		return combo, err
+	}
+	return combo, tx.Commit()
+}
+
+func (r *sqlComboRepository) Delete(comboId string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	//delete the slots first so no orphaned rows are left behind, billed combo lines keep their components
+	query := "DELETE FROM combo_slot_items WHERE combo_slot_id IN (SELECT combo_slot_id FROM combo_slots WHERE combo_id = ?)"
+	if _, err := tx.Exec(query, comboId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM combo_slots WHERE combo_id = ?", comboId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM combos WHERE combo_id = ?", comboId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//function to replace the slots of a combo and return them with their ids
+//slots sent back with the id they already had keep it, so combo lines billed before stay editable
+func saveSlots(tx *sql.Tx, comboId string, slots []models.ComboSlot) ([]models.ComboSlot, error) {
+	results, err := tx.Query("SELECT combo_slot_id FROM combo_slots WHERE combo_id = ?", comboId)
+	if err != nil {
+		return nil, err
+	}
+	previous := map[int]bool{}
+	for results.Next() {
+		var slotId int
+		if err := results.Scan(&slotId); err != nil {
+			results.Close()
+			return nil, err
+		}
+		previous[slotId] = true
+	}
+	results.Close()
+	if err := results.Err(); err != nil {
+		return nil, err
+	}
+
+	query := "DELETE FROM combo_slot_items WHERE combo_slot_id IN (SELECT combo_slot_id FROM combo_slots WHERE combo_id = ?)"
+	if _, err := tx.Exec(query, comboId); err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec("DELETE FROM combo_slots WHERE combo_id = ?", comboId); err != nil {
+		return nil, err
+	}
+
+	saved := make([]models.ComboSlot, 0, len(slots))
+	for _, slot := range slots {
+		var result sql.Result
+		if previous[slot.ComboSlotId] {
+			query := "INSERT INTO combo_slots (combo_slot_id, combo_id, name, item_kind, size, quantity) VALUES (?, ?, ?, ?, ?, ?)"
+			result, err = tx.Exec(query, slot.ComboSlotId, comboId, slot.Name, slot.ItemKind, slot.Size, slot.Quantity)
+		} else {
+			query := "INSERT INTO combo_slots (combo_id, name, item_kind, size, quantity) VALUES (?, ?, ?, ?, ?)"
+			result, err = tx.Exec(query, comboId, slot.Name, slot.ItemKind, slot.Size, slot.Quantity)
+		}
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		slot.ComboSlotId = int(id)
+
+		for _, itemId := range slot.ItemIds {
+			if _, err := tx.Exec("INSERT INTO combo_slot_items (combo_slot_id, item_id) VALUES (?, ?)", slot.ComboSlotId, itemId); err != nil {
+				return nil, err
+			}
+		}
+		saved = append(saved, slot)
+	}
+	return saved, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/creditnotes"
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/kitchen"
+	"piza_shop_billing/backend/loyalty"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/numbering"
+	"piza_shop_billing/backend/payments"
+)
+
+func (r *sqlInvoiceRepository) Void(invoiceId string, request models.CreditNote) (models.Invoice, models.CreditNote, error) {
+	return r.credit(creditnotes.KindVoid, invoiceId, request)
+}
+
+func (r *sqlInvoiceRepository) Refund(invoiceId string, request models.CreditNote) (models.Invoice, models.CreditNote, error) {
+	return r.credit(creditnotes.KindRefund, invoiceId, request)
+}
+
+//function to issue a credit note against an invoice and move the invoice to its new status in one transaction
+func (r *sqlInvoiceRepository) credit(kind string, invoiceId string, request models.CreditNote) (models.Invoice, models.CreditNote, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Invoice{}, request, err
+	}
+	defer tx.Rollback()
+
+	invoice, err := getInvoice(tx, invoiceId)
+	if err != nil {
+		return invoice, request, err
+	}
+	lines, err := billing.LoadItems(tx, invoiceId)
+	if err != nil {
+		return invoice, request, err
+	}
+	note, status, err := creditnotes.Issue(kind, invoice, lines, invoice.CreditNotes, request)
+	if err != nil {
+		return invoice, request, err
+	}
+	//the credit note takes the next number of its series in this transaction so no number is skipped
+	if note.CreditNoteNumber, err = numbering.CreditNoteFormat().Next(tx, note.CreatedAt.Year()); err != nil {
+		return invoice, request, err
+	}
+	if note, err = creditnotes.Save(tx, note); err != nil {
+		return invoice, note, err
+	}
+
+	query := "UPDATE invoices SET status=?, updated_at=" + database.Current.Now() + " WHERE invoice_id=?"
+	if _, err := tx.Exec(query, status, invoiceId); err != nil {
+		return invoice, note, err
+	}
+	//points earned on the credited part go back and points redeemed on it are given back
+	share, final := loyalty.Credited(invoice, note, status)
+	if err := loyalty.Reverse(tx, invoiceId, share, final, loyalty.CreditReason(note)); err != nil {
+		return invoice, note, err
+	}
+	//a voided invoice takes its pizzas off the kitchen screens
+	if status == payments.StatusVoid {
+		if err := kitchen.Sync(tx, invoiceId, nil, time.Now()); err != nil {
+			return invoice, note, err
+		}
+	}
+	if invoice, err = getInvoice(tx, invoiceId); err != nil {
+		return invoice, note, err
+	}
+	return invoice, note, tx.Commit()
+}
+
+func (r *sqlInvoiceRepository) CreditNotes(invoiceId string) ([]models.CreditNote, error) {
+	var exists bool
+	if err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM invoices WHERE invoice_id = ?)", invoiceId).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return creditnotes.Load(r.db, invoiceId)
+}
+
+func (r *sqlInvoiceRepository) CreditNote(creditNoteId string) (models.CreditNote, error) {
+	note, err := creditnotes.Get(r.db, creditNoteId)
+	if err == sql.ErrNoRows {
+		return note, ErrNotFound
+	}
+	return note, err
+}
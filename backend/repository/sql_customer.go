@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/listing"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/tax"
+)
+
+type sqlCustomerRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlCustomerRepository) List(q listing.Query) ([]models.Customer, listing.Page, error) {
+	total, err := q.Count(r.db, "customers", CustomerListing)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+
+	clauses, args := q.Clauses(CustomerListing)
+	query := "SELECT customer_id, name, COALESCE(phone, ''), email, notes, created_at, updated_at FROM customers" + clauses
+	results, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+	defer results.Close()
+
+	customers := []models.Customer{}
+	for results.Next() {
+		var customer models.Customer
+		if err := results.Scan(&customer.CustomerId, &customer.Name, &customer.Phone, &customer.Email, &customer.Notes, &customer.CreatedAt, &customer.UpdatedAt); err != nil {
+			return nil, listing.Page{}, err
+		}
+		customers = append(customers, customer)
+	}
+	if err := results.Err(); err != nil {
+		return nil, listing.Page{}, err
+	}
+	return listing.Paginate(q, customers, total)
+}
+
+func (r *sqlCustomerRepository) Get(customerId string) (models.Customer, error) {
+	var customer models.Customer
+	query := "SELECT customer_id, name, COALESCE(phone, ''), email, notes, created_at, updated_at FROM customers WHERE customer_id = ?"
+	err := r.db.QueryRow(query, customerId).Scan(&customer.CustomerId, &customer.Name, &customer.Phone, &customer.Email, &customer.Notes, &customer.CreatedAt, &customer.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return customer, ErrNotFound
+	}
+	if err != nil {
+		return customer, err
+	}
+
+	customer.Addresses, err = loadAddresses(r.db, customerId)
+	return customer, err
+}
+
+func (r *sqlCustomerRepository) Create(customer models.Customer) (models.Customer, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return customer, err
+	}
+	defer tx.Rollback()
+
+	if taken, err := phoneTaken(tx, customer); err != nil || taken {
+		if taken {
+			err = ErrDuplicate
+		}
+		return customer, err
+	}
+
+	query := "INSERT INTO customers (name, phone, email, notes, created_at, updated_at) VALUES (?,?,?,?,?,?)"
+	result, err := tx.Exec(query, customer.Name, phoneColumn(customer), customer.Email, customer.Notes, customer.CreatedAt, customer.UpdatedAt)
+	if err != nil {
+		return customer, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return customer, err
+	}
+	customer.CustomerId = int(id)
+
+	if customer.Addresses, err = saveAddresses(tx, customer.CustomerId, customer.Addresses); err != nil {
+		return customer, err
+	}
+	return customer, tx.Commit()
+}
+
+func (r *sqlCustomerRepository) Update(customer models.Customer) (models.Customer, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return customer, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow("SELECT EXISTS(SELECT 1 FROM customers WHERE customer_id = ?)", customer.CustomerId).Scan(&exists); err != nil {
+		return customer, err
+	}
+	if !exists {
+		return customer, ErrNotFound
+	}
+	if taken, err := phoneTaken(tx, customer); err != nil || taken {
+		if taken {
+			err = ErrDuplicate
+		}
+		return customer, err
+	}
+
+	query := "UPDATE customers SET name=?, phone=?, email=?, notes=?, updated_at=? WHERE customer_id=?"
+	if _, err := tx.Exec(query, customer.Name, phoneColumn(customer), customer.Email, customer.Notes, customer.UpdatedAt, customer.CustomerId); err != nil {
+		return customer, err
+	}
+
+	//the addresses are replaced as a whole like the sizes of a pizza type
+	if _, err := tx.Exec("DELETE FROM customer_addresses WHERE customer_id = ?", customer.CustomerId); err != nil {
+		return customer, err
+	}
+	if customer.Addresses, err = saveAddresses(tx, customer.CustomerId, customer.Addresses); err != nil {
+		return customer, err
+	}
+	return customer, tx.Commit()
+}
+
+func (r *sqlCustomerRepository) Delete(customerId string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	//invoices keep the name they were billed to, only the link to the directory goes
+	if _, err := tx.Exec("UPDATE invoices SET customer_id = NULL WHERE customer_id = ?", customerId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM customer_addresses WHERE customer_id = ?", customerId); err != nil {
+		return err
+	}
+	result, err := tx.Exec("DELETE FROM customers WHERE customer_id = ?", customerId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit()
+}
+
+func (r *sqlCustomerRepository) Spend(customerId string) (models.CustomerSpend, error) {
+	var spend models.CustomerSpend
+	var total, credited float64
+	query := "SELECT COUNT(*), COALESCE(SUM(total), 0), COALESCE(MAX(" + database.Current.FormatDate("invoice_date") + "), '') FROM invoices WHERE customer_id = ? AND status <> ?"
+	if err := r.db.QueryRow(query, customerId, payments.StatusVoid).Scan(&spend.InvoiceCount, &total, &spend.LastOrderDate); err != nil {
+		return spend, err
+	}
+
+	//refunds are taken off what the customer spent
+	query = `SELECT COALESCE(SUM(cn.total), 0) FROM credit_notes cn
+		INNER JOIN invoices i ON i.invoice_id = cn.invoice_id
+		WHERE i.customer_id = ? AND i.status <> ?`
+	if err := r.db.QueryRow(query, customerId, payments.StatusVoid).Scan(&credited); err != nil {
+		return spend, err
+	}
+	spend.TotalSpent = tax.Round(total + credited)
+	return spend, nil
+}
+
+func (r *sqlCustomerRepository) Favourites(customerId string, limit int) ([]models.FavouriteItem, error) {
+	query := `SELECT ii.item_kind, ii.item_id, MAX(ii.item_name), COALESCE(ii.size, ''), SUM(ii.quantity), COUNT(DISTINCT ii.invoice_id), MAX(` + database.Current.FormatDate("i.invoice_date") + `)
+		FROM invoice_items ii
+		INNER JOIN invoices i ON i.invoice_id = ii.invoice_id
+		WHERE i.customer_id = ? AND i.status NOT IN (?, ?) AND ii.parent_item_id IS NULL
+		GROUP BY ii.item_kind, ii.item_id, COALESCE(ii.size, '')
+		ORDER BY SUM(ii.quantity) DESC, COUNT(DISTINCT ii.invoice_id) DESC, ii.item_id
+		LIMIT ?`
+	results, err := r.db.Query(query, customerId, payments.StatusVoid, payments.StatusRefunded, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	favourites := []models.FavouriteItem{}
+	for results.Next() {
+		var item models.FavouriteItem
+		if err := results.Scan(&item.ItemKind, &item.ItemId, &item.Name, &item.Size, &item.Quantity, &item.Orders, &item.LastOrderDate); err != nil {
+			return nil, err
+		}
+		favourites = append(favourites, item)
+	}
+	return favourites, results.Err()
+}
+
+//function to load the addresses of a customer in the order they were entered
+func loadAddresses(db database.DBTX, customerId string) ([]models.CustomerAddress, error) {
+	query := "SELECT address_id, customer_id, label, line1, line2, city, postal_code, instructions FROM customer_addresses WHERE customer_id = ? ORDER BY address_id"
+	results, err := db.Query(query, customerId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	addresses := []models.CustomerAddress{}
+	for results.Next() {
+		var address models.CustomerAddress
+		if err := results.Scan(&address.AddressId, &address.CustomerId, &address.Label, &address.Line1, &address.Line2, &address.City, &address.PostalCode, &address.Instructions); err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, results.Err()
+}
+
+//function to store the addresses of a customer, it returns them with their ids
+func saveAddresses(tx *sql.Tx, customerId int, addresses []models.CustomerAddress) ([]models.CustomerAddress, error) {
+	query := "INSERT INTO customer_addresses (customer_id, label, line1, line2, city, postal_code, instructions) VALUES (?,?,?,?,?,?,?)"
+	saved := make([]models.CustomerAddress, 0, len(addresses))
+	for _, address := range addresses {
+		address.CustomerId = customerId
+		result, err := tx.Exec(query, address.CustomerId, address.Label, address.Line1, address.Line2, address.City, address.PostalCode, address.Instructions)
+		if err != nil {
+			return nil, err
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		address.AddressId = int(id)
+		saved = append(saved, address)
+	}
+	return saved, nil
+}
+
+//function to tell whether another customer already has the phone number of a customer
+func phoneTaken(tx *sql.Tx, customer models.Customer) (bool, error) {
+	if customer.Phone == "" {
+		return false, nil
+	}
+	var taken bool
+	query := "SELECT EXISTS(SELECT 1 FROM customers WHERE phone = ? AND customer_id <> ?)"
+	err := tx.QueryRow(query, customer.Phone, customer.CustomerId).Scan(&taken)
+	return taken, err
+}
+
+//function to store an empty phone number as NULL so customers without one do not collide
+func phoneColumn(customer models.Customer) interface{} {
+	if customer.Phone == "" {
+		return nil
+	}
+	return customer.Phone
+}
+
+//function to store walk-in invoices without a customer as NULL
+func customerColumn(customerId int) interface{} {
+	if customerId == 0 {
+		return nil
+	}
+	return customerId
+}
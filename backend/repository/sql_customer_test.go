@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/database/databasetest"
+	"piza_shop_billing/backend/listing"
+	"piza_shop_billing/backend/models"
+)
+
+func TestCustomersPageByCreationDay(t *testing.T) {
+	db := databasetest.Open(t)
+	repos := NewSQL(db)
+
+	//customers of two days created out of order, the last one just before midnight
+	first := time.Date(2026, 3, 12, 0, 0, 0, 0, time.Local)
+	second := first.AddDate(0, 0, 1)
+	for i, at := range []time.Time{
+		first.Add(10 * time.Hour),
+		second.Add(9 * time.Hour),
+		first.Add(11 * time.Hour),
+		second.Add(8 * time.Hour),
+		first.Add(23*time.Hour + 59*time.Minute),
+	} {
+		customer := models.Customer{Name: string(rune('A' + i)), CreatedAt: at, UpdatedAt: at}
+		if _, err := repos.Customers.Create(customer); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sort string
+		want []int
+	}{
+		{sort: "created_at", want: []int{1, 3, 5, 2, 4}},
+		{sort: "-created_at", want: []int{2, 4, 1, 3, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			var got []int
+			values := url.Values{"sort": {tt.sort}, "limit": {"2"}}
+			for pages := 0; pages < 5; pages++ {
+				q, err := listing.Parse(values, CustomerListing)
+				if err != nil {
+					t.Fatal(err)
+				}
+				customers, page, err := repos.Customers.List(q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, customer := range customers {
+					got = append(got, customer.CustomerId)
+				}
+				if !page.HasMore {
+					break
+				}
+				values.Set("cursor", page.NextCursor)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("customers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
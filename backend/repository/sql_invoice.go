@@ -0,0 +1,389 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/creditnotes"
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/kitchen"
+	"piza_shop_billing/backend/listing"
+	"piza_shop_billing/backend/loyalty"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/numbering"
+	"piza_shop_billing/backend/orders"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/promotions"
+	"piza_shop_billing/backend/tax"
+)
+
+type sqlInvoiceRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlInvoiceRepository) List(q listing.Query) ([]models.Invoice, listing.Page, error) {
+	total, err := q.Count(r.db, "invoices", InvoiceListing)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+
+	clauses, args := q.Clauses(InvoiceListing)
+	query := "SELECT invoice_id, COALESCE(invoice_number, '')," + database.Current.FormatDate("invoice_date") + " AS invoice_date, subtotal, discount, charge, tax, total,customer_name, COALESCE(customer_id, 0), order_type, table_number, status, amount_paid FROM invoices" + clauses
+	results, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+	defer results.Close()
+
+	invoices := []models.Invoice{}
+	for results.Next() {
+		var invoice models.Invoice
+		if err := results.Scan(&invoice.InvoiceId, &invoice.InvoiceNumber, &invoice.InvoiceDate, &invoice.SubTotal, &invoice.Discount, &invoice.Charge, &invoice.Tax, &invoice.Total, &invoice.CustomerName, &invoice.CustomerId, &invoice.OrderType, &invoice.TableNumber, &invoice.Status, &invoice.AmountPaid); err != nil {
+			return nil, listing.Page{}, err
+		}
+		invoice.BalanceDue = payments.Balance(invoice.Status, invoice.Total, invoice.AmountPaid)
+		invoices = append(invoices, invoice)
+	}
+	if err := results.Err(); err != nil {
+		return nil, listing.Page{}, err
+	}
+	return listing.Paginate(q, invoices, total)
+}
+
+func (r *sqlInvoiceRepository) Get(invoiceId string) (models.Invoice, error) {
+	return getInvoice(r.db, invoiceId)
+}
+
+func (r *sqlInvoiceRepository) GetByNumber(invoiceNumber string) (models.Invoice, error) {
+	var invoiceId string
+	err := r.db.QueryRow("SELECT invoice_id FROM invoices WHERE invoice_number = ?", invoiceNumber).Scan(&invoiceId)
+	if err == sql.ErrNoRows {
+		return models.Invoice{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	return getInvoice(r.db, invoiceId)
+}
+
+//function to load an invoice header with its tax and discount snapshots, its coupons, its payments and its credit notes
+func getInvoice(db database.DBTX, invoiceId string) (models.Invoice, error) {
+	var invoice models.Invoice
+	query := "SELECT invoice_id, COALESCE(invoice_number, ''), " + database.Current.FormatDateTime("invoice_date") + ", subtotal, discount, charge, tax, total, customer_name, COALESCE(customer_id, 0), order_type, table_number, status, amount_paid FROM invoices WHERE invoice_id = ?"
+	err := db.QueryRow(query, invoiceId).Scan(&invoice.InvoiceId, &invoice.InvoiceNumber, &invoice.InvoiceDate, &invoice.SubTotal, &invoice.Discount, &invoice.Charge, &invoice.Tax, &invoice.Total, &invoice.CustomerName, &invoice.CustomerId, &invoice.OrderType, &invoice.TableNumber, &invoice.Status, &invoice.AmountPaid)
+	if err == sql.ErrNoRows {
+		return invoice, ErrNotFound
+	}
+	if err != nil {
+		return invoice, err
+	}
+
+	if invoice.Taxes, err = tax.LoadInvoiceTaxes(db, invoiceId); err != nil {
+		return invoice, err
+	}
+	if invoice.Discounts, err = promotions.LoadInvoiceDiscounts(db, invoiceId); err != nil {
+		return invoice, err
+	}
+	if invoice.CouponCodes, err = promotions.LoadCoupons(db, invoiceId); err != nil {
+		return invoice, err
+	}
+	if invoice.Charges, err = orders.LoadInvoiceCharges(db, invoiceId); err != nil {
+		return invoice, err
+	}
+	if invoice.Delivery, err = orders.LoadDelivery(db, invoiceId); err != nil {
+		return invoice, err
+	}
+	invoice.BalanceDue = payments.Balance(invoice.Status, invoice.Total, invoice.AmountPaid)
+	if invoice.Payments, err = payments.Load(db, invoiceId); err != nil {
+		return invoice, err
+	}
+	if invoice.CreditNotes, err = creditnotes.Load(db, invoiceId); err != nil {
+		return invoice, err
+	}
+	if invoice.CustomerId != 0 {
+		summary, err := loyalty.Summary(db, invoiceId, invoice.CustomerId)
+		if err != nil {
+			return invoice, err
+		}
+		invoice.Loyalty = &summary
+	}
+	return invoice, nil
+}
+
+func (r *sqlInvoiceRepository) Create(invoice models.Invoice, items []models.InvoiceItem) (models.Invoice, []models.InvoiceItem, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return invoice, nil, err
+	}
+	//rollback is a no-op once the transaction has been committed
+	defer tx.Rollback()
+
+	//the number is taken in this transaction so a failed checkout gives it back
+	invoice.InvoiceNumber, err = numbering.InvoiceFormat().Next(tx, invoiceTime(invoice).Year())
+	if err != nil {
+		return invoice, nil, err
+	}
+
+	if invoice.OrderType == "" {
+		invoice.OrderType = orders.DefaultType
+	}
+	query := "INSERT INTO invoices (invoice_number, invoice_date, subtotal, tax, total,customer_name,customer_id,order_type,table_number,updated_at) VALUES (?,?,?,?,?,?,?,?,?,?)"
+	result, err := tx.Exec(query, invoice.InvoiceNumber, invoice.InvoiceDate, 0.00, 0.00, 0.00, invoice.CustomerName, customerColumn(invoice.CustomerId), invoice.OrderType, invoice.TableNumber, invoice.UpdatedAt)
+	if err != nil {
+		return invoice, nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return invoice, nil, err
+	}
+	invoice.InvoiceId = strconv.FormatInt(id, 10)
+	if err := orders.SaveDelivery(tx, invoice.InvoiceId, invoice.Delivery); err != nil {
+		return invoice, nil, err
+	}
+
+	var added []models.InvoiceItem
+	for i, line := range items {
+		line.ParentItemId = nil
+		item, err := billing.AddItem(tx, int(id), line)
+		if err != nil {
+			return invoice, nil, lineError(i, err)
+		}
+		added = append(added, item)
+	}
+
+	//coupons entered at the counter are checked and counted with the invoice
+	for i, code := range invoice.CouponCodes {
+		if err := applyCoupon(tx, invoice.InvoiceId, code); err != nil {
+			if err == ErrDuplicate {
+				err = promotions.CouponError("coupon " + code + " is entered more than once")
+			}
+			return invoice, nil, err
+		}
+		invoice.CouponCodes[i] = promotions.NormalizeCode(code)
+	}
+
+	if _, err := billing.Recalculate(tx, invoice.InvoiceId); err != nil {
+		return invoice, nil, err
+	}
+	//the pizzas go to the kitchen as soon as the invoice is stored
+	if err := syncKitchen(tx, invoice.InvoiceId); err != nil {
+		return invoice, nil, err
+	}
+
+	//tenders handed over at the counter settle the invoice straight away
+	if len(invoice.Payments) > 0 {
+		if _, _, err := pay(tx, invoice.InvoiceId, invoice.Payments); err != nil {
+			return invoice, nil, err
+		}
+	}
+
+	created, err := getInvoice(tx, invoice.InvoiceId)
+	if err != nil {
+		return invoice, nil, err
+	}
+	return created, added, tx.Commit()
+}
+
+func (r *sqlInvoiceRepository) UpdateCustomer(invoiceId string, customerId int, customerName string) (models.Invoice, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	defer tx.Rollback()
+
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	//redeemed points belong to the customer the invoice was billed to when they were redeemed
+	var redeemedBy int
+	err = tx.QueryRow("SELECT COALESCE(MAX(customer_id), 0) FROM loyalty_redemptions WHERE invoice_id = ?", invoiceId).Scan(&redeemedBy)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	if redeemedBy != 0 && redeemedBy != customerId {
+		return models.Invoice{}, loyalty.ErrRedeemed
+	}
+
+	//only who the invoice is billed to changes, its totals stay as they are
+	if _, err := tx.Exec("UPDATE invoices SET customer_name=?, customer_id=?, updated_at="+database.Current.Now()+" WHERE invoice_id=?", customerName, customerColumn(customerId), invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	invoice, err := getInvoice(tx, invoiceId)
+	if err != nil {
+		return invoice, err
+	}
+	return invoice, tx.Commit()
+}
+
+func (r *sqlInvoiceRepository) UpdateOrder(invoiceId string, orderType string, tableNumber string, delivery *models.Delivery) (models.Invoice, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	defer tx.Rollback()
+
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	query := "UPDATE invoices SET order_type=?, table_number=?, updated_at=" + database.Current.Now() + " WHERE invoice_id=?"
+	if _, err := tx.Exec(query, orderType, tableNumber, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	if err := orders.SaveDelivery(tx, invoiceId, delivery); err != nil {
+		return models.Invoice{}, err
+	}
+
+	//the charges of the new order type replace those of the old one
+	if _, err := billing.Recalculate(tx, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	invoice, err := getInvoice(tx, invoiceId)
+	if err != nil {
+		return invoice, err
+	}
+	return invoice, tx.Commit()
+}
+
+func (r *sqlInvoiceRepository) Items(invoiceId string) ([]models.InvoiceItem, error) {
+	items, err := billing.LoadItems(r.db, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+
+	//lines billed before names were stored on the item are named from the catalog
+	for i := range items {
+		if err := r.resolveName(&items[i]); err != nil {
+			return nil, err
+		}
+		for j := range items[i].Modifiers {
+			if err := r.resolveName(&items[i].Modifiers[j]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return items, nil
+}
+
+//function to fill in the name of an item from the catalog when it is missing
+func (r *sqlInvoiceRepository) resolveName(item *models.InvoiceItem) error {
+	if item.Name != "" {
+		return nil
+	}
+	catalogItem, err := pricing.ResolveKind(r.db, item.ItemKind, item.ItemId)
+	if err == pricing.ErrUnknownItem {
+		item.Name = item.ItemId
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	item.Name = catalogItem.Name
+	return nil
+}
+
+func (r *sqlInvoiceRepository) AddItem(invoiceId string, item models.InvoiceItem) (models.InvoiceItem, error) {
+	//the item and the new invoice totals are written together or not at all
+	tx, err := r.db.Begin()
+	if err != nil {
+		return item, err
+	}
+	defer tx.Rollback()
+
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return item, err
+	}
+	id, _ := strconv.Atoi(invoiceId)
+
+	item, err = billing.AddItem(tx, id, item)
+	if err != nil {
+		return item, err
+	}
+	if _, err := billing.Recalculate(tx, invoiceId); err != nil {
+		return item, err
+	}
+	if err := syncKitchen(tx, invoiceId); err != nil {
+		return item, err
+	}
+	return item, tx.Commit()
+}
+
+func (r *sqlInvoiceRepository) UpdateItem(invoiceItemId string, update models.InvoiceItem) (models.InvoiceItem, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return update, err
+	}
+	defer tx.Rollback()
+
+	item, invoiceId, err := billing.UpdateItem(tx, invoiceItemId, update)
+	if err != nil {
+		return item, err
+	}
+	//the change is rolled back when the invoice is no longer open
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return item, err
+	}
+	//refresh the totals of the invoice the item belongs to
+	if _, err := billing.Recalculate(tx, invoiceId); err != nil {
+		return item, err
+	}
+	if err := syncKitchen(tx, invoiceId); err != nil {
+		return item, err
+	}
+	return item, tx.Commit()
+}
+
+func (r *sqlInvoiceRepository) DeleteItem(invoiceItemId string) (string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	invoiceId, err := billing.DeleteItem(tx, invoiceItemId)
+	if err != nil {
+		return invoiceId, err
+	}
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return invoiceId, err
+	}
+	if _, err := billing.Recalculate(tx, invoiceId); err != nil {
+		return invoiceId, err
+	}
+	if err := syncKitchen(tx, invoiceId); err != nil {
+		return invoiceId, err
+	}
+	return invoiceId, tx.Commit()
+}
+
+//function to bring the kitchen tickets of an invoice in line with its items after they changed
+func syncKitchen(db database.DBTX, invoiceId string) error {
+	items, err := billing.LoadItems(db, invoiceId)
+	if err != nil {
+		return err
+	}
+	return kitchen.Sync(db, invoiceId, items, time.Now())
+}
+
+//function to check that an invoice exists and can still be changed
+func checkOpen(db database.DBTX, invoiceId string) error {
+	err := payments.CheckOpen(db, invoiceId)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	return err
+}
+
+//function to read the moment an invoice was billed, invoices without a readable date are treated as billed now
+func invoiceTime(invoice models.Invoice) time.Time {
+	date := invoice.InvoiceDate
+	if at, err := time.Parse(promotions.AtFormat, date[:min(len(date), len(promotions.AtFormat))]); err == nil {
+		return at
+	}
+	if at, err := time.Parse(tax.DateFormat, date[:min(len(date), len(tax.DateFormat))]); err == nil {
+		return at
+	}
+	return time.Now()
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/database/databasetest"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/pricing"
+	"piza_shop_billing/backend/promotions"
+)
+
+func TestCreateInvoiceIsAtomic(t *testing.T) {
+	t.Setenv("INVOICE_NUMBER_PREFIX", "")
+	t.Setenv("INVOICE_NUMBER_DIGITS", "")
+
+	pizza := models.InvoiceItem{ItemKind: pricing.KindPizza, ItemId: "P1", Size: "large", Quantity: 2}
+	beverage := models.InvoiceItem{ItemKind: pricing.KindBeverage, ItemId: "B1", Quantity: 2}
+	tests := []struct {
+		name     string
+		items    []models.InvoiceItem
+		coupons  []string
+		payments []models.Payment
+		//error the checkout must fail with, nil when it must succeed
+		check func(error) bool
+	}{
+		{name: "unknown item", items: []models.InvoiceItem{pizza, {ItemKind: pricing.KindBeverage, ItemId: "B9", Quantity: 1}}, check: isItemError},
+		{name: "unknown size", items: []models.InvoiceItem{{ItemKind: pricing.KindPizza, ItemId: "P1", Size: "huge", Quantity: 1}}, check: isItemError},
+		{name: "unknown coupon", items: []models.InvoiceItem{pizza}, coupons: []string{"NOPE"}, check: isCouponError},
+		{name: "card above the total", items: []models.InvoiceItem{pizza}, payments: []models.Payment{{Tender: payments.TenderCard, Amount: 100}}, check: isPaymentError},
+		{name: "valid checkout", items: []models.InvoiceItem{pizza, beverage}, payments: []models.Payment{{Tender: payments.TenderCash, Amount: 40}}},
+	}
+
+	db := databasetest.Open(t)
+	repos := NewSQL(db)
+	if err := repos.Pizzas.Create(models.PizzaType{PizzaTypeId: "P1", Name: "Margherita", BasePrice: 10, Sizes: []models.PizzaSize{
+		{Size: "large", Price: 14, ToppingMultiplier: 1.5},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Beverages.Create(models.Beverage{BeverageId: "B1", Name: "Cola", Price: 2.5}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := models.Invoice{
+				InvoiceDate:  "2026-03-13 18:00:00",
+				CustomerName: "Ana",
+				CouponCodes:  tt.coupons,
+				Payments:     tt.payments,
+				UpdatedAt:    time.Now(),
+			}
+			created, items, err := repos.Invoices.Create(invoice, tt.items)
+			if tt.check != nil {
+				if !tt.check(err) {
+					t.Fatalf("Create() error = %v", err)
+				}
+				//nothing of a failed checkout is kept
+				for _, table := range []string{"invoices", "invoice_items", "kitchen_tickets", "payments", "invoice_taxes"} {
+					var rows int
+					if err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&rows); err != nil {
+						t.Fatal(err)
+					}
+					if rows != 0 {
+						t.Errorf("a failed checkout left %d rows in %s", rows, table)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			//the numbers taken by the failed checkouts were given back
+			if created.InvoiceNumber != "INV-2026-000001" {
+				t.Errorf("invoice number = %q, want INV-2026-000001", created.InvoiceNumber)
+			}
+			if len(items) != 2 || items[0].InvoiceItemId == 0 || items[1].InvoiceItemId == 0 {
+				t.Fatalf("items = %+v, want both lines stored with their ids", items)
+			}
+			if items[0].LineTotal != 28 || items[1].LineTotal != 5 {
+				t.Errorf("line totals = %v, %v, want 28, 5", items[0].LineTotal, items[1].LineTotal)
+			}
+			if created.SubTotal != 33 || created.Tax != 3.3 || created.Total != 36.3 {
+				t.Errorf("totals = %v, %v, %v, want 33, 3.3, 36.3", created.SubTotal, created.Tax, created.Total)
+			}
+			if created.Status != payments.StatusPaid || created.AmountPaid != 36.3 || len(created.Payments) != 1 || created.Payments[0].Change != 3.7 {
+				t.Errorf("invoice is %s with %v paid and payments %+v, want paid in cash with 3.70 change", created.Status, created.AmountPaid, created.Payments)
+			}
+		})
+	}
+}
+
+func isItemError(err error) bool {
+	_, ok := err.(billing.ItemError)
+	return ok
+}
+
+func isCouponError(err error) bool {
+	_, ok := err.(promotions.CouponError)
+	return ok
+}
+
+func isPaymentError(err error) bool {
+	_, ok := err.(payments.PaymentError)
+	return ok
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"piza_shop_billing/backend/kitchen"
+	"piza_shop_billing/backend/models"
+)
+
+type sqlKitchenRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlKitchenRepository) Stations() ([]models.KitchenStation, error) {
+	return kitchen.LoadStations(r.db)
+}
+
+func (r *sqlKitchenRepository) Station(stationId string) (models.KitchenStation, error) {
+	station, err := kitchen.FindStation(r.db, stationId)
+	if err == sql.ErrNoRows {
+		return station, ErrNotFound
+	}
+	return station, err
+}
+
+func (r *sqlKitchenRepository) CreateStation(station models.KitchenStation) (models.KitchenStation, error) {
+	query := "INSERT INTO kitchen_stations (name, pizza_types, active, created_at, updated_at) VALUES (?,?,?,?,?)"
+	result, err := r.db.Exec(query, station.Name, station.PizzaTypes, station.Active, station.CreatedAt, station.UpdatedAt)
+	if err != nil {
+		return station, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return station, err
+	}
+	station.StationId = int(id)
+	return station, nil
+}
+
+func (r *sqlKitchenRepository) UpdateStation(station models.KitchenStation) (models.KitchenStation, error) {
+	query := "UPDATE kitchen_stations SET name=?, pizza_types=?, active=?, updated_at=? WHERE station_id=?"
+	if _, err := r.db.Exec(query, station.Name, station.PizzaTypes, station.Active, station.UpdatedAt, station.StationId); err != nil {
+		return station, err
+	}
+	return r.Station(strconv.Itoa(station.StationId))
+}
+
+func (r *sqlKitchenRepository) DeleteStation(stationId string) error {
+	result, err := r.db.Exec("DELETE FROM kitchen_stations WHERE station_id = ?", stationId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqlKitchenRepository) Queue(stationId string) ([]models.KitchenTicket, error) {
+	return kitchen.Queue(r.db, stationId)
+}
+
+func (r *sqlKitchenRepository) Ticket(ticketId string) (models.KitchenTicket, error) {
+	ticket, err := kitchen.FindTicket(r.db, ticketId)
+	if err == sql.ErrNoRows {
+		return ticket, ErrNotFound
+	}
+	return ticket, err
+}
+
+func (r *sqlKitchenRepository) InvoiceTickets(invoiceId string) ([]models.KitchenTicket, error) {
+	var exists bool
+	if err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM invoices WHERE invoice_id = ?)", invoiceId).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return kitchen.InvoiceTickets(r.db, invoiceId)
+}
+
+//method to move a ticket to a status in a transaction so two screens bumping the same ticket do not skip a status
+func (r *sqlKitchenRepository) UpdateStatus(ticketId string, status string) (models.KitchenTicket, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.KitchenTicket{}, err
+	}
+	defer tx.Rollback()
+
+	//touching the row first holds its lock until the status is stored
+	if _, err := tx.Exec("UPDATE kitchen_tickets SET updated_at = updated_at WHERE ticket_id = ?", ticketId); err != nil {
+		return models.KitchenTicket{}, err
+	}
+	ticket, err := kitchen.FindTicket(tx, ticketId)
+	if err == sql.ErrNoRows {
+		return ticket, ErrNotFound
+	}
+	if err != nil {
+		return ticket, err
+	}
+	if ticket, err = kitchen.Advance(ticket, status, time.Now()); err != nil {
+		return ticket, err
+	}
+	if err := kitchen.SaveStatus(tx, ticket); err != nil {
+		return ticket, err
+	}
+	return ticket, tx.Commit()
+}
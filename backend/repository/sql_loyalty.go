@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/listing"
+	"piza_shop_billing/backend/loyalty"
+	"piza_shop_billing/backend/models"
+)
+
+type sqlLoyaltyRepository struct {
+	db       *sql.DB
+	settings loyalty.Settings
+}
+
+func (r *sqlLoyaltyRepository) Rules() ([]models.LoyaltyRule, error) {
+	return loyalty.LoadRules(r.db)
+}
+
+func (r *sqlLoyaltyRepository) Rule(ruleId string) (models.LoyaltyRule, error) {
+	rule, err := loyalty.FindRule(r.db, ruleId)
+	if err == sql.ErrNoRows {
+		return rule, ErrNotFound
+	}
+	return rule, err
+}
+
+func (r *sqlLoyaltyRepository) CreateRule(rule models.LoyaltyRule) (models.LoyaltyRule, error) {
+	query := "INSERT INTO loyalty_rules (name, kind, points, item_kind, item_id, active, created_at, updated_at) VALUES (?,?,?,?,?,?,?,?)"
+	result, err := r.db.Exec(query, rule.Name, rule.Kind, rule.Points, rule.ItemKind, rule.ItemId, rule.Active, rule.CreatedAt, rule.UpdatedAt)
+	if err != nil {
+		return rule, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return rule, err
+	}
+	rule.RuleId = int(id)
+	return rule, nil
+}
+
+func (r *sqlLoyaltyRepository) UpdateRule(rule models.LoyaltyRule) (models.LoyaltyRule, error) {
+	query := "UPDATE loyalty_rules SET name=?, kind=?, points=?, item_kind=?, item_id=?, active=?, updated_at=? WHERE rule_id=?"
+	if _, err := r.db.Exec(query, rule.Name, rule.Kind, rule.Points, rule.ItemKind, rule.ItemId, rule.Active, rule.UpdatedAt, rule.RuleId); err != nil {
+		return rule, err
+	}
+	return r.Rule(strconv.Itoa(rule.RuleId))
+}
+
+func (r *sqlLoyaltyRepository) DeleteRule(ruleId string) error {
+	result, err := r.db.Exec("DELETE FROM loyalty_rules WHERE rule_id = ?", ruleId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqlLoyaltyRepository) Settings() loyalty.Settings {
+	return r.settings
+}
+
+func (r *sqlLoyaltyRepository) Balance(customerId string) (int, error) {
+	id, err := customerExists(r.db, customerId)
+	if err != nil {
+		return 0, err
+	}
+	return loyalty.Balance(r.db, id)
+}
+
+func (r *sqlLoyaltyRepository) Ledger(q listing.Query) ([]models.LoyaltyEntry, listing.Page, error) {
+	total, err := q.Count(r.db, "loyalty_ledger", LoyaltyLedgerListing)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+
+	clauses, args := q.Clauses(LoyaltyLedgerListing)
+	query := "SELECT entry_id, customer_id, COALESCE(invoice_id, 0), COALESCE(redemption_id, 0), kind, points, balance, description, created_by, created_at FROM loyalty_ledger" + clauses
+	results, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, listing.Page{}, err
+	}
+	defer results.Close()
+
+	entries := []models.LoyaltyEntry{}
+	for results.Next() {
+		var e models.LoyaltyEntry
+		if err := results.Scan(&e.EntryId, &e.CustomerId, &e.InvoiceId, &e.RedemptionId, &e.Kind, &e.Points, &e.Balance, &e.Description, &e.CreatedBy, &e.CreatedAt); err != nil {
+			return nil, listing.Page{}, err
+		}
+		entries = append(entries, e)
+	}
+	if err := results.Err(); err != nil {
+		return nil, listing.Page{}, err
+	}
+	return listing.Paginate(q, entries, total)
+}
+
+func (r *sqlLoyaltyRepository) Adjust(customerId string, entry models.LoyaltyEntry) (models.LoyaltyEntry, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return entry, err
+	}
+	defer tx.Rollback()
+
+	if entry.CustomerId, err = customerExists(tx, customerId); err != nil {
+		return entry, err
+	}
+	balance, err := loyalty.Balance(tx, entry.CustomerId)
+	if err != nil {
+		return entry, err
+	}
+	if err := loyalty.CheckAdjustment(balance, entry.Points); err != nil {
+		return entry, err
+	}
+	entry.Kind = loyalty.EntryAdjust
+	if entry, err = loyalty.Record(tx, entry); err != nil {
+		return entry, err
+	}
+	return entry, tx.Commit()
+}
+
+func (r *sqlLoyaltyRepository) Redeem(invoiceId string, request models.LoyaltyRedemption) (models.Invoice, models.LoyaltyRedemption, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Invoice{}, request, err
+	}
+	defer tx.Rollback()
+
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return models.Invoice{}, request, err
+	}
+	invoice, err := getInvoice(tx, invoiceId)
+	if err != nil {
+		return invoice, request, err
+	}
+	if invoice.CustomerId == 0 {
+		return invoice, request, loyalty.LoyaltyError("points can only be redeemed on an invoice billed to a customer")
+	}
+	items, err := billing.LoadItems(tx, invoiceId)
+	if err != nil {
+		return invoice, request, err
+	}
+	redeemed, err := loyalty.LoadRedemptions(tx, invoiceId)
+	if err != nil {
+		return invoice, request, err
+	}
+	balance, err := loyalty.Balance(tx, invoice.CustomerId)
+	if err != nil {
+		return invoice, request, err
+	}
+	redemption, err := loyalty.Price(r.settings, request, balance, items, redeemed, loyalty.Redeemable(invoice))
+	if err != nil {
+		return invoice, request, err
+	}
+
+	redemption.InvoiceId, _ = strconv.Atoi(invoiceId)
+	redemption.CustomerId = invoice.CustomerId
+	redemption.CreatedAt = time.Now()
+	if redemption, err = loyalty.SaveRedemption(tx, redemption); err != nil {
+		return invoice, redemption, err
+	}
+	entry := models.LoyaltyEntry{
+		CustomerId:   invoice.CustomerId,
+		InvoiceId:    redemption.InvoiceId,
+		RedemptionId: redemption.RedemptionId,
+		Kind:         loyalty.EntryRedeem,
+		Points:       -redemption.Points,
+		Description:  loyalty.Label(redemption) + " on invoice " + loyalty.Reference(invoice.InvoiceNumber, invoiceId),
+		CreatedBy:    redemption.CreatedBy,
+		CreatedAt:    redemption.CreatedAt,
+	}
+	if _, err := loyalty.Record(tx, entry); err != nil {
+		return invoice, redemption, err
+	}
+
+	if _, err := billing.Recalculate(tx, invoiceId); err != nil {
+		return invoice, redemption, err
+	}
+	if invoice, err = getInvoice(tx, invoiceId); err != nil {
+		return invoice, redemption, err
+	}
+	return invoice, redemption, tx.Commit()
+}
+
+func (r *sqlLoyaltyRepository) CancelRedemption(invoiceId string, redemptionId string) (models.Invoice, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	defer tx.Rollback()
+
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	redemptions, err := loyalty.LoadRedemptions(tx, invoiceId)
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	redemption, ok := findRedemption(redemptions, redemptionId)
+	if !ok {
+		return models.Invoice{}, ErrNotFound
+	}
+
+	//the ledger keeps the redemption and its cancellation, only the reward leaves the invoice
+	if _, err := tx.Exec("DELETE FROM loyalty_redemptions WHERE redemption_id = ?", redemption.RedemptionId); err != nil {
+		return models.Invoice{}, err
+	}
+	entry := models.LoyaltyEntry{
+		CustomerId:   redemption.CustomerId,
+		InvoiceId:    redemption.InvoiceId,
+		RedemptionId: redemption.RedemptionId,
+		Kind:         loyalty.EntryRestore,
+		Points:       redemption.Points,
+		Description:  "Cancelled " + loyalty.Label(redemption),
+	}
+	if _, err := loyalty.Record(tx, entry); err != nil {
+		return models.Invoice{}, err
+	}
+
+	if _, err := billing.Recalculate(tx, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	invoice, err := getInvoice(tx, invoiceId)
+	if err != nil {
+		return invoice, err
+	}
+	return invoice, tx.Commit()
+}
+
+//function to check a customer exists and return its id
+func customerExists(db database.DBTX, customerId string) (int, error) {
+	var id int
+	err := db.QueryRow("SELECT customer_id FROM customers WHERE customer_id = ?", customerId).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return id, err
+}
+
+//function to find a redemption of an invoice by its id
+func findRedemption(redemptions []models.LoyaltyRedemption, redemptionId string) (models.LoyaltyRedemption, bool) {
+	for _, r := range redemptions {
+		if strconv.Itoa(r.RedemptionId) == redemptionId {
+			return r, true
+		}
+	}
+	return models.LoyaltyRedemption{}, false
+}
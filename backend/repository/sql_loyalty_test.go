@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"net/url"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/database/databasetest"
+	"piza_shop_billing/backend/listing"
+	"piza_shop_billing/backend/models"
+)
+
+func TestLedgerPagesByDay(t *testing.T) {
+	db := databasetest.Open(t)
+	repos := NewSQL(db)
+
+	customer, err := repos.Customers.Create(models.Customer{Name: "Ana", CreatedAt: time.Now(), UpdatedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := strconv.Itoa(customer.CustomerId)
+
+	//adjustments over two days, the first day ending just before midnight
+	first := time.Date(2026, 3, 12, 0, 0, 0, 0, time.Local)
+	second := first.AddDate(0, 0, 1)
+	for _, at := range []time.Time{
+		first.Add(9 * time.Hour),
+		first.Add(23*time.Hour + 59*time.Minute),
+		second.Add(10 * time.Minute),
+		second.Add(11 * time.Hour),
+		second.Add(12 * time.Hour),
+	} {
+		entry := models.LoyaltyEntry{Points: 10, Description: "welcome", CreatedBy: "admin", CreatedAt: at}
+		if _, err := repos.Loyalty.Adjust(id, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		sort string
+		want []int
+	}{
+		{sort: "created_at", want: []int{1, 2, 3, 4, 5}},
+		{sort: "-created_at", want: []int{3, 4, 5, 1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			var got []int
+			values := url.Values{"customer_id": {id}, "sort": {tt.sort}, "limit": {"2"}}
+			for pages := 0; pages < 5; pages++ {
+				q, err := listing.Parse(values, LoyaltyLedgerListing)
+				if err != nil {
+					t.Fatal(err)
+				}
+				entries, page, err := repos.Loyalty.Ledger(q)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, entry := range entries {
+					got = append(got, entry.EntryId)
+				}
+				if !page.HasMore {
+					break
+				}
+				values.Set("cursor", page.NextCursor)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/orders"
+)
+
+type sqlOrderRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlOrderRepository) Zones() ([]models.DeliveryZone, error) {
+	return orders.LoadZones(r.db)
+}
+
+func (r *sqlOrderRepository) Zone(zoneId string) (models.DeliveryZone, error) {
+	zone, err := orders.FindZone(r.db, zoneId)
+	if err == sql.ErrNoRows {
+		return zone, ErrNotFound
+	}
+	return zone, err
+}
+
+func (r *sqlOrderRepository) CreateZone(zone models.DeliveryZone) (models.DeliveryZone, error) {
+	query := "INSERT INTO delivery_zones (name, fee, free_over, postal_codes, active, created_at, updated_at) VALUES (?,?,?,?,?,?,?)"
+	result, err := r.db.Exec(query, zone.Name, zone.Fee, zone.FreeOver, zone.PostalCodes, zone.Active, zone.CreatedAt, zone.UpdatedAt)
+	if err != nil {
+		return zone, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return zone, err
+	}
+	zone.ZoneId = int(id)
+	return zone, nil
+}
+
+func (r *sqlOrderRepository) UpdateZone(zone models.DeliveryZone) (models.DeliveryZone, error) {
+	query := "UPDATE delivery_zones SET name=?, fee=?, free_over=?, postal_codes=?, active=?, updated_at=? WHERE zone_id=?"
+	if _, err := r.db.Exec(query, zone.Name, zone.Fee, zone.FreeOver, zone.PostalCodes, zone.Active, zone.UpdatedAt, zone.ZoneId); err != nil {
+		return zone, err
+	}
+	return r.Zone(strconv.Itoa(zone.ZoneId))
+}
+
+func (r *sqlOrderRepository) DeleteZone(zoneId string) error {
+	result, err := r.db.Exec("DELETE FROM delivery_zones WHERE zone_id = ?", zoneId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *sqlOrderRepository) Charges() ([]models.OrderCharge, error) {
+	return orders.LoadCharges(r.db)
+}
+
+func (r *sqlOrderRepository) Charge(chargeId string) (models.OrderCharge, error) {
+	charge, err := orders.FindCharge(r.db, chargeId)
+	if err == sql.ErrNoRows {
+		return charge, ErrNotFound
+	}
+	return charge, err
+}
+
+func (r *sqlOrderRepository) CreateCharge(charge models.OrderCharge) (models.OrderCharge, error) {
+	query := "INSERT INTO order_charges (name, order_type, kind, amount, active, effective_from, created_at, updated_at) VALUES (?,?,?,?,?,?,?,?)"
+	result, err := r.db.Exec(query, charge.Name, charge.OrderType, charge.Kind, charge.Amount, charge.Active, charge.EffectiveFrom, charge.CreatedAt, charge.UpdatedAt)
+	if err != nil {
+		return charge, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return charge, err
+	}
+	charge.ChargeId = int(id)
+	return charge, nil
+}
+
+func (r *sqlOrderRepository) UpdateCharge(charge models.OrderCharge) (models.OrderCharge, error) {
+	query := "UPDATE order_charges SET name=?, order_type=?, kind=?, amount=?, active=?, effective_from=?, updated_at=? WHERE charge_id=?"
+	if _, err := r.db.Exec(query, charge.Name, charge.OrderType, charge.Kind, charge.Amount, charge.Active, charge.EffectiveFrom, charge.UpdatedAt, charge.ChargeId); err != nil {
+		return charge, err
+	}
+	return r.Charge(strconv.Itoa(charge.ChargeId))
+}
+
+func (r *sqlOrderRepository) DeleteCharge(chargeId string) error {
+	result, err := r.db.Exec("DELETE FROM order_charges WHERE charge_id = ?", chargeId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"piza_shop_billing/backend/loyalty"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+)
+
+//function to take tenders against an open invoice inside a transaction and settle it
+//it returns the payments taken and the change due to the customer
+func pay(tx *sql.Tx, invoiceId string, tenders []models.Payment) ([]models.Payment, float64, error) {
+	//touching the invoice first holds its lock until the payment is stored, so two tills
+	//paying the same invoice take turns and the second one sees the balance the first one left
+	if _, err := tx.Exec("UPDATE invoices SET updated_at = updated_at WHERE invoice_id = ?", invoiceId); err != nil {
+		return nil, 0, err
+	}
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return nil, 0, err
+	}
+	var total, paid float64
+	query := "SELECT total, (SELECT COALESCE(SUM(amount), 0) FROM payments WHERE invoice_id = ?) FROM invoices WHERE invoice_id = ?"
+	if err := tx.QueryRow(query, invoiceId, invoiceId).Scan(&total, &paid); err != nil {
+		return nil, 0, err
+	}
+
+	taken, change, err := payments.Take(payments.Balance(payments.StatusOpen, total, paid), tenders)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range taken {
+		taken[i].CreatedAt = time.Now()
+	}
+	id, _ := strconv.Atoi(invoiceId)
+	if taken, err = payments.Save(tx, id, taken); err != nil {
+		return nil, 0, err
+	}
+	if err := payments.Settle(tx, invoiceId, total); err != nil {
+		return nil, 0, err
+	}
+	if err := loyalty.Award(tx, invoiceId); err != nil {
+		return nil, 0, err
+	}
+	return taken, change, nil
+}
+
+func (r *sqlInvoiceRepository) Pay(invoiceId string, tenders []models.Payment) (models.Invoice, []models.Payment, float64, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Invoice{}, nil, 0, err
+	}
+	defer tx.Rollback()
+
+	taken, change, err := pay(tx, invoiceId, tenders)
+	if err != nil {
+		return models.Invoice{}, nil, 0, err
+	}
+	invoice, err := getInvoice(tx, invoiceId)
+	if err != nil {
+		return invoice, nil, 0, err
+	}
+	return invoice, taken, change, tx.Commit()
+}
+
+func (r *sqlInvoiceRepository) Payments(invoiceId string) ([]models.Payment, error) {
+	var exists bool
+	if err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM invoices WHERE invoice_id = ?)", invoiceId).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return payments.Load(r.db, invoiceId)
+}
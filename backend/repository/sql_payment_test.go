@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/database/databasetest"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/pricing"
+)
+
+func TestConcurrentPaymentsTakeTurns(t *testing.T) {
+	db := databasetest.OpenFile(t)
+	repos := NewSQL(db)
+	if err := repos.Pizzas.Create(models.PizzaType{PizzaTypeId: "P1", Name: "Margherita", BasePrice: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tenders [2]models.Payment
+		//what the two tills pay together, the second till finds only what the first one left
+		paid   float64
+		change float64
+	}{
+		{
+			name:    "two cards for most of the total",
+			tenders: [2]models.Payment{{Tender: payments.TenderCard, Amount: 15}, {Tender: payments.TenderCard, Amount: 15}},
+			paid:    15,
+		},
+		{
+			name:    "two cash payments of the whole total",
+			tenders: [2]models.Payment{{Tender: payments.TenderCash, Amount: 22}, {Tender: payments.TenderCash, Amount: 22}},
+			paid:    22,
+		},
+		{
+			name:    "two cash payments of part of the total",
+			tenders: [2]models.Payment{{Tender: payments.TenderCash, Amount: 15}, {Tender: payments.TenderCash, Amount: 15}},
+			paid:    22,
+			change:  8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice, _, err := repos.Invoices.Create(models.Invoice{InvoiceDate: "2026-03-13 18:00:00", CustomerName: "Ana", UpdatedAt: time.Now()},
+				[]models.InvoiceItem{{ItemKind: pricing.KindPizza, ItemId: "P1", Quantity: 2}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if invoice.Total != 22 {
+				t.Fatalf("total = %v, want 22", invoice.Total)
+			}
+
+			var wg sync.WaitGroup
+			start := make(chan struct{})
+			changes := make([]float64, 2)
+			errs := make([]error, 2)
+			for i, tender := range tt.tenders {
+				wg.Add(1)
+				go func(i int, tender models.Payment) {
+					defer wg.Done()
+					<-start
+					_, _, changes[i], errs[i] = repos.Invoices.Pay(invoice.InvoiceId, []models.Payment{tender})
+				}(i, tender)
+			}
+			close(start)
+			wg.Wait()
+
+			change := 0.0
+			for i, err := range errs {
+				//a till that finds the invoice settled is told so, it never fails on the lock
+				switch err.(type) {
+				case nil:
+					change += changes[i]
+				case payments.PaymentError:
+				default:
+					if err != payments.ErrLocked {
+						t.Errorf("till %d failed with %v", i+1, err)
+					}
+				}
+			}
+
+			stored, err := repos.Invoices.Get(invoice.InvoiceId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.AmountPaid != tt.paid {
+				t.Errorf("amount paid = %v, want %v", stored.AmountPaid, tt.paid)
+			}
+			if change != tt.change {
+				t.Errorf("change given = %v, want %v", change, tt.change)
+			}
+		})
+	}
+}
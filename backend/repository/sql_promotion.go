@@ -0,0 +1,235 @@
+package repository
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"piza_shop_billing/backend/billing"
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/promotions"
+)
+
+type sqlPromotionRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlPromotionRepository) List() ([]models.Promotion, error) {
+	return promotions.Load(r.db)
+}
+
+func (r *sqlPromotionRepository) Get(promotionId string) (models.Promotion, error) {
+	promotion, err := promotions.Find(r.db, promotionId)
+	if err == sql.ErrNoRows {
+		return promotion, ErrNotFound
+	}
+	return promotion, err
+}
+
+func (r *sqlPromotionRepository) Create(promotion models.Promotion) (models.Promotion, error) {
+	if taken, err := r.codeTaken(promotion); err != nil || taken {
+		if taken {
+			err = ErrDuplicate
+		}
+		return promotion, err
+	}
+
+	query := `INSERT INTO promotions (name, kind, value, pizza_type_id, beverage_id, buy_quantity, free_quantity, coupon_code, usage_limit,
+		valid_from, valid_to, days, start_time, end_time, active, created_at, updated_at) VALUES (?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?,?)`
+	result, err := r.db.Exec(query, promotion.Name, promotion.Kind, promotion.Value, promotion.PizzaTypeId, promotion.BeverageId,
+		promotion.BuyQuantity, promotion.FreeQuantity, couponColumn(promotion), promotion.UsageLimit, promotion.ValidFrom, promotion.ValidTo,
+		promotion.Days, promotion.StartTime, promotion.EndTime, promotion.Active, promotion.CreatedAt, promotion.UpdatedAt)
+	if err != nil {
+		return promotion, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return promotion, err
+	}
+	promotion.PromotionId = int(id)
+	return promotion, nil
+}
+
+func (r *sqlPromotionRepository) Update(promotion models.Promotion) error {
+	if taken, err := r.codeTaken(promotion); err != nil || taken {
+		if taken {
+			err = ErrDuplicate
+		}
+		return err
+	}
+
+	//times_used is only moved by entering and removing coupons
+	query := `UPDATE promotions SET name=?, kind=?, value=?, pizza_type_id=?, beverage_id=?, buy_quantity=?, free_quantity=?, coupon_code=?,
+		usage_limit=?, valid_from=?, valid_to=?, days=?, start_time=?, end_time=?, active=?, updated_at=? WHERE promotion_id=?`
+	result, err := r.db.Exec(query, promotion.Name, promotion.Kind, promotion.Value, promotion.PizzaTypeId, promotion.BeverageId,
+		promotion.BuyQuantity, promotion.FreeQuantity, couponColumn(promotion), promotion.UsageLimit, promotion.ValidFrom, promotion.ValidTo,
+		promotion.Days, promotion.StartTime, promotion.EndTime, promotion.Active, promotion.UpdatedAt, promotion.PromotionId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		if _, err := r.Get(strconv.Itoa(promotion.PromotionId)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *sqlPromotionRepository) Delete(promotionId string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	//invoices keep their discount snapshot with the terms it was worked out with, only the coupons entered on them go
+	if _, err := tx.Exec("DELETE FROM invoice_coupons WHERE promotion_id = ?", promotionId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE invoice_discounts SET promotion_id = NULL WHERE promotion_id = ?", promotionId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM promotions WHERE promotion_id = ?", promotionId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//function to tell whether another promotion already uses the coupon code of a promotion
+func (r *sqlPromotionRepository) codeTaken(promotion models.Promotion) (bool, error) {
+	if promotion.CouponCode == "" {
+		return false, nil
+	}
+	var taken bool
+	query := "SELECT EXISTS(SELECT 1 FROM promotions WHERE coupon_code = ? AND promotion_id <> ?)"
+	err := r.db.QueryRow(query, promotion.CouponCode, promotion.PromotionId).Scan(&taken)
+	return taken, err
+}
+
+//function to store an empty coupon code as NULL so promotions without a coupon do not collide
+func couponColumn(promotion models.Promotion) interface{} {
+	if promotion.CouponCode == "" {
+		return nil
+	}
+	return promotion.CouponCode
+}
+
+//function to enter a coupon code on an invoice inside a transaction
+//the use is counted with a conditional update so concurrent cashiers cannot exceed the usage limit
+func applyCoupon(tx *sql.Tx, invoiceId string, code string) error {
+	code = promotions.NormalizeCode(code)
+
+	var invoiceDate string
+	query := "SELECT " + database.Current.FormatDateTime("invoice_date") + " FROM invoices WHERE invoice_id = ?"
+	err := tx.QueryRow(query, invoiceId).Scan(&invoiceDate)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	at, err := time.Parse(promotions.AtFormat, invoiceDate)
+	if err != nil {
+		return err
+	}
+
+	var promotion models.Promotion
+	query = "SELECT promotion_id, coupon_code, valid_from, valid_to, days, start_time, end_time, active FROM promotions WHERE coupon_code = ?"
+	err = tx.QueryRow(query, code).Scan(&promotion.PromotionId, &promotion.CouponCode, &promotion.ValidFrom, &promotion.ValidTo,
+		&promotion.Days, &promotion.StartTime, &promotion.EndTime, &promotion.Active)
+	if err == sql.ErrNoRows {
+		return promotions.CouponError("coupon " + code + " does not exist")
+	}
+	if err != nil {
+		return err
+	}
+	//the usage limit is checked by the update below
+	if err := promotions.CheckCoupon(promotion, at); err != nil {
+		return err
+	}
+
+	var entered bool
+	query = "SELECT EXISTS(SELECT 1 FROM invoice_coupons WHERE invoice_id = ? AND promotion_id = ?)"
+	if err := tx.QueryRow(query, invoiceId, promotion.PromotionId).Scan(&entered); err != nil {
+		return err
+	}
+	if entered {
+		return ErrDuplicate
+	}
+
+	query = "UPDATE promotions SET times_used = times_used + 1 WHERE promotion_id = ? AND (usage_limit = 0 OR times_used < usage_limit)"
+	result, err := tx.Exec(query, promotion.PromotionId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		if err == nil {
+			err = promotions.ErrCouponUsedUp
+		}
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO invoice_coupons (invoice_id, promotion_id, coupon_code) VALUES (?, ?, ?)", invoiceId, promotion.PromotionId, code)
+	return err
+}
+
+func (r *sqlInvoiceRepository) ApplyCoupon(invoiceId string, code string) (models.Invoice, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	defer tx.Rollback()
+
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	if err := applyCoupon(tx, invoiceId, code); err != nil {
+		return models.Invoice{}, err
+	}
+	if _, err := billing.Recalculate(tx, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	invoice, err := getInvoice(tx, invoiceId)
+	if err != nil {
+		return invoice, err
+	}
+	return invoice, tx.Commit()
+}
+
+func (r *sqlInvoiceRepository) RemoveCoupon(invoiceId string, code string) (models.Invoice, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Invoice{}, err
+	}
+	defer tx.Rollback()
+
+	if err := checkOpen(tx, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	var promotionId int
+	query := "SELECT promotion_id FROM invoice_coupons WHERE invoice_id = ? AND coupon_code = ?"
+	err = tx.QueryRow(query, invoiceId, promotions.NormalizeCode(code)).Scan(&promotionId)
+	if err == sql.ErrNoRows {
+		return models.Invoice{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Invoice{}, err
+	}
+
+	//the use of the coupon is given back
+	if _, err := tx.Exec("DELETE FROM invoice_coupons WHERE invoice_id = ? AND promotion_id = ?", invoiceId, promotionId); err != nil {
+		return models.Invoice{}, err
+	}
+	if _, err := tx.Exec("UPDATE promotions SET times_used = times_used - 1 WHERE promotion_id = ? AND times_used > 0", promotionId); err != nil {
+		return models.Invoice{}, err
+	}
+	if _, err := billing.Recalculate(tx, invoiceId); err != nil {
+		return models.Invoice{}, err
+	}
+	invoice, err := getInvoice(tx, invoiceId)
+	if err != nil {
+		return invoice, err
+	}
+	return invoice, tx.Commit()
+}
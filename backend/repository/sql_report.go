@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"database/sql"
+
+	"piza_shop_billing/backend/reports"
+)
+
+type sqlReportRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlReportRepository) Sales(from string, to string) (reports.SalesReport, error) {
+	return reports.Sales(r.db, from, to)
+}
+
+func (r *sqlReportRepository) PrepTimes(from string, to string) (reports.PrepTimeReport, error) {
+	return reports.PrepTimes(r.db, from, to)
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"database/sql"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/tax"
+)
+
+type sqlTaxRateRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlTaxRateRepository) List() ([]models.TaxRate, error) {
+	return tax.LoadRates(r.db)
+}
+
+func (r *sqlTaxRateRepository) Get(taxRateId string) (models.TaxRate, error) {
+	rate, err := tax.FindRate(r.db, taxRateId)
+	if err == sql.ErrNoRows {
+		return rate, ErrNotFound
+	}
+	return rate, err
+}
+
+func (r *sqlTaxRateRepository) Create(rate models.TaxRate) (models.TaxRate, error) {
+	query := "INSERT INTO tax_rates(name,category,rate,inclusive,effective_from,created_at,updated_at) VALUES(?,?,?,?,?,?,?)"
+	result, err := r.db.Exec(query, rate.Name, rate.Category, rate.Rate, rate.Inclusive, rate.EffectiveFrom, rate.CreatedAt, rate.UpdatedAt)
+	if err != nil {
+		return rate, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return rate, err
+	}
+	rate.TaxRateId = int(id)
+	return rate, nil
+}
+
+func (r *sqlTaxRateRepository) Update(rate models.TaxRate) error {
+	query := "UPDATE tax_rates SET name=?, category=?, rate=?, inclusive=?, effective_from=?, updated_at=? WHERE tax_rate_id=?"
+	_, err := r.db.Exec(query, rate.Name, rate.Category, rate.Rate, rate.Inclusive, rate.EffectiveFrom, rate.UpdatedAt, rate.TaxRateId)
+	return err
+}
+
+func (r *sqlTaxRateRepository) Delete(taxRateId string) error {
+	result, err := r.db.Exec("DELETE FROM tax_rates WHERE tax_rate_id = ?", taxRateId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"database/sql"
+
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/models"
+)
+
+type sqlUserRepository struct {
+	db *sql.DB
+}
+
+func (r *sqlUserRepository) List() ([]models.User, error) {
+	results, err := r.db.Query("SELECT user_id, username, role, created_at, updated_at FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var users []models.User
+	for results.Next() {
+		var user models.User
+		if err := results.Scan(&user.UserId, &user.Username, &user.Role, &user.CreatedAt, &user.UpdatedAt); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, results.Err()
+}
+
+func (r *sqlUserRepository) Get(userId string) (models.User, error) {
+	return r.find("user_id", userId)
+}
+
+func (r *sqlUserRepository) FindByUsername(username string) (models.User, error) {
+	return r.find("username", username)
+}
+
+//function to read the user whose column holds value
+func (r *sqlUserRepository) find(column string, value string) (models.User, error) {
+	var user models.User
+	query := "SELECT user_id, username, password_hash, role, created_at, updated_at FROM users WHERE " + column + " = ?"
+	err := r.db.QueryRow(query, value).Scan(&user.UserId, &user.Username, &user.PasswordHash, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return user, ErrNotFound
+	}
+	return user, err
+}
+
+func (r *sqlUserRepository) Create(user models.User) (models.User, error) {
+	user, err := auth.CreateUser(r.db, user)
+	if err == auth.ErrUsernameTaken {
+		return user, ErrDuplicate
+	}
+	return user, err
+}
+
+func (r *sqlUserRepository) Update(user models.User) error {
+	query := "UPDATE users SET password_hash=?, role=?, updated_at=? WHERE user_id=?"
+	_, err := r.db.Exec(query, user.PasswordHash, user.Role, user.UpdatedAt, user.UserId)
+	return err
+}
+
+func (r *sqlUserRepository) Delete(userId string) error {
+	_, err := r.db.Exec("DELETE FROM users WHERE user_id = ?", userId)
+	return err
+}
+
+func (r *sqlUserRepository) LastAdmin(userId string) (bool, error) {
+	return auth.LastAdmin(r.db, userId)
+}
+
+func (r *sqlUserRepository) Role(userId int) (string, error) {
+	var role string
+	err := r.db.QueryRow("SELECT role FROM users WHERE user_id = ?", userId).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", auth.ErrUnknownUser
+	}
+	return role, err
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/repository"
+)
+
+func RegisterAuthRoutes(router *mux.Router, repos repository.Repositories) {
+	//route for logging in, it is the only route open to anonymous users
+	router.HandleFunc("/auth/login", controllers.Login(repos.Users)).Methods("POST")
+
+	//routes for managing user accounts
+	router.HandleFunc("/users", auth.Require(auth.RoleAdmin, controllers.GetUsers(repos.Users))).Methods("GET")
+	router.HandleFunc("/users", auth.Require(auth.RoleAdmin, controllers.CreateUser(repos.Users))).Methods("POST")
+	router.HandleFunc("/users/{user_id}", auth.Require(auth.RoleAdmin, controllers.UpdateUser(repos.Users))).Methods("PUT")
+	router.HandleFunc("/users/{user_id}", auth.Require(auth.RoleAdmin, controllers.DeleteUser(repos.Users))).Methods("DELETE")
+}
@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"piza_shop_billing/backend/auth"
+)
+
+func TestLogin(t *testing.T) {
+	s := newServer(t)
+	run(t, s, []step{
+		{name: "right password", method: "POST", path: "/auth/login", body: object{"username": "cashier", "password": "cashier-password"}, want: http.StatusOK},
+		{name: "wrong password", method: "POST", path: "/auth/login", body: object{"username": "cashier", "password": "manager-password"}, want: http.StatusUnauthorized},
+		{name: "unknown user", method: "POST", path: "/auth/login", body: object{"username": "nobody", "password": "cashier-password"}, want: http.StatusUnauthorized},
+	})
+}
+
+func TestManageUsers(t *testing.T) {
+	s := newServer(t)
+	admin, err := s.repos.Users.FindByUsername(auth.RoleAdmin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cashier, err := s.repos.Users.FindByUsername(auth.RoleCashier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminPath := "/users/" + strconv.Itoa(admin.UserId)
+	cashierPath := "/users/" + strconv.Itoa(cashier.UserId)
+
+	run(t, s, []step{
+		{name: "taken username", role: auth.RoleAdmin, method: "POST", path: "/users", body: object{"username": "cashier", "password": "secret", "role": "cashier"}, want: http.StatusConflict},
+		{name: "unknown role", role: auth.RoleAdmin, method: "POST", path: "/users", body: object{"username": "owner", "password": "secret", "role": "owner"}, want: http.StatusBadRequest},
+		{name: "delete own account", role: auth.RoleAdmin, method: "DELETE", path: adminPath, want: http.StatusBadRequest},
+		{name: "demote the last admin", role: auth.RoleAdmin, method: "PUT", path: adminPath, body: object{"role": "manager"}, want: http.StatusConflict},
+		{name: "cashier token before the promotion", role: auth.RoleCashier, method: "GET", path: "/reports/sales", want: http.StatusForbidden},
+		{name: "promote the cashier", role: auth.RoleAdmin, method: "PUT", path: cashierPath, body: object{"role": "manager"}, want: http.StatusOK},
+		//the token still says cashier, the role is read again on every request
+		{name: "cashier token after the promotion", role: auth.RoleCashier, method: "GET", path: "/reports/sales", want: http.StatusOK},
+		{name: "delete the cashier", role: auth.RoleAdmin, method: "DELETE", path: cashierPath, want: http.StatusOK},
+		{name: "token of a deleted user", role: auth.RoleCashier, method: "GET", path: "/beverages", want: http.StatusUnauthorized},
+	})
+}
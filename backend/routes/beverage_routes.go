@@ -3,27 +3,28 @@ package routes
 import (
 	"net/http"
 	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
 	"piza_shop_billing/backend/controllers"
-	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/repository"
 )
 
-func RegisterBeverageRoutes(router *mux.Router) {
+func RegisterBeverageRoutes(router *mux.Router, repos repository.Repositories) {
 	
 	router.HandleFunc("/beverages", func(w http.ResponseWriter, r *http.Request) {
         switch r.Method {
         case http.MethodGet:
-            controllers.GetBeverages(database.DB)(w, r)
+            auth.Require(auth.RoleCashier, controllers.GetBeverages(repos.Beverages))(w, r)
         case http.MethodPost:
-            controllers.CreateBeverage(database.DB)(w, r)
+            auth.Require(auth.RoleManager, controllers.CreateBeverage(repos.Beverages))(w, r)
         default:
             http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
         }
     }).Methods("GET", "POST")
 
 	//route for updating a topping
-	router.HandleFunc("/beverages/{beverage_id}", controllers.UpdateBeverage(database.DB)).Methods("PUT")
+	router.HandleFunc("/beverages/{beverage_id}", auth.Require(auth.RoleManager, controllers.UpdateBeverage(repos.Beverages))).Methods("PUT")
 
 	//route for deleting a topping
-	router.HandleFunc("/beverages/{beverage_id}", controllers.DeleteBeverage(database.DB)).Methods("DELETE")
+	router.HandleFunc("/beverages/{beverage_id}", auth.Require(auth.RoleManager, controllers.DeleteBeverage(repos.Beverages))).Methods("DELETE")
 
 }
\ No newline at end of file
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/repository"
+)
+
+func RegisterComboRoutes(router *mux.Router, repos repository.Repositories) {
+	router.HandleFunc("/combos", auth.Require(auth.RoleCashier, controllers.GetCombos(repos.Combos))).Methods("GET")
+	router.HandleFunc("/combos", auth.Require(auth.RoleManager, controllers.CreateCombo(repos.Combos, repos.Pizzas, repos.Beverages))).Methods("POST")
+	router.HandleFunc("/combos/{combo_id}", auth.Require(auth.RoleCashier, controllers.GetCombo(repos.Combos))).Methods("GET")
+
+	//route for updating a combo and its slots
+	router.HandleFunc("/combos/{combo_id}", auth.Require(auth.RoleManager, controllers.UpdateCombo(repos.Combos, repos.Pizzas, repos.Beverages))).Methods("PUT")
+
+	//route for deleting a combo
+	router.HandleFunc("/combos/{combo_id}", auth.Require(auth.RoleManager, controllers.DeleteCombo(repos.Combos))).Methods("DELETE")
+}
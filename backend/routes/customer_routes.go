@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/repository"
+)
+
+func RegisterCustomerRoutes(router *mux.Router, repos repository.Repositories) {
+	//routes for the customer directory, cashiers look customers up and add them at the counter
+	router.HandleFunc("/customers", auth.Require(auth.RoleCashier, controllers.GetCustomers(repos.Customers))).Methods("GET")
+	router.HandleFunc("/customers", auth.Require(auth.RoleCashier, controllers.CreateCustomer(repos.Customers))).Methods("POST")
+	router.HandleFunc("/customers/{customer_id}", auth.Require(auth.RoleCashier, controllers.GetCustomer(repos.Customers))).Methods("GET")
+	router.HandleFunc("/customers/{customer_id}", auth.Require(auth.RoleCashier, controllers.UpdateCustomer(repos.Customers))).Methods("PUT")
+
+	//route for deleting a customer, their invoices keep the name they were billed to
+	router.HandleFunc("/customers/{customer_id}", auth.Require(auth.RoleManager, controllers.DeleteCustomer(repos.Customers))).Methods("DELETE")
+
+	//routes for the order history of a customer
+	router.HandleFunc("/customers/{customer_id}/invoices", auth.Require(auth.RoleCashier, controllers.GetCustomerInvoices(repos.Customers, repos.Invoices))).Methods("GET")
+	router.HandleFunc("/customers/{customer_id}/favourites", auth.Require(auth.RoleCashier, controllers.GetCustomerFavourites(repos.Customers))).Methods("GET")
+}
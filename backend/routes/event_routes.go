@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/events"
+)
+
+func RegisterEventRoutes(router *mux.Router, broker *events.Broker) {
+	//route for the live updates of the counter, kitchen and manager screens
+	router.HandleFunc("/events", auth.RequireStream(auth.RoleCashier, controllers.StreamEvents(broker))).Methods("GET")
+	//route to get the short lived token an EventSource opens the stream with
+	router.HandleFunc("/events/token", auth.Require(auth.RoleCashier, controllers.IssueStreamToken())).Methods("POST")
+}
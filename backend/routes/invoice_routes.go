@@ -2,20 +2,33 @@ package routes
 
 import (
     "github.com/gorilla/mux"
+    "piza_shop_billing/backend/auth"
     "piza_shop_billing/backend/controllers"
-    "piza_shop_billing/backend/database"
+    "piza_shop_billing/backend/repository"
 )
 
-func RegisterInvoiceRoutes(router *mux.Router) {
-    router.HandleFunc("/invoices", controllers.GetInvoices(database.DB)).Methods("GET")
-    router.HandleFunc("/invoices", controllers.CreateInvoice(database.DB)).Methods("POST")
-    router.HandleFunc("/invoices/{invoice_id}", controllers.UpdateInvoice(database.DB)).Methods("PUT")
-    router.HandleFunc("/invoices/{invoice_id}", controllers.DeleteInvoice(database.DB)).Methods("DELETE")
+func RegisterInvoiceRoutes(router *mux.Router, repos repository.Repositories) {
+    router.HandleFunc("/invoices", auth.Require(auth.RoleCashier, controllers.GetInvoices(repos.Invoices))).Methods("GET")
+    router.HandleFunc("/invoices", auth.Require(auth.RoleCashier, controllers.CreateInvoice(repos.Invoices, repos.Customers, repos.Orders))).Methods("POST")
+    router.HandleFunc("/invoices/checkout", auth.Require(auth.RoleCashier, controllers.CreateInvoiceWithItems(repos.Invoices, repos.Customers, repos.Orders))).Methods("POST")
+    router.HandleFunc("/invoices/{invoice_id}", auth.Require(auth.RoleCashier, controllers.GetInvoice(repos.Invoices))).Methods("GET")
+    router.HandleFunc("/invoices/{invoice_id}", auth.Require(auth.RoleCashier, controllers.UpdateInvoice(repos.Invoices, repos.Customers))).Methods("PUT")
 
-    router.HandleFunc("/invoices/{invoice_id}/items", controllers.GetInvoiceItems(database.DB)).Methods("GET")
-    router.HandleFunc("/invoices/{invoice_id}/items", controllers.CreateInvoiceItem(database.DB)).Methods("POST")
-    router.HandleFunc("/invoices/items/{invoice_item_id}", controllers.UpdateInvoiceItem(database.DB)).Methods("PUT")
-    router.HandleFunc("/invoices/items/{invoice_item_id}", controllers.DeleteInvoiceItem(database.DB)).Methods("DELETE")
+    router.HandleFunc("/invoices/{invoice_id}/payments", auth.Require(auth.RoleCashier, controllers.GetInvoicePayments(repos.Invoices))).Methods("GET")
+    router.HandleFunc("/invoices/{invoice_id}/payments", auth.Require(auth.RoleCashier, controllers.CreateInvoicePayment(repos.Invoices))).Methods("POST")
 
-    router.HandleFunc("/invoices/{invoice_id}/print", controllers.GeneratePrintableInvoice(database.DB)).Methods("GET")
+    //invoices are never deleted, they are voided or refunded with a credit note
+    router.HandleFunc("/invoices/{invoice_id}/void", auth.Require(auth.RoleManager, controllers.VoidInvoice(repos.Invoices))).Methods("POST")
+    router.HandleFunc("/invoices/{invoice_id}/refunds", auth.Require(auth.RoleManager, controllers.RefundInvoice(repos.Invoices))).Methods("POST")
+    router.HandleFunc("/invoices/{invoice_id}/credit-notes", auth.Require(auth.RoleCashier, controllers.GetInvoiceCreditNotes(repos.Invoices))).Methods("GET")
+    router.HandleFunc("/credit-notes/{credit_note_id}", auth.Require(auth.RoleCashier, controllers.GetCreditNote(repos.Invoices))).Methods("GET")
+
+    router.HandleFunc("/invoices/{invoice_id}/items", auth.Require(auth.RoleCashier, controllers.GetInvoiceItems(repos.Invoices))).Methods("GET")
+    router.HandleFunc("/invoices/{invoice_id}/items", auth.Require(auth.RoleCashier, controllers.CreateInvoiceItem(repos.Invoices))).Methods("POST")
+    router.HandleFunc("/invoices/items/{invoice_item_id}", auth.Require(auth.RoleCashier, controllers.UpdateInvoiceItem(repos.Invoices))).Methods("PUT")
+    router.HandleFunc("/invoices/items/{invoice_item_id}", auth.Require(auth.RoleCashier, controllers.DeleteInvoiceItem(repos.Invoices))).Methods("DELETE")
+
+    router.HandleFunc("/invoices/{invoice_id}/print", auth.Require(auth.RoleCashier, controllers.GeneratePrintableInvoice(repos.Invoices))).Methods("GET")
+    router.HandleFunc("/invoices/{invoice_id}/escpos", auth.Require(auth.RoleCashier, controllers.GenerateESCPOSInvoice(repos.Invoices))).Methods("GET")
+    router.HandleFunc("/invoices/{invoice_id}/kitchen-ticket", auth.Require(auth.RoleCashier, controllers.GenerateKitchenTicket(repos.Invoices))).Methods("GET")
 }
\ No newline at end of file
@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/payments"
+	"piza_shop_billing/backend/reports"
+)
+
+func TestCheckoutPayAndRefund(t *testing.T) {
+	s := newServer(t)
+	decode(t, s.do(t, auth.RoleManager, "POST", "/beverages", object{"beverage_id": "B1", "name": "Cola", "price": 2.5}), http.StatusOK, nil)
+	decode(t, s.do(t, auth.RoleManager, "POST", "/beverages", object{"beverage_id": "B2", "name": "Lemonade", "price": 3}), http.StatusOK, nil)
+
+	var checkout struct {
+		Invoice      models.Invoice       `json:"invoice"`
+		InvoiceItems []models.InvoiceItem `json:"invoice_items"`
+	}
+	decode(t, s.do(t, auth.RoleCashier, "POST", "/invoices/checkout", object{
+		"customer_name": "Walk-in",
+		"order_type":    "takeaway",
+		"items": []object{
+			{"item_kind": "beverage", "item_id": "B1", "quantity": 2},
+			{"item_kind": "beverage", "item_id": "B2", "quantity": 1},
+		},
+	}), http.StatusCreated, &checkout)
+	if checkout.Invoice.Total != 8.8 || len(checkout.InvoiceItems) != 2 {
+		t.Fatalf("checkout = %+v, want a total of 8.8 over 2 lines with the default tax", checkout)
+	}
+	invoicePath := "/invoices/" + checkout.Invoice.InvoiceId
+
+	run(t, s, []step{
+		{name: "refund before payment", role: auth.RoleManager, method: "POST", path: invoicePath + "/refunds", body: object{"reason": "cold", "tender": "cash"}, want: http.StatusBadRequest},
+		{name: "pay", role: auth.RoleCashier, method: "POST", path: invoicePath + "/payments", body: object{"tenders": []object{{"tender": "cash", "amount": 8.8}}}, want: http.StatusCreated},
+		{name: "cashier refunds", role: auth.RoleCashier, method: "POST", path: invoicePath + "/refunds", body: object{"reason": "cold", "tender": "cash"}, want: http.StatusForbidden},
+	})
+
+	var refund struct {
+		Invoice    models.Invoice    `json:"invoice"`
+		CreditNote models.CreditNote `json:"credit_note"`
+	}
+	decode(t, s.do(t, auth.RoleManager, "POST", invoicePath+"/refunds", object{
+		"reason": "cold",
+		"tender": "cash",
+		"items":  []object{{"invoice_item_id": checkout.InvoiceItems[1].InvoiceItemId, "quantity": 1}},
+	}), http.StatusCreated, &refund)
+	if refund.CreditNote.Total != -3.3 || refund.CreditNote.AmountRefunded != 3.3 || refund.Invoice.Status != payments.StatusPaid {
+		t.Errorf("refund = %+v, want 3.3 handed back on an invoice that stays paid", refund)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	var report reports.SalesReport
+	decode(t, s.do(t, auth.RoleManager, "GET", "/reports/sales?from="+today+"&to="+today, nil), http.StatusOK, &report)
+	if report.InvoiceCount != 1 || report.CreditNoteCount != 1 || report.Credited != -3.3 || report.Total != 5.5 {
+		t.Errorf("report = %+v, want 1 invoice of 8.8 and 1 credit note of -3.3", report)
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/repository"
+)
+
+func RegisterKitchenRoutes(router *mux.Router, repos repository.Repositories) {
+	//routes for the kitchen stations, managers decide which station makes which pizzas
+	router.HandleFunc("/kitchen/stations", auth.Require(auth.RoleCashier, controllers.GetKitchenStations(repos.Kitchen))).Methods("GET")
+	router.HandleFunc("/kitchen/stations", auth.Require(auth.RoleManager, controllers.CreateKitchenStation(repos.Kitchen, repos.Pizzas))).Methods("POST")
+	router.HandleFunc("/kitchen/stations/{station_id}", auth.Require(auth.RoleManager, controllers.UpdateKitchenStation(repos.Kitchen, repos.Pizzas))).Methods("PUT")
+	router.HandleFunc("/kitchen/stations/{station_id}", auth.Require(auth.RoleManager, controllers.DeleteKitchenStation(repos.Kitchen))).Methods("DELETE")
+
+	//routes for the kitchen screens, tickets are bumped from queued to served
+	router.HandleFunc("/kitchen/queue", auth.Require(auth.RoleCashier, controllers.GetKitchenQueue(repos.Kitchen))).Methods("GET")
+	router.HandleFunc("/kitchen/tickets/{ticket_id}", auth.Require(auth.RoleCashier, controllers.GetKitchenTicket(repos.Kitchen))).Methods("GET")
+	router.HandleFunc("/kitchen/tickets/{ticket_id}/bump", auth.Require(auth.RoleCashier, controllers.BumpKitchenTicket(repos.Kitchen))).Methods("POST")
+	router.HandleFunc("/kitchen/tickets/{ticket_id}/status", auth.Require(auth.RoleCashier, controllers.UpdateKitchenTicketStatus(repos.Kitchen))).Methods("PUT")
+	router.HandleFunc("/invoices/{invoice_id}/kitchen-tickets", auth.Require(auth.RoleCashier, controllers.GetInvoiceKitchenTickets(repos.Kitchen))).Methods("GET")
+}
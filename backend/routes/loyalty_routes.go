@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/repository"
+)
+
+func RegisterLoyaltyRoutes(router *mux.Router, repos repository.Repositories) {
+	//routes for the earning rules and redemption terms of the loyalty program, managers set the rules
+	router.HandleFunc("/loyalty/settings", auth.Require(auth.RoleCashier, controllers.GetLoyaltySettings(repos.Loyalty))).Methods("GET")
+	router.HandleFunc("/loyalty/rules", auth.Require(auth.RoleCashier, controllers.GetLoyaltyRules(repos.Loyalty))).Methods("GET")
+	router.HandleFunc("/loyalty/rules", auth.Require(auth.RoleManager, controllers.CreateLoyaltyRule(repos.Loyalty, repos.Pizzas, repos.Beverages, repos.Combos))).Methods("POST")
+	router.HandleFunc("/loyalty/rules/{rule_id}", auth.Require(auth.RoleManager, controllers.UpdateLoyaltyRule(repos.Loyalty, repos.Pizzas, repos.Beverages, repos.Combos))).Methods("PUT")
+	router.HandleFunc("/loyalty/rules/{rule_id}", auth.Require(auth.RoleManager, controllers.DeleteLoyaltyRule(repos.Loyalty))).Methods("DELETE")
+
+	//routes for the points ledger of a customer, only managers correct points by hand
+	router.HandleFunc("/customers/{customer_id}/loyalty", auth.Require(auth.RoleCashier, controllers.GetCustomerLoyalty(repos.Loyalty))).Methods("GET")
+	router.HandleFunc("/customers/{customer_id}/loyalty/adjustments", auth.Require(auth.RoleManager, controllers.AdjustCustomerLoyalty(repos.Loyalty))).Methods("POST")
+
+	//routes for redeeming points on an open invoice at the counter
+	router.HandleFunc("/invoices/{invoice_id}/loyalty/redemptions", auth.Require(auth.RoleCashier, controllers.RedeemLoyaltyPoints(repos.Loyalty))).Methods("POST")
+	router.HandleFunc("/invoices/{invoice_id}/loyalty/redemptions/{redemption_id}", auth.Require(auth.RoleCashier, controllers.CancelLoyaltyRedemption(repos.Loyalty))).Methods("DELETE")
+}
@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/repository"
+)
+
+func RegisterOrderRoutes(router *mux.Router, repos repository.Repositories) {
+	//routes for the delivery zones and their fees, managers set the zones
+	router.HandleFunc("/delivery-zones", auth.Require(auth.RoleCashier, controllers.GetDeliveryZones(repos.Orders))).Methods("GET")
+	router.HandleFunc("/delivery-zones", auth.Require(auth.RoleManager, controllers.CreateDeliveryZone(repos.Orders))).Methods("POST")
+	router.HandleFunc("/delivery-zones/{zone_id}", auth.Require(auth.RoleManager, controllers.UpdateDeliveryZone(repos.Orders))).Methods("PUT")
+	router.HandleFunc("/delivery-zones/{zone_id}", auth.Require(auth.RoleManager, controllers.DeleteDeliveryZone(repos.Orders))).Methods("DELETE")
+
+	//routes for the charges added to an order depending on its type, such as a dine-in service charge
+	router.HandleFunc("/order-charges", auth.Require(auth.RoleCashier, controllers.GetOrderCharges(repos.Orders))).Methods("GET")
+	router.HandleFunc("/order-charges", auth.Require(auth.RoleManager, controllers.CreateOrderCharge(repos.Orders))).Methods("POST")
+	router.HandleFunc("/order-charges/{charge_id}", auth.Require(auth.RoleManager, controllers.UpdateOrderCharge(repos.Orders))).Methods("PUT")
+	router.HandleFunc("/order-charges/{charge_id}", auth.Require(auth.RoleManager, controllers.DeleteOrderCharge(repos.Orders))).Methods("DELETE")
+
+	//route for changing the order type of an open invoice with its table or delivery address
+	router.HandleFunc("/invoices/{invoice_id}/order", auth.Require(auth.RoleCashier, controllers.UpdateInvoiceOrder(repos.Invoices, repos.Customers, repos.Orders))).Methods("PUT")
+}
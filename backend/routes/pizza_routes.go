@@ -3,11 +3,12 @@ package routes
 import (
 	"net/http"
 	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
 	"piza_shop_billing/backend/controllers"
-	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/repository"
 
 )
-func RegisterPizzaRoutes()  *mux.Router {
+func RegisterPizzaRoutes(repos repository.Repositories)  *mux.Router {
 
 
 	//create a new router with the mux package
@@ -15,25 +16,38 @@ func RegisterPizzaRoutes()  *mux.Router {
 	router.HandleFunc("/pizzas", func(w http.ResponseWriter, r *http.Request) {
         switch r.Method {
         case http.MethodGet:
-            controllers.GetPizzaTypes(database.DB)(w, r)
+            auth.Require(auth.RoleCashier, controllers.GetPizzaTypes(repos.Pizzas))(w, r)
         case http.MethodPost:
-            controllers.CreatePizzaType(database.DB)(w, r)
+            auth.Require(auth.RoleManager, controllers.CreatePizzaType(repos.Pizzas))(w, r)
         default:
             http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
         }
     }).Methods("GET", "POST")
 
+	//route for pricing a build-your-own pizza
+	router.HandleFunc("/pizzas/configure", auth.Require(auth.RoleCashier, controllers.ConfigurePizza(repos.Pizzas))).Methods("POST")
+
 	//route for updating a pizza type
-	router.HandleFunc("/pizzas/{pizza_type_id}", controllers.UpdatePizzaType(database.DB)).Methods("PUT")
+	router.HandleFunc("/pizzas/{pizza_type_id}", auth.Require(auth.RoleManager, controllers.UpdatePizzaType(repos.Pizzas))).Methods("PUT")
 
 	//route for deleting a pizza type
-	router.HandleFunc("/pizzas/{pizza_type_id}", controllers.DeletePizzaType(database.DB)).Methods("DELETE")
+	router.HandleFunc("/pizzas/{pizza_type_id}", auth.Require(auth.RoleManager, controllers.DeletePizzaType(repos.Pizzas))).Methods("DELETE")
+
+	//routes for the size matrix of a pizza type
+	router.HandleFunc("/pizzas/{pizza_type_id}/sizes", auth.Require(auth.RoleCashier, controllers.GetPizzaSizes(repos.Pizzas))).Methods("GET")
+	router.HandleFunc("/pizzas/{pizza_type_id}/sizes", auth.Require(auth.RoleManager, controllers.UpdatePizzaSizes(repos.Pizzas))).Methods("PUT")
 
 	//route for linking a pizza type and topping
-	router.HandleFunc("/pizzas/{pizza_type_id}/toppings", controllers.LinkPizzaTopping(database.DB)).Methods("POST")
+	router.HandleFunc("/pizzas/{pizza_type_id}/toppings", auth.Require(auth.RoleManager, controllers.LinkPizzaTopping(repos.Pizzas, repos.Toppings))).Methods("POST")
+
+	//route for get toppings of specific pizza type
+	router.HandleFunc("/pizzas/{pizza_type_id}/toppings", auth.Require(auth.RoleCashier, controllers.GetToppingsByPizzaType(repos.Pizzas))).Methods("GET")
+
+	//route for replacing every topping of a pizza type
+	router.HandleFunc("/pizzas/{pizza_type_id}/toppings", auth.Require(auth.RoleManager, controllers.ReplacePizzaToppings(repos.Pizzas, repos.Toppings))).Methods("PUT")
 
-	//route for get topping name for specific pizza type
-	router.HandleFunc("/pizzas/{pizza_type_id}/toppings", controllers.GetToppingsByPizzaType(database.DB)).Methods("GET")
+	//route for removing a topping from a pizza type
+	router.HandleFunc("/pizzas/{pizza_type_id}/toppings/{topping_id}", auth.Require(auth.RoleManager, controllers.UnlinkPizzaTopping(repos.Pizzas))).Methods("DELETE")
 
 	return router
 	
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/repository"
+)
+
+func RegisterPromotionRoutes(router *mux.Router, repos repository.Repositories) {
+	router.HandleFunc("/promotions", auth.Require(auth.RoleCashier, controllers.GetPromotions(repos.Promotions))).Methods("GET")
+	router.HandleFunc("/promotions", auth.Require(auth.RoleManager, controllers.CreatePromotion(repos.Promotions, repos.Pizzas, repos.Beverages))).Methods("POST")
+
+	//route for updating a promotion
+	router.HandleFunc("/promotions/{promotion_id}", auth.Require(auth.RoleManager, controllers.UpdatePromotion(repos.Promotions, repos.Pizzas, repos.Beverages))).Methods("PUT")
+
+	//route for deleting a promotion
+	router.HandleFunc("/promotions/{promotion_id}", auth.Require(auth.RoleManager, controllers.DeletePromotion(repos.Promotions))).Methods("DELETE")
+
+	//routes for the coupon codes entered on an invoice
+	router.HandleFunc("/invoices/{invoice_id}/coupons", auth.Require(auth.RoleCashier, controllers.ApplyInvoiceCoupon(repos.Invoices))).Methods("POST")
+	router.HandleFunc("/invoices/{invoice_id}/coupons/{coupon_code}", auth.Require(auth.RoleCashier, controllers.RemoveInvoiceCoupon(repos.Invoices))).Methods("DELETE")
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/repository"
+)
+
+func RegisterReportRoutes(router *mux.Router, repos repository.Repositories) {
+	//route for the sales report of a date range
+	router.HandleFunc("/reports/sales", auth.Require(auth.RoleManager, controllers.GetSalesReport(repos.Reports))).Methods("GET")
+
+	//route for the prep times of the kitchen over a date range
+	router.HandleFunc("/reports/prep-times", auth.Require(auth.RoleManager, controllers.GetPrepTimeReport(repos.Reports))).Methods("GET")
+}
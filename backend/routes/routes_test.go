@@ -0,0 +1,140 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/events"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/repository"
+
+	"github.com/gorilla/mux"
+)
+
+//server of the handler tests, every route over in-memory repositories
+type server struct {
+	router *mux.Router
+	repos  repository.Repositories
+	//token of a user holding each role
+	tokens map[string]string
+}
+
+//function to serve every route the way server.go does over in-memory repositories
+//an admin, a manager and a cashier are signed in, their tokens are kept by role
+func newServer(t *testing.T) *server {
+	t.Helper()
+	t.Setenv("AUTH_SECRET", "test-secret")
+	previous := database.Current
+	t.Cleanup(func() { database.Current = previous })
+
+	repos, close, err := repository.NewMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { close() })
+	repos = repository.Publishing(repos, events.NewBroker(events.DefaultHistory))
+	auth.UseDirectory(repos.Users)
+	t.Cleanup(func() { auth.UseDirectory(nil) })
+
+	router := RegisterPizzaRoutes(repos)
+	RegisterToppingRoutes(router, repos)
+	RegisterBeverageRoutes(router, repos)
+	RegisterComboRoutes(router, repos)
+	RegisterCustomerRoutes(router, repos)
+	RegisterLoyaltyRoutes(router, repos)
+	RegisterOrderRoutes(router, repos)
+	RegisterKitchenRoutes(router, repos)
+	RegisterInvoiceRoutes(router, repos)
+	RegisterPromotionRoutes(router, repos)
+	RegisterTaxRateRoutes(router, repos)
+	RegisterReportRoutes(router, repos)
+	RegisterAuthRoutes(router, repos)
+
+	s := &server{router: router, repos: repos, tokens: map[string]string{}}
+	for _, role := range []string{auth.RoleAdmin, auth.RoleManager, auth.RoleCashier} {
+		user, err := repos.Users.Create(models.User{Username: role, Password: role + "-password", Role: role})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.tokens[role], _, err = auth.IssueToken(user.UserId, user.Username, user.Role); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+//function to send a request as the user holding role, body is sent as json unless it is nil
+//an empty role sends the request without a token
+func (s *server) do(t *testing.T, role string, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var reader bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reader).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := httptest.NewRequest(method, path, &reader)
+	r.Header.Set("Content-Type", "application/json")
+	if role != "" {
+		r.Header.Set("Authorization", "Bearer "+s.tokens[role])
+	}
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, r)
+	return w
+}
+
+//function to decode the json response of a request into v, the test fails on an unexpected status
+func decode(t *testing.T, w *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, want %d: %s", w.Code, status, w.Body.String())
+	}
+	if v == nil {
+		return
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("cannot decode %s: %v", w.Body.String(), err)
+	}
+}
+
+//a request of the handler tests and the status it must get
+type step struct {
+	name   string
+	role   string
+	method string
+	path   string
+	body   interface{}
+	want   int
+}
+
+//function to send the steps in order, each one sees what the previous ones stored
+func run(t *testing.T, s *server, steps []step) {
+	t.Helper()
+	for _, st := range steps {
+		w := s.do(t, st.role, st.method, st.path, st.body)
+		if w.Code != st.want {
+			t.Errorf("%s: status = %d, want %d: %s", st.name, w.Code, st.want, w.Body.String())
+		}
+	}
+}
+
+//map written as a json request body
+type object map[string]interface{}
+
+func TestRoutesRequireARole(t *testing.T) {
+	s := newServer(t)
+	run(t, s, []step{
+		{name: "anonymous", method: "GET", path: "/beverages", want: http.StatusUnauthorized},
+		{name: "cashier reads the catalog", role: auth.RoleCashier, method: "GET", path: "/beverages", want: http.StatusOK},
+		{name: "cashier edits the catalog", role: auth.RoleCashier, method: "POST", path: "/beverages", body: object{"beverage_id": "B1", "name": "Cola", "price": 2.5}, want: http.StatusForbidden},
+		{name: "manager edits the catalog", role: auth.RoleManager, method: "POST", path: "/beverages", body: object{"beverage_id": "B1", "name": "Cola", "price": 2.5}, want: http.StatusOK},
+		{name: "cashier reads the reports", role: auth.RoleCashier, method: "GET", path: "/reports/sales", want: http.StatusForbidden},
+		{name: "manager manages users", role: auth.RoleManager, method: "GET", path: "/users", want: http.StatusForbidden},
+		{name: "admin manages users", role: auth.RoleAdmin, method: "GET", path: "/users", want: http.StatusOK},
+	})
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/controllers"
+	"piza_shop_billing/backend/repository"
+)
+
+func RegisterTaxRateRoutes(router *mux.Router, repos repository.Repositories) {
+	router.HandleFunc("/tax-rates", auth.Require(auth.RoleCashier, controllers.GetTaxRates(repos.TaxRates))).Methods("GET")
+	router.HandleFunc("/tax-rates", auth.Require(auth.RoleManager, controllers.CreateTaxRate(repos.TaxRates))).Methods("POST")
+
+	//route for updating a tax rate
+	router.HandleFunc("/tax-rates/{tax_rate_id}", auth.Require(auth.RoleManager, controllers.UpdateTaxRate(repos.TaxRates))).Methods("PUT")
+
+	//route for deleting a tax rate
+	router.HandleFunc("/tax-rates/{tax_rate_id}", auth.Require(auth.RoleManager, controllers.DeleteTaxRate(repos.TaxRates))).Methods("DELETE")
+}
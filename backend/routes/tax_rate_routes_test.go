@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/auth"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/tax"
+)
+
+func TestTaxRates(t *testing.T) {
+	s := newServer(t)
+	today := time.Now().Format(tax.DateFormat)
+	yesterday := time.Now().AddDate(0, 0, -1).Format(tax.DateFormat)
+	nextMonth := time.Now().AddDate(0, 1, 0).Format(tax.DateFormat)
+
+	var current, future models.TaxRate
+	decode(t, s.do(t, auth.RoleManager, "POST", "/tax-rates", object{"name": "GST", "rate": 0.05, "effective_from": today}), http.StatusCreated, &current)
+	decode(t, s.do(t, auth.RoleManager, "POST", "/tax-rates", object{"name": "GST", "rate": 0.12, "effective_from": nextMonth}), http.StatusCreated, &future)
+	if current.Category != tax.CategoryAll {
+		t.Errorf("category = %q, want %q", current.Category, tax.CategoryAll)
+	}
+	currentPath := "/tax-rates/" + strconv.Itoa(current.TaxRateId)
+	futurePath := "/tax-rates/" + strconv.Itoa(future.TaxRateId)
+
+	run(t, s, []step{
+		{name: "cashier lists the rates", role: auth.RoleCashier, method: "GET", path: "/tax-rates", want: http.StatusOK},
+		{name: "cashier adds a rate", role: auth.RoleCashier, method: "POST", path: "/tax-rates", body: object{"name": "GST", "rate": 5}, want: http.StatusForbidden},
+		{name: "rate in the past", role: auth.RoleManager, method: "POST", path: "/tax-rates", body: object{"name": "GST", "rate": 0.05, "effective_from": yesterday}, want: http.StatusBadRequest},
+		{name: "rename a rate in effect", role: auth.RoleManager, method: "PUT", path: currentPath, body: object{"name": "Goods and services tax"}, want: http.StatusOK},
+		{name: "change a rate in effect", role: auth.RoleManager, method: "PUT", path: currentPath, body: object{"rate": 0.18}, want: http.StatusConflict},
+		{name: "delete a rate in effect", role: auth.RoleManager, method: "DELETE", path: currentPath, want: http.StatusConflict},
+		{name: "change a future rate", role: auth.RoleManager, method: "PUT", path: futurePath, body: object{"rate": 0.18}, want: http.StatusOK},
+		{name: "delete a future rate", role: auth.RoleManager, method: "DELETE", path: futurePath, want: http.StatusOK},
+		{name: "delete it again", role: auth.RoleManager, method: "DELETE", path: futurePath, want: http.StatusNotFound},
+	})
+
+	var rates []models.TaxRate
+	decode(t, s.do(t, auth.RoleCashier, "GET", "/tax-rates", nil), http.StatusOK, &rates)
+	if len(rates) != 1 || rates[0].Name != "Goods and services tax" || rates[0].Rate != 0.05 {
+		t.Errorf("rates = %+v, want the renamed 5%% rate only", rates)
+	}
+}
@@ -3,27 +3,28 @@ package routes
 import (
 	"net/http"
 	"github.com/gorilla/mux"
+	"piza_shop_billing/backend/auth"
 	"piza_shop_billing/backend/controllers"
-	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/repository"
 )
 
-func RegisterToppingRoutes(router *mux.Router) {
+func RegisterToppingRoutes(router *mux.Router, repos repository.Repositories) {
 	//create a new router with the mux package
 	router.HandleFunc("/toppings", func(w http.ResponseWriter, r *http.Request) {
         switch r.Method {
         case http.MethodGet:
-            controllers.GetToppings(database.DB)(w, r)
+            auth.Require(auth.RoleCashier, controllers.GetToppings(repos.Toppings))(w, r)
         case http.MethodPost:
-            controllers.CreateTopping(database.DB)(w, r)
+            auth.Require(auth.RoleManager, controllers.CreateTopping(repos.Toppings))(w, r)
         default:
             http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
         }
     }).Methods("GET", "POST")
 
 	//route for updating a topping
-	router.HandleFunc("/toppings/{topping_id}", controllers.UpdateTopping(database.DB)).Methods("PUT")
+	router.HandleFunc("/toppings/{topping_id}", auth.Require(auth.RoleManager, controllers.UpdateTopping(repos.Toppings))).Methods("PUT")
 
 	//route for deleting a topping
-	router.HandleFunc("/toppings/{topping_id}", controllers.DeleteTopping(database.DB)).Methods("DELETE")
+	router.HandleFunc("/toppings/{topping_id}", auth.Require(auth.RoleManager, controllers.DeleteTopping(repos.Toppings))).Methods("DELETE")
 
 }
\ No newline at end of file
@@ -4,8 +4,14 @@ import (
     
     "net/http"
     "log"
+    "os"
+    "strings"
+    "piza_shop_billing/backend/auth"
+    "piza_shop_billing/backend/commands"
+    "piza_shop_billing/backend/events"
     "piza_shop_billing/backend/routes"
     "piza_shop_billing/backend/database"
+    "piza_shop_billing/backend/repository"
     "github.com/rs/cors"
     
 )
@@ -13,21 +19,70 @@ import (
 func main() {
 
     
-    database.Connect()
+    // The migrate command manages the schema itself, everything else starts from an up to date schema
+    if len(os.Args) > 1 && os.Args[1] == "migrate" {
+        database.Open()
+    } else {
+        database.Connect()
+    }
     defer database.DB.Close()
     log.Println("Application connected to the database")
 
+    // Run a maintenance command instead of the server when one is given
+    if len(os.Args) > 1 {
+        runCommand(os.Args[1], os.Args[2:])
+        return
+    }
+
+    // The catalog and invoice handlers work on the repositories of the configured database,
+    // changes are published to the event stream as they are stored
+    broker := events.NewBroker(events.DefaultHistory)
+    repos := repository.Publishing(repository.NewSQL(database.DB), broker)
+
+    // Tokens are checked against the current role of their user on every request
+    auth.UseDirectory(repos.Users)
+
    // Register the pizza routes
-   router := routes.RegisterPizzaRoutes()
+   router := routes.RegisterPizzaRoutes(repos)
 
    // Register the topping routes
-   routes.RegisterToppingRoutes(router)
+   routes.RegisterToppingRoutes(router, repos)
 
     // Register the beverage routes
-    routes.RegisterBeverageRoutes(router)
+    routes.RegisterBeverageRoutes(router, repos)
+
+    // Register the combo routes
+    routes.RegisterComboRoutes(router, repos)
+
+    // Register the customer directory routes
+    routes.RegisterCustomerRoutes(router, repos)
+
+    // Register the loyalty program routes
+    routes.RegisterLoyaltyRoutes(router, repos)
+
+    // Register the order type, delivery zone and order charge routes
+    routes.RegisterOrderRoutes(router, repos)
+
+    // Register the kitchen station and ticket routes
+    routes.RegisterKitchenRoutes(router, repos)
 
     // Register the invoice routes
-    routes.RegisterInvoiceRoutes(router)
+    routes.RegisterInvoiceRoutes(router, repos)
+
+    // Register the promotion and coupon routes
+    routes.RegisterPromotionRoutes(router, repos)
+
+    // Register the tax rate routes
+    routes.RegisterTaxRateRoutes(router, repos)
+
+    // Register the report routes
+    routes.RegisterReportRoutes(router, repos)
+
+    // Register the login and user management routes
+    routes.RegisterAuthRoutes(router, repos)
+
+    // Register the live event stream route
+    routes.RegisterEventRoutes(router, broker)
 
    // Define the root path
    router.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
@@ -35,10 +90,11 @@ func main() {
        w.Write([]byte(`{"message": "Welcome to the Pizza Shop Billing API"}`))
    })
 
-    // Enable CORS with default settings (allowing all origins)
+    // Enable CORS for the configured frontend origins only
     c := cors.New(cors.Options{
-        AllowedOrigins: []string{"*"}, // Allows all origins
+        AllowedOrigins: allowedOrigins(),
         AllowedMethods: []string{"GET", "POST", "PUT", "DELETE"}, 
+        AllowedHeaders: []string{"Authorization", "Content-Type", "Last-Event-ID"},
     })
 
     // start the server on port 8080
@@ -49,4 +105,36 @@ func main() {
    
 }
 
+// runCommand executes a maintenance command such as "check-totals -repair"
+func runCommand(name string, args []string) {
+    var err error
+    switch name {
+    case "check-totals":
+        err = commands.CheckTotals(database.DB, args, os.Stdout)
+    case "create-user":
+        err = commands.CreateUser(database.DB, args, os.Stdout)
+    case "migrate":
+        err = commands.Migrate(database.DB, args, os.Stdout)
+    default:
+        log.Fatalf("Unknown command %q", name)
+    }
+    if err != nil {
+        log.Fatalf("%s failed: %v", name, err)
+    }
+}
 
+// allowedOrigins reads the comma separated CORS_ALLOWED_ORIGINS variable,
+// defaulting to the React development server
+func allowedOrigins() []string {
+    value := os.Getenv("CORS_ALLOWED_ORIGINS")
+    if value == "" {
+        return []string{"http://localhost:3000"}
+    }
+    var origins []string
+    for _, origin := range strings.Split(value, ",") {
+        if origin = strings.TrimSpace(origin); origin != "" {
+            origins = append(origins, origin)
+        }
+    }
+    return origins
+}
@@ -0,0 +1,264 @@
+package tax
+
+import (
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+
+	"piza_shop_billing/backend/database"
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+)
+
+//tax categories a rate can be bound to
+const (
+	CategoryAll      = "all"
+	CategoryFood     = "food"
+	CategoryBeverage = "beverage"
+)
+
+//layout used for the effective_from date of a tax rate
+const DateFormat = "2006-01-02"
+
+//rule applied when no configured rate matches, this keeps the original flat 10% behaviour
+var DefaultRate = models.TaxRate{
+	Name:          "Default",
+	Category:      CategoryAll,
+	Rate:          0.10,
+	EffectiveFrom: "0001-01-01",
+}
+
+//Line is a taxable amount belonging to a category
+type Line struct {
+	Category string
+	Amount   float64
+}
+
+//Result holds the totals of a tax calculation and the rules that produced them
+type Result struct {
+	SubTotal float64
+	Tax      float64
+	Total    float64
+	Taxes    []models.InvoiceTax
+}
+
+//function to check whether a category name is supported
+func ValidCategory(category string) bool {
+	switch category {
+	case CategoryAll, CategoryFood, CategoryBeverage:
+		return true
+	}
+	return false
+}
+
+//function to map a catalog item kind to its tax category
+func CategoryFor(kind string) string {
+	switch kind {
+	case pricing.KindPizza, pricing.KindTopping:
+		return CategoryFood
+	case pricing.KindBeverage:
+		return CategoryBeverage
+	}
+	return CategoryAll
+}
+
+//function to split an invoice line and its modifiers into taxable amounts per category
+//a combo line is split over its components so its pizzas and drinks keep their own rates
+func ItemLines(item models.InvoiceItem) []Line {
+	var lines []Line
+	if len(item.Components) > 0 {
+		for _, component := range item.Components {
+			lines = append(lines, Line{Category: CategoryFor(component.ItemKind), Amount: component.Price * float64(item.Quantity)})
+		}
+	} else {
+		lines = append(lines, Line{Category: CategoryFor(item.ItemKind), Amount: item.LineTotal})
+	}
+	for _, modifier := range item.Modifiers {
+		lines = append(lines, Line{Category: CategoryFor(modifier.ItemKind), Amount: modifier.LineTotal})
+	}
+	return lines
+}
+
+//function to load every configured tax rate
+func LoadRates(db database.DBTX) ([]models.TaxRate, error) {
+	return loadRates(db, "")
+}
+
+//function to load one tax rate, sql.ErrNoRows is returned when it does not exist
+func FindRate(db database.DBTX, taxRateId string) (models.TaxRate, error) {
+	found, err := loadRates(db, " WHERE tax_rate_id = ?", taxRateId)
+	if err != nil {
+		return models.TaxRate{}, err
+	}
+	if len(found) == 0 {
+		return models.TaxRate{}, sql.ErrNoRows
+	}
+	return found[0], nil
+}
+
+func loadRates(db database.DBTX, clause string, args ...interface{}) ([]models.TaxRate, error) {
+	query := "SELECT tax_rate_id, name, category, rate, inclusive, " + database.Current.FormatDate("effective_from") + " FROM tax_rates" + clause
+	results, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var rates []models.TaxRate
+	for results.Next() {
+		var rate models.TaxRate
+		if err := results.Scan(&rate.TaxRateId, &rate.Name, &rate.Category, &rate.Rate, &rate.Inclusive, &rate.EffectiveFrom); err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, results.Err()
+}
+
+//function to tell whether a rate has taken effect on a given day, invoices may already have been taxed with it
+func Started(rate models.TaxRate, on time.Time) bool {
+	return rate.EffectiveFrom <= on.Format(DateFormat)
+}
+
+//function to pick the rate in force for a category on a given day
+//a rate bound to the exact category wins over a rate bound to "all"
+func SelectRate(rates []models.TaxRate, category string, on time.Time) models.TaxRate {
+	var best *models.TaxRate
+	for i := range rates {
+		rate := &rates[i]
+		if !Started(*rate, on) {
+			continue
+		}
+		if rate.Category != category && rate.Category != CategoryAll {
+			continue
+		}
+		if best == nil || moreSpecific(rate, best, category) {
+			best = rate
+		}
+	}
+
+	if best == nil {
+		return DefaultRate
+	}
+	return *best
+}
+
+//function to decide whether rate a should be preferred over rate b
+func moreSpecific(a, b *models.TaxRate, category string) bool {
+	aExact := a.Category == category
+	bExact := b.Category == category
+	if aExact != bExact {
+		return aExact
+	}
+	if a.EffectiveFrom != b.EffectiveFrom {
+		return a.EffectiveFrom > b.EffectiveFrom
+	}
+	return a.TaxRateId > b.TaxRateId
+}
+
+//function to calculate subtotal, tax and total for a set of lines
+//inclusive rates extract the tax from the line amounts, exclusive rates add it on top
+func Calculate(rates []models.TaxRate, lines []Line, on time.Time) Result {
+	var result Result
+
+	//group the lines by the category and the rate that applies to them
+	type group struct {
+		category string
+		rate     models.TaxRate
+		amount   float64
+	}
+	var groups []*group
+	for _, line := range lines {
+		rate := SelectRate(rates, line.Category, on)
+		result.SubTotal += line.Amount
+
+		var match *group
+		for _, g := range groups {
+			if g.category == line.Category && g.rate.TaxRateId == rate.TaxRateId && g.rate.Name == rate.Name {
+				match = g
+				break
+			}
+		}
+		if match == nil {
+			match = &group{category: line.Category, rate: rate}
+			groups = append(groups, match)
+		}
+		match.amount += line.Amount
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].category < groups[j].category })
+
+	exclusiveTax := 0.0
+	for _, g := range groups {
+		amount := Round(g.amount)
+
+		var taxAmount float64
+		if g.rate.Inclusive {
+			taxAmount = Round(amount - amount/(1+g.rate.Rate))
+		} else {
+			taxAmount = Round(amount * g.rate.Rate)
+			exclusiveTax += taxAmount
+		}
+
+		result.Tax += taxAmount
+		result.Taxes = append(result.Taxes, models.InvoiceTax{
+			TaxRateId:     g.rate.TaxRateId,
+			Name:          g.rate.Name,
+			Category:      g.category,
+			Rate:          g.rate.Rate,
+			Inclusive:     g.rate.Inclusive,
+			TaxableAmount: amount,
+			TaxAmount:     taxAmount,
+		})
+	}
+
+	result.SubTotal = Round(result.SubTotal)
+	result.Tax = Round(result.Tax)
+	result.Total = Round(result.SubTotal + exclusiveTax)
+	return result
+}
+
+//function to replace the tax snapshot stored against an invoice
+func SaveInvoiceTaxes(db database.DBTX, invoiceId string, taxes []models.InvoiceTax) error {
+	if _, err := db.Exec("DELETE FROM invoice_taxes WHERE invoice_id = ?", invoiceId); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO invoice_taxes (invoice_id, tax_rate_id, name, category, rate, inclusive, taxable_amount, tax_amount) VALUES (?, ?, ?, ?, ?, ?, ?, ?)"
+	for _, t := range taxes {
+		//the built in default rule has no row in tax_rates
+		var taxRateId interface{}
+		if t.TaxRateId != 0 {
+			taxRateId = t.TaxRateId
+		}
+		if _, err := db.Exec(query, invoiceId, taxRateId, t.Name, t.Category, t.Rate, t.Inclusive, t.TaxableAmount, t.TaxAmount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//function to load the tax snapshot stored against an invoice
+func LoadInvoiceTaxes(db database.DBTX, invoiceId string) ([]models.InvoiceTax, error) {
+	query := "SELECT invoice_tax_id, invoice_id, COALESCE(tax_rate_id, 0), name, category, rate, inclusive, taxable_amount, tax_amount FROM invoice_taxes WHERE invoice_id = ? ORDER BY invoice_tax_id"
+	results, err := db.Query(query, invoiceId)
+	if err != nil {
+		return nil, err
+	}
+	defer results.Close()
+
+	var taxes []models.InvoiceTax
+	for results.Next() {
+		var t models.InvoiceTax
+		if err := results.Scan(&t.InvoiceTaxId, &t.InvoiceId, &t.TaxRateId, &t.Name, &t.Category, &t.Rate, &t.Inclusive, &t.TaxableAmount, &t.TaxAmount); err != nil {
+			return nil, err
+		}
+		taxes = append(taxes, t)
+	}
+	return taxes, results.Err()
+}
+
+//function to round a currency amount to two decimal places
+func Round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,159 @@
+package tax
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"piza_shop_billing/backend/models"
+	"piza_shop_billing/backend/pricing"
+)
+
+func TestCalculate(t *testing.T) {
+	on := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	vat := models.TaxRate{TaxRateId: 1, Name: "VAT", Category: CategoryAll, Rate: 0.20, EffectiveFrom: "2026-01-01"}
+	vatIncl := models.TaxRate{TaxRateId: 2, Name: "VAT", Category: CategoryAll, Rate: 0.20, Inclusive: true, EffectiveFrom: "2026-01-01"}
+	food := models.TaxRate{TaxRateId: 3, Name: "Food", Category: CategoryFood, Rate: 0.05, EffectiveFrom: "2026-01-01"}
+	drinks := models.TaxRate{TaxRateId: 4, Name: "Drinks", Category: CategoryBeverage, Rate: 0.25, Inclusive: true, EffectiveFrom: "2026-01-01"}
+	later := models.TaxRate{TaxRateId: 5, Name: "Food", Category: CategoryFood, Rate: 0.08, EffectiveFrom: "2026-06-01"}
+
+	tests := []struct {
+		name  string
+		rates []models.TaxRate
+		lines []Line
+		want  Result
+	}{
+		{
+			name:  "no rates falls back to the default",
+			lines: []Line{{Category: CategoryFood, Amount: 10}},
+			want: Result{SubTotal: 10, Tax: 1, Total: 11, Taxes: []models.InvoiceTax{
+				{Name: "Default", Category: CategoryFood, Rate: 0.10, TaxableAmount: 10, TaxAmount: 1},
+			}},
+		},
+		{
+			name:  "exclusive rate is added on top",
+			rates: []models.TaxRate{vat},
+			lines: []Line{{Category: CategoryFood, Amount: 10}, {Category: CategoryFood, Amount: 5}},
+			want: Result{SubTotal: 15, Tax: 3, Total: 18, Taxes: []models.InvoiceTax{
+				{TaxRateId: 1, Name: "VAT", Category: CategoryFood, Rate: 0.20, TaxableAmount: 15, TaxAmount: 3},
+			}},
+		},
+		{
+			name:  "inclusive rate is extracted from the amount",
+			rates: []models.TaxRate{vatIncl},
+			lines: []Line{{Category: CategoryFood, Amount: 12}},
+			want: Result{SubTotal: 12, Tax: 2, Total: 12, Taxes: []models.InvoiceTax{
+				{TaxRateId: 2, Name: "VAT", Category: CategoryFood, Rate: 0.20, Inclusive: true, TaxableAmount: 12, TaxAmount: 2},
+			}},
+		},
+		{
+			name:  "category rate wins over the rate for all",
+			rates: []models.TaxRate{vat, food},
+			lines: []Line{{Category: CategoryFood, Amount: 20}, {Category: CategoryBeverage, Amount: 5}},
+			want: Result{SubTotal: 25, Tax: 2, Total: 27, Taxes: []models.InvoiceTax{
+				{TaxRateId: 1, Name: "VAT", Category: CategoryBeverage, Rate: 0.20, TaxableAmount: 5, TaxAmount: 1},
+				{TaxRateId: 3, Name: "Food", Category: CategoryFood, Rate: 0.05, TaxableAmount: 20, TaxAmount: 1},
+			}},
+		},
+		{
+			name:  "inclusive and exclusive categories on one invoice",
+			rates: []models.TaxRate{food, drinks},
+			lines: []Line{{Category: CategoryFood, Amount: 30}, {Category: CategoryBeverage, Amount: 5}},
+			want: Result{SubTotal: 35, Tax: 2.5, Total: 36.5, Taxes: []models.InvoiceTax{
+				{TaxRateId: 4, Name: "Drinks", Category: CategoryBeverage, Rate: 0.25, Inclusive: true, TaxableAmount: 5, TaxAmount: 1},
+				{TaxRateId: 3, Name: "Food", Category: CategoryFood, Rate: 0.05, TaxableAmount: 30, TaxAmount: 1.5},
+			}},
+		},
+		{
+			name:  "rate effective after the invoice date is ignored",
+			rates: []models.TaxRate{food, later},
+			lines: []Line{{Category: CategoryFood, Amount: 10}},
+			want: Result{SubTotal: 10, Tax: 0.5, Total: 10.5, Taxes: []models.InvoiceTax{
+				{TaxRateId: 3, Name: "Food", Category: CategoryFood, Rate: 0.05, TaxableAmount: 10, TaxAmount: 0.5},
+			}},
+		},
+		{
+			name:  "tax is rounded per category",
+			rates: []models.TaxRate{food},
+			lines: []Line{{Category: CategoryFood, Amount: 3.33}, {Category: CategoryFood, Amount: 3.33}},
+			want: Result{SubTotal: 6.66, Tax: 0.33, Total: 6.99, Taxes: []models.InvoiceTax{
+				{TaxRateId: 3, Name: "Food", Category: CategoryFood, Rate: 0.05, TaxableAmount: 6.66, TaxAmount: 0.33},
+			}},
+		},
+		{
+			name: "no lines",
+			want: Result{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Calculate(tt.rates, tt.lines, on)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Calculate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectRate(t *testing.T) {
+	on := time.Date(2026, 3, 14, 0, 0, 0, 0, time.UTC)
+	rates := []models.TaxRate{
+		{TaxRateId: 1, Category: CategoryAll, EffectiveFrom: "2025-01-01"},
+		{TaxRateId: 2, Category: CategoryAll, EffectiveFrom: "2026-01-01"},
+		{TaxRateId: 3, Category: CategoryFood, EffectiveFrom: "2025-01-01"},
+		{TaxRateId: 4, Category: CategoryFood, EffectiveFrom: "2026-03-15"},
+		{TaxRateId: 5, Category: CategoryBeverage, EffectiveFrom: "2026-03-14"},
+		{TaxRateId: 6, Category: CategoryBeverage, EffectiveFrom: "2026-03-14"},
+	}
+	tests := []struct {
+		category string
+		want     int
+	}{
+		//the exact category is preferred even when a rate for all is newer
+		{category: CategoryFood, want: 3},
+		//the newest of the rates for a category, the highest id on a tie
+		{category: CategoryBeverage, want: 6},
+		{category: CategoryAll, want: 2},
+	}
+	for _, tt := range tests {
+		if got := SelectRate(rates, tt.category, on); got.TaxRateId != tt.want {
+			t.Errorf("SelectRate(%q) = rate %d, want %d", tt.category, got.TaxRateId, tt.want)
+		}
+	}
+}
+
+func TestItemLines(t *testing.T) {
+	tests := []struct {
+		name string
+		item models.InvoiceItem
+		want []Line
+	}{
+		{
+			name: "pizza with a topping",
+			item: models.InvoiceItem{ItemKind: pricing.KindPizza, LineTotal: 20, Modifiers: []models.InvoiceItem{
+				{ItemKind: pricing.KindTopping, LineTotal: 2},
+			}},
+			want: []Line{{Category: CategoryFood, Amount: 20}, {Category: CategoryFood, Amount: 2}},
+		},
+		{
+			name: "beverage",
+			item: models.InvoiceItem{ItemKind: pricing.KindBeverage, LineTotal: 3},
+			want: []Line{{Category: CategoryBeverage, Amount: 3}},
+		},
+		{
+			name: "combo is split over its components",
+			item: models.InvoiceItem{ItemKind: pricing.KindCombo, Quantity: 2, LineTotal: 24, Components: []models.ComboComponent{
+				{ItemKind: pricing.KindPizza, Price: 10},
+				{ItemKind: pricing.KindBeverage, Price: 2},
+			}},
+			want: []Line{{Category: CategoryFood, Amount: 20}, {Category: CategoryBeverage, Amount: 4}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ItemLines(tt.item); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ItemLines() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ function App() {
     <>
       <Router>
         <Routes>
+          <Route path="/" element={<LoginPage />} />
           <Route path="/dashboard" element={<Dashboard />} />
           <Route path="/pizzatypes" element={<Pizzatype />} />
           <Route path="/toppings" element={<Topping />} />
@@ -34,9 +34,11 @@ const Beverage = () => {
   // Function to fetch beverage types from the backend using Axios
   const fetchBeverages = async () => {
     try {
-      const response = await axios.get("http://localhost:8080/beverages"); // Replace with your backend endpoint
-      if (Array.isArray(response.data)) {
-        setBeverages(response.data);
+      const response = await axios.get("http://localhost:8080/beverages", {
+        params: { limit: 200 }, // lists come back one page at a time
+      }); // Replace with your backend endpoint
+      if (Array.isArray(response.data.items)) {
+        setBeverages(response.data.items);
       } else {
         setBeverages([]); // Ensure beverages is always an array
       }
@@ -44,10 +44,12 @@ const Pizzatype = () => {
   // Function to fetch pizza types from the backend using Axios
   const fetchPizzaTypes = async () => {
     try {
-      const response = await axios.get("http://localhost:8080/pizzas");
+      const response = await axios.get("http://localhost:8080/pizzas", {
+        params: { limit: 200 }, // lists come back one page at a time
+      });
       console.log(response.data);
-      if (Array.isArray(response.data)) {
-        setPizzaTypes(response.data);
+      if (Array.isArray(response.data.items)) {
+        setPizzaTypes(response.data.items);
       } else {
         setPizzaTypes([]);
       }
@@ -63,7 +65,7 @@ const Pizzatype = () => {
         `http://localhost:8080/pizzas/${pizzaTypeId}/toppings`
       );
       console.log(response.data);
-      return response.data; // Return topping names as an array
+      return response.data; // Return the linked toppings as an array
     } catch (err) {
       console.error(
         `Error fetching toppings for pizza type ${pizzaTypeId}:`,
@@ -77,8 +79,8 @@ const Pizzatype = () => {
   const fetchAllToppings = async () => {
     const toppingMap = {};
     for (const pizza of pizzaTypes) {
-      const toppingNames = await fetchToppingsForPizzaType(pizza.pizza_type_id);
-      toppingMap[pizza.pizza_type_id] = toppingNames;
+      const linkedToppings = await fetchToppingsForPizzaType(pizza.pizza_type_id);
+      toppingMap[pizza.pizza_type_id] = linkedToppings;
     }
     setToppings(toppingMap); // Store toppings in state
   };
@@ -213,12 +215,30 @@ const Pizzatype = () => {
                 <TableRow key={pizza.pizza_type_id}>
                   <TableCell>{pizza.pizza_type_id}</TableCell>
                   <TableCell>{pizza.name}</TableCell>
-                  <TableCell>{pizza.size}</TableCell>
-                  <TableCell>${pizza.base_price.toFixed(2)}</TableCell>
+                  <TableCell>
+                    {Array.isArray(pizza.sizes)
+                      ? pizza.sizes.map((size) => size.size).join(", ")
+                      : pizza.size}
+                  </TableCell>
+                  <TableCell>
+                    {/* Sized pizzas list one price per size */}
+                    {Array.isArray(pizza.sizes)
+                      ? pizza.sizes
+                          .map((size) => `$${size.price.toFixed(2)}`)
+                          .join(" / ")
+                      : `$${pizza.base_price.toFixed(2)}`}
+                  </TableCell>
                   <TableCell>{pizza.description}</TableCell>
                   <TableCell>
+                    {/* Extras are charged on top of the pizza price */}
                     {Array.isArray(toppings[pizza.pizza_type_id])
-                      ? toppings[pizza.pizza_type_id].join(", ")
+                      ? toppings[pizza.pizza_type_id]
+                          .map((topping) =>
+                            topping.is_default
+                              ? topping.name
+                              : `${topping.name} (+$${topping.price.toFixed(2)})`
+                          )
+                          .join(", ")
                       : "Loading..."}
                   </TableCell>
                   <TableCell>
@@ -34,9 +34,11 @@ const Topping = () => {
   // Function to fetch topping types from the backend using Axios
   const fetchToppings = async () => {
     try {
-      const response = await axios.get("http://localhost:8080/toppings"); // Replace with your backend endpoint
-      if (Array.isArray(response.data)) {
-        setToppings(response.data);
+      const response = await axios.get("http://localhost:8080/toppings", {
+        params: { limit: 200 }, // lists come back one page at a time
+      }); // Replace with your backend endpoint
+      if (Array.isArray(response.data.items)) {
+        setToppings(response.data.items);
       } else {
         setToppings([]); // Ensure toppings is always an array
       }
@@ -1,3 +1,6 @@
+import { useState } from "react";
+import axios from "axios";
+import { useNavigate } from "react-router-dom";
 import {
   Container,
   Paper,
@@ -5,10 +8,34 @@ import {
   Box,
   TextField,
   Button,
+  Alert,
 } from "@mui/material";
 
 const LoginPage = () => {
-  const handleSubmit = () => console.log("login");
+  const navigate = useNavigate();
+  const [username, setUsername] = useState("");
+  const [password, setPassword] = useState("");
+  const [error, setError] = useState("");
+
+  // Log in against the backend and keep the token for later requests
+  const handleSubmit = async (event) => {
+    event.preventDefault();
+    setError("");
+    try {
+      const response = await axios.post("http://localhost:8080/auth/login", {
+        username,
+        password,
+      });
+      localStorage.setItem("token", response.data.token);
+      localStorage.setItem("role", response.data.role);
+      axios.defaults.headers.common["Authorization"] = `Bearer ${response.data.token}`;
+      navigate("/dashboard");
+    } catch (err) {
+      console.error("Error logging in:", err);
+      setError("Invalid username or password.");
+    }
+  };
+
   return (
     <Container maxWidth="xs">
       <Paper elevation={10} sx={{ marginTop: 8, padding: 2 }}>
@@ -16,11 +43,18 @@ const LoginPage = () => {
           LOG IN
         </Typography>
         <Box component="form" onSubmit={handleSubmit} noValidate sx={{ mt: 1 }}>
+          {error && (
+            <Alert severity="error" sx={{ mb: 2 }}>
+              {error}
+            </Alert>
+          )}
           <TextField
             placeholder="username"
             fullWidth
             required
             autoFocus
+            value={username}
+            onChange={(e) => setUsername(e.target.value)}
             sx={{ mb: 2 }}
           ></TextField>
           <TextField
@@ -28,6 +62,8 @@ const LoginPage = () => {
             fullWidth
             required
             type="password"
+            value={password}
+            onChange={(e) => setPassword(e.target.value)}
             sx={{ mb: 2 }}
           ></TextField>
           <Button type="submit" variant="contained" fullWidth sx={{ mt: 1 }}>